@@ -0,0 +1,294 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"whirlwind/common"
+	"whirlwind/mods"
+	"whirlwind/syntax"
+	"whirlwind/typing"
+)
+
+// Build Cache
+// -----------
+// The build cache stores the exported interface of every package that has been
+// successfully built so that unchanged dependencies don't need to be parsed,
+// resolved, and validated again on every build.  Each entry is keyed by the
+// content hash of its package: a hash of its source files and the build target.
+// The entry also stores the content hashes of all of the package's
+// dependencies.  An entry can only be used if the content hashes of every
+// package reachable from it (through its dependencies) still match.  The main
+// package is never loaded from the cache.
+
+// cacheVersion is the version of the format of the cache entries.  It must be
+// incremented whenever the format of the entries (or of the encoded types)
+// changes so that old entries are ignored.
+const cacheVersion = 6
+
+// CacheDirectory returns the path to the directory that stores the build cache
+// for a given build directory
+func CacheDirectory(buildDir string) string {
+	return filepath.Join(buildDir, ".whirl", "cache")
+}
+
+// CleanCache removes the build cache of a given build directory
+func CleanCache(buildDir string) error {
+	return os.RemoveAll(CacheDirectory(buildDir))
+}
+
+// cacheEntry is a single entry in the build cache
+type cacheEntry struct {
+	Version       int    `json:"version"`
+	RootDirectory string `json:"root_directory"`
+
+	// ModulePath is the path to the module the package belongs to and
+	// ModuleHash is the hash of its module file.  ModulePath is empty if the
+	// package has no module.
+	ModulePath string `json:"module_path,omitempty"`
+	ModuleHash string `json:"module_hash,omitempty"`
+
+	Dependencies []*cacheDependency `json:"dependencies"`
+	Interface    *packageInterface  `json:"interface"`
+}
+
+// cacheDependency is a package that a cached package imports
+type cacheDependency struct {
+	RootDirectory string `json:"root_directory"`
+	ContentHash   string `json:"content_hash"`
+}
+
+// -----------------------------------------------------------------------------
+
+// loadCachedPackage attempts to load a package from the build cache.  It will
+// only succeed if the package and all of its dependencies are unchanged since
+// it was cached.  All of its dependencies are loaded from the cache as well.
+func (c *Compiler) loadCachedPackage(abspath string) (*common.WhirlPackage, bool) {
+	if abspath == c.buildDirectory || !c.cacheClosureValid(abspath) {
+		return nil, false
+	}
+
+	entry := c.cacheEntries[abspath]
+
+	var mod *mods.Module
+	if entry.ModulePath != "" {
+		mod = c.getModule(entry.ModulePath)
+	}
+
+	pkg := &common.WhirlPackage{
+		PackageID:           getPackageID(abspath),
+		Name:                syntax.NormalizeIdentifier(filepath.Base(abspath)),
+		RootDirectory:       abspath,
+		Files:               make(map[string]*common.WhirlFile),
+		ImportTable:         make(map[uint]*common.WhirlImport),
+		OperatorDefinitions: make(map[int][]*common.WhirlOperatorDefinition),
+		GlobalTable:         make(map[string]*common.Symbol),
+		GlobalBindings:      &typing.BindingRegistry{},
+		ParentModule:        mod,
+		Initialized:         true,
+		Precompiled:         true,
+	}
+
+	if !entry.Interface.decode(pkg, nil) {
+		return nil, false
+	}
+
+	// add the package to the dependency graph before we load its dependencies
+	// so that cyclic dependencies resolve to it
+	c.addPackage(pkg)
+
+	for _, dep := range entry.Dependencies {
+		depPkg, ok := c.depGraph[getPackageID(dep.RootDirectory)]
+		if !ok {
+			// this should always succeed since the whole closure was checked
+			if depPkg, ok = c.loadCachedPackage(dep.RootDirectory); !ok {
+				return nil, false
+			}
+		}
+
+		pkg.ImportTable[depPkg.PackageID] = &common.WhirlImport{
+			PackageRef:      depPkg,
+			ImportedSymbols: make(map[string]*common.Symbol),
+		}
+	}
+
+	return pkg, true
+}
+
+// cacheClosureValid checks if the cache entry of a package and the cache
+// entries of every package it depends on (directly or indirectly) are valid.
+func (c *Compiler) cacheClosureValid(abspath string) bool {
+	visited := map[string]struct{}{abspath: {}}
+	for queue := []string{abspath}; len(queue) > 0; queue = queue[1:] {
+		entry, ok := c.lookupCacheEntry(queue[0])
+		if !ok {
+			return false
+		}
+
+		for _, dep := range entry.Dependencies {
+			if _, ok := visited[dep.RootDirectory]; !ok {
+				visited[dep.RootDirectory] = struct{}{}
+				queue = append(queue, dep.RootDirectory)
+			}
+		}
+	}
+
+	return true
+}
+
+// lookupCacheEntry looks up and checks the cache entry for a package (without
+// checking its dependencies' entries).  An entry is valid if its package and
+// module and the packages it imports are unchanged.  The result is memoized.
+func (c *Compiler) lookupCacheEntry(abspath string) (*cacheEntry, bool) {
+	if entry, ok := c.cacheEntries[abspath]; ok {
+		return entry, entry != nil
+	}
+
+	// mark the entry as invalid until it is shown to be valid
+	c.cacheEntries[abspath] = nil
+
+	hash, ok := c.contentHash(abspath)
+	if !ok {
+		return nil, false
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(CacheDirectory(c.buildDirectory), hash+".json"))
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &cacheEntry{}
+	if json.Unmarshal(data, entry) != nil || entry.Version != cacheVersion || entry.RootDirectory != abspath || entry.Interface == nil {
+		return nil, false
+	}
+
+	if entry.ModulePath != "" && hashFile(mods.ModuleFilePath(entry.ModulePath)) != entry.ModuleHash {
+		return nil, false
+	}
+
+	for _, dep := range entry.Dependencies {
+		if depHash, ok := c.contentHash(dep.RootDirectory); !ok || depHash != dep.ContentHash {
+			return nil, false
+		}
+	}
+
+	c.cacheEntries[abspath] = entry
+	return entry, true
+}
+
+// contentHash computes the content hash of the package at the given path: a
+// hash of all of its source files and its interface file (in order) along with
+// the build target and the cache version.  The result is memoized.
+func (c *Compiler) contentHash(abspath string) (string, bool) {
+	if hash, ok := c.contentHashes[abspath]; ok {
+		return hash, hash != ""
+	}
+
+	c.contentHashes[abspath] = ""
+
+	files, err := ioutil.ReadDir(abspath)
+	if err != nil {
+		return "", false
+	}
+
+	h := sha256.New()
+	h.Write([]byte{cacheVersion})
+	h.Write([]byte(c.targetos + "/" + c.targetarch + "\x00"))
+
+	// ReadDir sorts the files by name
+	for _, finfo := range files {
+		if ext := filepath.Ext(finfo.Name()); finfo.IsDir() || (ext != SrcFileExtension && ext != InterfaceFileExtension) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(abspath, finfo.Name()))
+		if err != nil {
+			return "", false
+		}
+
+		h.Write([]byte(finfo.Name() + "\x00"))
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	c.contentHashes[abspath] = hash
+	return hash, true
+}
+
+// hashFile computes the hash of a single file.  It returns an empty string if
+// the file can't be read.
+func hashFile(fpath string) string {
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// -----------------------------------------------------------------------------
+
+// writeCache writes cache entries for all of the packages that were built
+// (ie. not loaded from the cache) excluding the main package.  This should only
+// be called once all the packages have been successfully validated.  Packages
+// whose interfaces can't be encoded are simply not cached.
+func (c *Compiler) writeCache() {
+	cacheDir := CacheDirectory(c.buildDirectory)
+	if err := os.MkdirAll(cacheDir, os.ModePerm); err != nil {
+		return
+	}
+
+	for _, pkg := range c.depGraph {
+		if pkg.Precompiled || pkg.RootDirectory == c.buildDirectory {
+			continue
+		}
+
+		hash, ok := c.contentHash(pkg.RootDirectory)
+		if !ok {
+			continue
+		}
+
+		entry := &cacheEntry{Version: cacheVersion, RootDirectory: pkg.RootDirectory}
+
+		if pkg.ParentModule != nil {
+			entry.ModulePath = pkg.ParentModule.Path
+			entry.ModuleHash = hashFile(mods.ModuleFilePath(pkg.ParentModule.Path))
+		}
+
+		for _, wimport := range Imports(pkg) {
+			depHash, ok := c.contentHash(wimport.PackageRef.RootDirectory)
+			if !ok {
+				break
+			}
+
+			entry.Dependencies = append(entry.Dependencies, &cacheDependency{
+				RootDirectory: wimport.PackageRef.RootDirectory,
+				ContentHash:   depHash,
+			})
+		}
+
+		// a package can only be cached if all of its dependencies can be
+		if len(entry.Dependencies) != len(pkg.ImportTable) {
+			continue
+		}
+
+		sort.Slice(entry.Dependencies, func(i, j int) bool {
+			return entry.Dependencies[i].RootDirectory < entry.Dependencies[j].RootDirectory
+		})
+
+		if entry.Interface, ok = encodePackageInterface(pkg); !ok {
+			continue
+		}
+
+		if data, err := json.Marshal(entry); err == nil {
+			ioutil.WriteFile(filepath.Join(cacheDir, hash+".json"), data, 0644)
+		}
+	}
+}
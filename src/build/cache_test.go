@@ -0,0 +1,152 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"whirlwind/common"
+	"whirlwind/logging"
+)
+
+// libProject is a project with a subpackage that is cached once the project
+// has been built
+var libProject = map[string]string{
+	"whirl-mod.yml": "name: proj\n",
+	"main.wrl":      "!! no_prelude\nimport proj::util\n\nexport of\n    func main() -> 0\n",
+	"util/util.wrl": "!! no_prelude\n\nexport of\n    # `Point` is a point\n    type Point {\n        x, y: bool\n    }\n",
+}
+
+// buildTestLib builds a project as a library: the test fails if it can't be
+// built
+func buildTestLib(t *testing.T, dir string) {
+	t.Helper()
+
+	c := newCompilerIn(t, dir)
+	if err := c.SetOutputFormat("lib"); err != nil {
+		t.Fatal(err)
+	}
+
+	c.setPointerSize()
+	c.initialize(false)
+	if !c.initPrelude() || !c.buildMainPackage() {
+		logging.LogStageEnd()
+		t.Fatal("failed to build the project")
+	}
+}
+
+// analyzeTestProject analyzes a project and returns the compiler and the main
+// package along with the package with the given name
+func analyzeTestProject(t *testing.T, dir, name string) (*Compiler, *common.WhirlPackage, *common.WhirlPackage) {
+	t.Helper()
+
+	c := newCompilerIn(t, dir)
+	mainPkg, ok := c.Analyze()
+	if !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to analyze the project")
+	}
+
+	for _, pkg := range c.Packages() {
+		if pkg.Name == name {
+			return c, mainPkg, pkg
+		}
+	}
+
+	t.Fatalf("missing package `%s`", name)
+	return nil, nil, nil
+}
+
+func TestCachedSubpackageModule(t *testing.T) {
+	dir := writeTestProject(t, libProject)
+
+	// cold: nothing is cached yet
+	_, _, util := analyzeTestProject(t, dir, "util")
+	if util.Precompiled {
+		t.Fatal("`util` should not be loaded from the cache before it is built")
+	}
+
+	buildTestLib(t, dir)
+
+	// warm: `util` is loaded from the cache but is still a part of the main
+	// module
+	_, mainPkg, util := analyzeTestProject(t, dir, "util")
+	if !util.Precompiled {
+		t.Fatal("`util` should be loaded from the cache once it is built")
+	}
+
+	if util.ParentModule != mainPkg.ParentModule {
+		t.Error("cached `util` does not share the main package's module")
+	}
+
+	if util.ImportPath != "proj::util" {
+		t.Errorf("got import path `%s`, want `proj::util`", util.ImportPath)
+	}
+}
+
+func TestDocsColdAndWarm(t *testing.T) {
+	dir := writeTestProject(t, libProject)
+
+	for _, run := range []string{"cold", "warm"} {
+		c, mainPkg, _ := analyzeTestProject(t, dir, "util")
+
+		outDir := filepath.Join(t.TempDir(), "docs")
+		if err := c.WriteDocs(mainPkg, "md", outDir, false); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, page := range []string{"index.md", "proj/index.md", "proj/util/index.md"} {
+			if _, err := os.Stat(filepath.Join(outDir, filepath.FromSlash(page))); err != nil {
+				t.Errorf("%s: missing documentation page `%s`", run, page)
+			}
+		}
+
+		if run == "cold" {
+			buildTestLib(t, dir)
+		}
+	}
+}
+
+func TestCachedCycleWarning(t *testing.T) {
+	dir := writeTestProject(t, map[string]string{
+		"whirl-mod.yml": "name: proj\ncycles: warn\n",
+		"main.wrl":      "!! no_prelude\nimport proj::a\n\nexport of\n    func main() -> 0\n",
+		"a/a.wrl":       "!! no_prelude\nimport proj::b\n\nexport of\n    func f() -> 0\n",
+		"b/b.wrl":       "!! no_prelude\nimport proj::a\n\nexport of\n    func g() -> 0\n",
+	})
+
+	// the cycle is reported whether or not its packages are cached
+	for _, run := range []string{"cold", "warm"} {
+		c := newCompilerIn(t, dir)
+
+		// the warnings are only displayed at the warning log level
+		logging.Initialize(dir, "warning")
+		if err := logging.SetColorMode("never"); err != nil {
+			t.Fatal(err)
+		}
+
+		output := captureStdout(t, func() {
+			if _, ok := c.Analyze(); !ok {
+				t.Errorf("%s: analysis failed", run)
+			}
+
+			logging.LogFinished()
+		})
+
+		for _, pkg := range c.Packages() {
+			if pkg.Name == "a" && pkg.Precompiled != (run == "warm") {
+				t.Fatalf("%s: `a` precompiled: %v", run, pkg.Precompiled)
+			}
+		}
+
+		want := "Import cycle `proj::a` -> `proj::b` -> `proj::a` in module `proj`"
+		if !strings.Contains(output, want) {
+			t.Errorf("%s: missing `%s` in:\n%s", run, want, output)
+		}
+
+		if run == "cold" {
+			buildTestLib(t, dir)
+		}
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"errors"
 	"os"
 	"path"
+	"runtime"
 	"strings"
+	"sync"
 
 	"whirlwind/common"
 	"whirlwind/logging"
 	"whirlwind/mods"
 	"whirlwind/resolve"
 	"whirlwind/syntax"
+	"whirlwind/typing"
 	"whirlwind/validate"
 )
 
@@ -40,6 +43,34 @@ type Compiler struct {
 	outputFormat        int
 	debugTarget         bool
 
+	// jobs is the maximum number of packages that can be validated at once
+	jobs int
+
+	// pointerSize is the size of a pointer on the target architecture in bytes
+	pointerSize int
+
+	// layouts is the layout service for the target (created when it is first
+	// needed)
+	layouts *typing.LayoutService
+
+	// vtables stores the method table plans of all the packages (computed when
+	// they are first requested)
+	vtables []*typing.VTablePlan
+
+	// graphFormat is the format the dependency graph should be emitted in
+	// (`dot` or `json`).  If it is empty, no graph is emitted.  graphPath is
+	// the path the graph is written to.
+	graphFormat string
+	graphPath   string
+
+	// traceFormat is the format solver traces are written in (`text` or
+	// `json`).  If it is empty, no types are traced.  tracePackage and
+	// traceFunc identify the function whose types are traced: tracePackage is
+	// empty if the function is in the main package.
+	traceFormat  string
+	tracePackage string
+	traceFunc    string
+
 	// global, shared log context
 	lctx *logging.LogContext
 
@@ -53,6 +84,23 @@ type Compiler struct {
 	// help manage and resolve cyclic dependencies.  The key is the package ID.
 	depGraph map[uint]*common.WhirlPackage
 
+	// packagePaths maps the IDs of the packages in the dependency graph to their
+	// import paths so that the types they define can be qualified by them in
+	// diagnostics
+	packagePaths typing.PackagePaths
+
+	// loadedModules stores all of the modules that have been loaded by path.
+	// A `nil` entry indicates the module failed to load.
+	loadedModules map[string]*mods.Module
+
+	// contentHashes stores the content hashes of packages by path.  An empty
+	// hash indicates that the package could not be hashed.
+	contentHashes map[string]string
+
+	// cacheEntries stores the build cache entries that have been looked up by
+	// package path.  A `nil` entry indicates that there is no valid entry.
+	cacheEntries map[string]*cacheEntry
+
 	// validators stores all the validators for each specific package
 	validators map[uint]*validate.PredicateValidator
 }
@@ -114,6 +162,17 @@ func (c *Compiler) SetOutputFormat(formatName string) error {
 	return nil
 }
 
+// SetJobs sets the maximum number of packages that can be validated
+// concurrently.  It returns an error if the number of jobs is not positive.
+func (c *Compiler) SetJobs(jobs int) error {
+	if jobs < 1 {
+		return errors.New("The number of jobs must be at least 1")
+	}
+
+	c.jobs = jobs
+	return nil
+}
+
 // NewCompiler creates a new, singletone compiler based on the essential input
 // information (p: platform, a: architecture, op: output path, bd: build
 // directory). It then stores the compiler globally if its creation was
@@ -141,19 +200,22 @@ func NewCompiler(o string, a string, op string, bd string, debugT bool, whirlpat
 
 	return &Compiler{targetos: o, targetarch: a, outputPath: op,
 		buildDirectory: bd, debugTarget: debugT, whirlpath: whirlpath,
-		validators: make(map[uint]*validate.PredicateValidator),
+		jobs:          runtime.NumCPU(),
+		validators:    make(map[uint]*validate.PredicateValidator),
+		loadedModules: make(map[string]*mods.Module),
+		contentHashes: make(map[string]string),
+		cacheEntries:  make(map[string]*cacheEntry),
 	}, nil
 }
 
 // determines the pointer size for any given architecture (and/or platform)
-// TODO: confirm that these sizes will work as a general rule
 func (c *Compiler) setPointerSize() {
-	// switch c.targetarch {
-	// case "x86":
-	// 	logging.PointerSize = 4
-	// case "x64":
-	// 	logging.PointerSize = 8
-	// }
+	switch c.targetarch {
+	case "386", "arm":
+		c.pointerSize = 4
+	case "amd64", "arm64":
+		c.pointerSize = 8
+	}
 }
 
 // Compile initializes the compiler (for building) and runs the main compilation
@@ -163,6 +225,30 @@ func (c *Compiler) Compile(forceGrammarRebuild bool) {
 	// initialize any necessary globals
 	c.setPointerSize()
 
+	c.initialize(forceGrammarRebuild)
+
+	// make sure we log the completion of compilation
+	defer logging.LogFinished()
+
+	// give verbose feedback as necessary
+	logging.LogInfo(c.targetos, c.targetarch, c.debugTarget)
+	logging.LogStateChange("Analyzing")
+
+	// load the prelude before we begin the building process (this only proceeds
+	// through stage 1 of the import algorithm: it will be finished off during
+	// the main building algorithm)
+	if !c.initPrelude() {
+		logging.LogFatal("Failed to initialize prelude")
+	}
+
+	// now that we are setup and ready to go, we can run the main package
+	// building algorithm
+	c.buildMainPackage()
+}
+
+// initialize sets up the compiler state that is used by every stage of
+// compilation: the log context, the parsing table, and the dependency graph
+func (c *Compiler) initialize(forceGrammarRebuild bool) {
 	// initialize our log context
 	c.lctx = &logging.LogContext{}
 
@@ -176,71 +262,144 @@ func (c *Compiler) Compile(forceGrammarRebuild bool) {
 
 	c.ptable = ptable
 	c.depGraph = make(map[uint]*common.WhirlPackage)
+	c.packagePaths = make(typing.PackagePaths)
+}
 
-	// make sure we log the completion of compilation
-	defer logging.LogFinished()
+// Analyze runs the first three stages of compilation (initialization,
+// resolution and validation) on the main package and all of its dependencies
+// without generating any output.  This is used by tools that need the fully
+// typed packages (eg. `doc`).  It returns the main package.
+func (c *Compiler) Analyze() (*common.WhirlPackage, bool) {
+	c.setPointerSize()
 
-	// give verbose feedback as necessary
-	logging.LogInfo(c.targetos, c.targetarch, c.debugTarget)
-	logging.LogStateChange("Analyzing")
+	c.initialize(false)
 
-	// load the prelude before we begin the building process (this only proceeds
-	// through stage 1 of the import algorithm: it will be finished off during
-	// the main building algorithm)
 	if !c.initPrelude() {
-		logging.LogFatal("Failed to initialize prelude")
+		return nil, false
 	}
 
-	// now that we are setup and ready to go, we can run the main package
-	// building algorithm
-	c.buildMainPackage()
+	return c.analyzeMainPackage()
 }
 
 // buildPackage is the main compilation function: it takes the main package path
 // and fully builds it and all of its dependencies into LLVM modules that can be
 // linked together to form the final program
 func (c *Compiler) buildMainPackage() bool {
-	// start by looking for the main module -- this must exist in order for
-	// compilation to succeed; error out immediately if it isn't found
-	mainMod, ok := mods.LoadModule(c.buildDirectory)
+	pkg, ok := c.analyzeMainPackage()
 	if !ok {
-		logging.LogInternalError("Module", "Missing main module")
 		return false
 	}
 
-	// initialize the main package (indexing the directory, parsing the files)
-	pkg, ok := c.initPackage(c.buildDirectory, mainMod)
-	if !ok {
+	// now that all the packages have been validated, we can cache them
+	c.writeCache()
+
+	// libraries are shipped with the interface files of their packages
+	if c.outputFormat == LIB && !c.writeInterfaceFiles(pkg) {
 		return false
 	}
 
-	// then, initialize all of its dependencies (recursively)
-	if !c.initDependencies(pkg) {
-		return false
+	// TODO: rest of compilation
+
+	return true
+}
+
+// analyzeMainPackage initializes, resolves and validates the main package and
+// all of its dependencies (stages 1 through 3 of compilation)
+func (c *Compiler) analyzeMainPackage() (*common.WhirlPackage, bool) {
+	// the compile messages of each stage are displayed once the stage finishes
+	// (regardless of whether it succeeded)
+	pkg, ok := c.initMainPackage()
+	logging.LogStageEnd()
+	if !ok {
+		return nil, false
+	}
+
+	// the dependency graph is emitted before resolution so that it is still
+	// available if resolution fails (eg. because of a bad cycle)
+	if c.graphFormat != "" {
+		c.emitDependencyGraph(pkg)
 	}
 
 	// once the dependency graph has been created, group and resolve all
 	// dependencies (using the Grouper)
-	g := resolve.NewGrouper(pkg, c.depGraph, c.validators)
-	if !g.ResolveAll() {
-		return false
+	g := resolve.NewGrouper(pkg, c.depGraph, c.packagePaths, c.validators, c.jobs)
+	ok = g.ResolveAll()
+	logging.LogStageEnd()
+	if !ok {
+		return nil, false
 	}
 
-	// run stage 3 of compilation -- predicate validation.  This stage cannot be
-	// run concurrently due to the surplus of shared stage between dependent
-	// packages (eg. generic instances, interface instances, etc.) that can't
-	// really be updated concurrently (at least not without a lot of extra
-	// lifting).  Plus, most projects are going to have upwards of 20 packages
-	// which on many systems is going to result in a lot of resource squabbling
-	// especially since none of the goroutines are going to performing any
-	// blocking IO operations (no cooperative switching except for maybe a few
-	// channel read/writes)
-	// TODO: revise this approach -- allow concurrency?
-	for _, v := range c.validators {
-		v.Validate()
+	// run stage 3 of compilation -- predicate validation
+	if c.traceFormat != "" && !c.setupTypeTrace(pkg) {
+		logging.LogStageEnd()
+		return nil, false
 	}
 
-	// TODO: rest of compilation
+	c.validatePackages()
+	logging.LogStageEnd()
+
+	return pkg, logging.ShouldProceed()
+}
+
+// validatePackages runs predicate validation on all of the packages.  All of
+// the definitions of every package are resolved before any package is
+// validated so validating a package never depends on another package having
+// been validated: it only updates the package's own HIR and the state of data
+// types that is shared between packages (eg. generic instances, interface
+// instances, etc.) which is only accessed while holding the solvers' shared
+// state lock.  Thus, the packages can be validated concurrently in any order
+// rather than by the dependency graph.  They are validated by a pool of
+// `c.jobs` workers: most projects have far more packages than there are CPUs
+// and none of the workers block on IO so more workers would just squabble over
+// resources.
+func (c *Compiler) validatePackages() {
+	validatorChan := make(chan *validate.PredicateValidator)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < c.jobs; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for v := range validatorChan {
+				v.Validate()
+			}
+		}()
+	}
+
+	// the packages are always dispatched in the same order
+	for _, pkg := range c.Packages() {
+		if v, ok := c.validators[pkg.PackageID]; ok {
+			validatorChan <- v
+		}
+	}
+
+	close(validatorChan)
+	wg.Wait()
+}
+
+// initMainPackage initializes the main package and all of its dependencies
+// (performing step 1 of the Import Algorithm for the main package).
+func (c *Compiler) initMainPackage() (*common.WhirlPackage, bool) {
+	// start by looking for the main module -- this must exist in order for
+	// compilation to succeed; error out immediately if it isn't found
+	mainMod := c.getModule(c.buildDirectory)
+	if mainMod == nil {
+		logging.LogInternalError("Module", "Missing main module")
+		return nil, false
+	}
+
+	// initialize the main package (indexing the directory, parsing the files)
+	pkg, ok := c.initPackage(c.buildDirectory, mainMod)
+	if !ok {
+		return nil, false
+	}
+
+	// then, initialize all of its dependencies (recursively)
+	if !c.initDependencies(pkg) {
+		return nil, false
+	}
 
-	return logging.ShouldProceed()
+	return pkg, true
 }
@@ -0,0 +1,206 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/typing"
+)
+
+// writeTestProject writes the files of a project (by slash-separated path)
+// into a temporary `proj` directory and returns the path to it
+func writeTestProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	// the name of the main package is the name of its directory
+	dir := filepath.Join(t.TempDir(), "proj")
+	for name, content := range files {
+		fpath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// newTestCompiler writes a project into a temporary directory and creates a
+// compiler to build it (see `writeTestProject`)
+func newTestCompiler(t *testing.T, files map[string]string) *Compiler {
+	t.Helper()
+
+	return newCompilerIn(t, writeTestProject(t, files))
+}
+
+// newCompilerIn creates a compiler to build the project in the given directory.
+// Its output is written to `out` in that directory.  The repository root is
+// used as the WHIRL_PATH so that the standard library is available.
+func newCompilerIn(t *testing.T, dir string) *Compiler {
+	t.Helper()
+
+	whirlpath, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logging.Initialize(dir, "error")
+
+	c, err := NewCompiler("linux", "amd64", filepath.Join(dir, "out"), dir, false, whirlpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// genericUsersProject is a project with several independent packages that all
+// instantiate the generics of a shared package
+func genericUsersProject() map[string]string {
+	files := map[string]string{
+		"whirl-mod.yml":     "name: proj\n",
+		"shared/shared.wrl": "!! no_prelude\n\nexport of\n    type Box<T> {\n        value: T\n    }\n\n    type Pair<T> {\n        first, second: Box<T>\n    }\n",
+	}
+
+	mainSrc := "!! no_prelude\n"
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		files[name+"/"+name+".wrl"] = "!! no_prelude\nimport Box, Pair from proj::shared\n\nexport of\n" +
+			"    type Item {\n        x: bool\n    }\n\n" +
+			"    type Holder {\n        items: Pair<Item>\n        flag: Box<bool>\n    }\n\n" +
+			"    func unwrap(p: Pair<Item>) Box<Item> -> p\n"
+		mainSrc += "import proj::" + name + "\n"
+	}
+
+	files["main.wrl"] = mainSrc + "\nfunc main() -> 0\n"
+	return files
+}
+
+func TestAnalyzeConcurrently(t *testing.T) {
+	c := newTestCompiler(t, genericUsersProject())
+	if err := c.SetJobs(4); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.Analyze(); !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to analyze the project")
+	}
+
+	var shared *common.WhirlPackage
+	for _, pkg := range c.Packages() {
+		if pkg.Name == "shared" {
+			shared = pkg
+		}
+	}
+
+	if shared == nil {
+		t.Fatal("missing package `shared`")
+	}
+
+	// every package has its own `Item` so each one creates its own instance
+	pair := shared.GlobalTable["Pair"].Type.(*typing.GenericType)
+	if len(pair.Instances) != 6 {
+		t.Fatalf("`Pair` has %d instances; want 6", len(pair.Instances))
+	}
+
+	items := make(map[typing.DataType]bool)
+	for _, gi := range pair.Instances {
+		items[gi.TypeParams[0]] = true
+
+		// the type parameter of the copied template of `Pair` must be replaced
+		// by the instance's type value and not by that of another instance
+		st := gi.MemoizedGenerate.(*typing.StructType)
+		for _, name := range []string{"first", "second"} {
+			box := st.Fields[name].Type.(*typing.GenericInstanceType)
+			if box.TypeParams[0].(*typing.WildcardType).Value != gi.TypeParams[0] {
+				t.Errorf("field `%s` of `%s` is `%s`", name, gi.Repr(), box.Repr())
+			}
+		}
+	}
+
+	if len(items) != 6 {
+		t.Errorf("`Pair` was instantiated with %d distinct items; want 6", len(items))
+	}
+
+	// the templates are shared so instantiating them must not change them
+	for _, name := range []string{"Box", "Pair"} {
+		for _, tp := range shared.GlobalTable[name].Type.(*typing.GenericType).TypeParams {
+			if tp.Value != nil {
+				t.Errorf("type parameter `%s` of `%s` was left bound to `%s`", tp.Name, name, tp.Value.Repr())
+			}
+		}
+	}
+}
+
+func TestAnalyzeUnresolvedCycle(t *testing.T) {
+	// `a` and `b` are resolved together and neither can resolve all of its
+	// definitions: `A2` depends on `Missing` through `A` and `B` depends on
+	// `A2`
+	c := newTestCompiler(t, map[string]string{
+		"whirl-mod.yml": "name: proj\ncycles: allow\n",
+		"main.wrl":      "!! no_prelude\nimport proj::a\nimport proj::b\n\nfunc main() -> 0\n",
+		"a/a.wrl":       "!! no_prelude\nimport B2 from proj::b\n\nexport of\n    type A {\n        x: Missing\n    }\n\n    type A2 {\n        x: A\n    }\n",
+		"b/b.wrl":       "!! no_prelude\nimport A2 from proj::a\n\nexport of\n    type B {\n        x: A2\n    }\n\n    type B2 {\n        x: B\n    }\n",
+	})
+
+	done := make(chan bool)
+	go func() {
+		_, ok := c.Analyze()
+		done <- ok
+	}()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("analysis succeeded with an undefined symbol")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("resolution did not terminate")
+	}
+}
+
+func TestDocCommentSymbols(t *testing.T) {
+	c := newTestCompiler(t, map[string]string{
+		"whirl-mod.yml": "name: proj\n",
+		"main.wrl": "!! no_prelude\n\n# `Point` is a point\ntype Point {\n    x: bool\n}\n\n" +
+			"# `origin` gets the origin\nfunc origin(p: Point) Point -> p\n\nfunc main() -> 0\n",
+	})
+
+	mainPkg, ok := c.Analyze()
+	if !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to analyze the project")
+	}
+
+	for name, want := range map[string]string{"Point": "`Point` is a point", "origin": "`origin` gets the origin", "main": ""} {
+		sym, ok := mainPkg.GlobalTable[name]
+		if !ok {
+			t.Fatalf("missing symbol `%s`", name)
+		}
+
+		if sym.DocComment != want {
+			t.Errorf("`%s` has the doc comment %q; want %q", name, sym.DocComment, want)
+		}
+
+		var defDoc string
+		switch v := sym.DefNode.(type) {
+		case *common.HIRTypeDef:
+			defDoc = v.DocComment
+		case *common.HIRFuncDef:
+			defDoc = v.DocComment
+		default:
+			t.Fatalf("`%s` has no definition", name)
+		}
+
+		if defDoc != want {
+			t.Errorf("the definition of `%s` has the doc comment %q; want %q", name, defDoc, want)
+		}
+	}
+}
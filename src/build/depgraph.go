@@ -0,0 +1,121 @@
+package build
+
+import (
+	"sort"
+	"strings"
+
+	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/mods"
+)
+
+// LoadDependencyGraph runs only the first stage of the import algorithm: the
+// main package and all of its dependencies are initialized, but nothing is
+// resolved or validated.  This is used by tools that only care about the shape
+// of the dependency graph (eg. `mod graph`).  It returns the main package.
+func (c *Compiler) LoadDependencyGraph() (*common.WhirlPackage, bool) {
+	c.initialize(false)
+
+	if !c.initPrelude() {
+		return nil, false
+	}
+
+	pkg, ok := c.initMainPackage()
+	if !ok {
+		return nil, false
+	}
+
+	return pkg, logging.ShouldProceed()
+}
+
+// addPackage adds a package to the dependency graph and determines its import
+// path.  Its import path is also recorded so that the types it defines can be
+// qualified by it in diagnostics.
+func (c *Compiler) addPackage(pkg *common.WhirlPackage) {
+	if importPath, ok := c.importPathOf(pkg); ok {
+		pkg.ImportPath = strings.ReplaceAll(importPath, "/", "::")
+	} else {
+		pkg.ImportPath = pkg.Name
+	}
+
+	c.depGraph[pkg.PackageID] = pkg
+	c.packagePaths[pkg.PackageID] = pkg.ImportPath
+}
+
+// Packages returns all of the packages in the dependency graph sorted by their
+// root directories (so that they are always in the same order)
+func (c *Compiler) Packages() []*common.WhirlPackage {
+	pkgs := make([]*common.WhirlPackage, 0, len(c.depGraph))
+	for _, pkg := range c.depGraph {
+		pkgs = append(pkgs, pkg)
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].RootDirectory < pkgs[j].RootDirectory
+	})
+
+	return pkgs
+}
+
+// inModule checks if a package belongs to the given module.  Modules are
+// compared by path so that the check doesn't depend on how the package and its
+// module were loaded.
+func inModule(pkg *common.WhirlPackage, mod *mods.Module) bool {
+	return pkg.ParentModule != nil && mod != nil && pkg.ParentModule.Path == mod.Path
+}
+
+// Imports returns all of the imports of a package sorted by the root
+// directories of the imported packages
+func Imports(pkg *common.WhirlPackage) []*common.WhirlImport {
+	imports := make([]*common.WhirlImport, 0, len(pkg.ImportTable))
+	for _, wimport := range pkg.ImportTable {
+		imports = append(imports, wimport)
+	}
+
+	sort.Slice(imports, func(i, j int) bool {
+		return imports[i].PackageRef.RootDirectory < imports[j].PackageRef.RootDirectory
+	})
+
+	return imports
+}
+
+// ImportChains finds the shortest chain of imports from the root package to
+// every package that matches the given predicate.  Each chain begins with the
+// root package and ends with the matched package.  The chains are sorted by
+// the root directory of the package they lead to.
+func ImportChains(root *common.WhirlPackage, matches func(*common.WhirlPackage) bool) [][]*common.WhirlPackage {
+	// breadth-first search so that the first path we find to each package is
+	// the shortest one: `prev` stores the package each package was reached from
+	prev := map[uint]*common.WhirlPackage{root.PackageID: nil}
+	queue := []*common.WhirlPackage{root}
+
+	var matched []*common.WhirlPackage
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+
+		if matches(pkg) {
+			matched = append(matched, pkg)
+		}
+
+		for _, wimport := range Imports(pkg) {
+			if _, ok := prev[wimport.PackageRef.PackageID]; !ok {
+				prev[wimport.PackageRef.PackageID] = pkg
+				queue = append(queue, wimport.PackageRef)
+			}
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].RootDirectory < matched[j].RootDirectory
+	})
+
+	chains := make([][]*common.WhirlPackage, len(matched))
+	for i, pkg := range matched {
+		for ; pkg != nil; pkg = prev[pkg.PackageID] {
+			chains[i] = append([]*common.WhirlPackage{pkg}, chains[i]...)
+		}
+	}
+
+	return chains
+}
@@ -0,0 +1,174 @@
+package build
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+// captureStdout returns everything written to stdout while running a function
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	f()
+
+	w.Close()
+	return <-output
+}
+
+// brokenProject is a project with undefined symbols in several files of
+// several packages that are resolved concurrently
+var brokenProject = map[string]string{
+	"whirl-mod.yml": "name: proj\n",
+	"main.wrl":      "!! no_prelude\nimport proj::a\nimport proj::b\n\nfunc main() -> 0\n",
+	"a/x.wrl":       "!! no_prelude\n\ntype AX {\n    x: MissingAX\n}\n\ntype AX2 {\n    x: MissingAX2\n}\n",
+	"a/y.wrl":       "!! no_prelude\n\ntype AY {\n    x: MissingAY\n}\n",
+	"b/x.wrl":       "!! no_prelude\n\ntype BX {\n    x: MissingBX\n}\n",
+	"b/y.wrl":       "!! no_prelude\n\ntype BY {\n    x: MissingBY\n}\n",
+}
+
+func TestDiagnosticOrder(t *testing.T) {
+	dir := writeTestProject(t, brokenProject)
+
+	var first string
+	for i := 0; i < 5; i++ {
+		output := captureStdout(t, func() {
+			c := newCompilerIn(t, dir)
+			if err := c.SetJobs(4); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := c.Analyze(); ok {
+				t.Error("analysis succeeded with undefined symbols")
+			}
+
+			logging.LogStageEnd()
+		})
+
+		if i == 0 {
+			first = output
+		} else if output != first {
+			t.Fatalf("the output of run %d differs from that of the first run:\n%s\nvs.\n%s", i, output, first)
+		}
+	}
+
+	// the errors are sorted by package, file and then position
+	last := -1
+	for _, name := range []string{"MissingAX", "MissingAX2", "MissingAY", "MissingBX", "MissingBY"} {
+		ndx := strings.Index(first, "`"+name+"`")
+		if ndx == -1 {
+			t.Fatalf("missing error for `%s` in:\n%s", name, first)
+		}
+
+		if ndx < last {
+			t.Errorf("error for `%s` is out of order in:\n%s", name, first)
+		}
+
+		last = ndx
+	}
+}
+
+func TestRepeatDefinitionSpans(t *testing.T) {
+	output := analyzeOutput(t, map[string]string{
+		"whirl-mod.yml": "name: proj\n",
+		"main.wrl":      "!! no_prelude\n\nfunc f() -> 0\n\nfunc main() -> 0\n",
+		"other.wrl":     "!! no_prelude\n\nfunc f() -> 1\n",
+	})
+
+	// the error points to both definitions
+	for _, want := range []string{"Symbol `f` already defined", "^ redefined here", "- `f` first defined here"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing `%s` in:\n%s", want, output)
+		}
+	}
+}
+
+// analyzeOutput analyzes a project that fails to compile and returns the
+// messages that were displayed
+func analyzeOutput(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := writeTestProject(t, files)
+	return captureStdout(t, func() {
+		c := newCompilerIn(t, dir)
+		if err := logging.SetColorMode("never"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := c.Analyze(); ok {
+			t.Error("analysis succeeded")
+		}
+
+		logging.LogStageEnd()
+	})
+}
+
+func TestSuggestions(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			"package",
+			map[string]string{
+				"whirl-mod.yml": "name: proj\n",
+				"main.wrl":      "!! no_prelude\nimport proj::utl\n\nfunc main() -> 0\n",
+				"util/util.wrl": "!! no_prelude\n\nfunc f() -> 0\n",
+			},
+			[]string{"Unable to locate package at path `proj::utl`", "help: did you mean `proj::util`?"},
+		},
+		{
+			"symbol",
+			map[string]string{
+				"whirl-mod.yml": "name: proj\n",
+				"main.wrl":      "!! no_prelude\n\ntype Point {\n    x: bool\n}\n\ntype Line {\n    p: Piont\n}\n\nfunc main() -> 0\n",
+			},
+			[]string{"`Piont`", "help: did you mean `Point`?"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			output := analyzeOutput(t, c.files)
+
+			for _, want := range c.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("missing `%s` in:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestQualifiedTypeNames(t *testing.T) {
+	// both packages define a type named `Point` so they are qualified by their
+	// import paths in the error
+	output := analyzeOutput(t, map[string]string{
+		"whirl-mod.yml": "name: proj\n",
+		"main.wrl": "!! no_prelude\nimport proj::geom\n\ntype Point {\n    x, y: bool\n}\n\n" +
+			"interf for Point is geom::Point of\n    func other() bool -> true\n\nfunc main() -> 0\n",
+		"geom/geom.wrl": "!! no_prelude\n\nexport of\n    interf Point of\n        func area() bool\n",
+	})
+
+	want := "Type interface for `proj::Point` does not fully implement interface `proj::geom::Point`"
+	if !strings.Contains(output, want) {
+		t.Errorf("missing `%s` in:\n%s", want, output)
+	}
+}
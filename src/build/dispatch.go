@@ -0,0 +1,133 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"whirlwind/common"
+	"whirlwind/typing"
+)
+
+// VTables returns the method table plans of every type for every conceptual
+// interface that it was found to implement during validation.  The plans are
+// ordered by the package that defines the interface, then by the name of the
+// interface and finally by the type that implements it so that they are always
+// in the same order.  This should only be called once the packages have been
+// analyzed.
+func (c *Compiler) VTables() ([]*typing.VTablePlan, error) {
+	if c.vtables != nil {
+		return c.vtables, nil
+	}
+
+	// every binding is declared globally in some package and a type's method
+	// table must include the bindings of every package
+	bindings := &typing.BindingRegistry{}
+	for _, pkg := range c.Packages() {
+		// packages that failed to load may not have a binding registry
+		if pkg.GlobalBindings != nil {
+			bindings.Bindings = append(bindings.Bindings, pkg.GlobalBindings.Bindings...)
+		}
+	}
+
+	solver := typing.NewSolver(c.lctx, &typing.BindingRegistry{}, bindings, c.packagePaths)
+
+	var vtables []*typing.VTablePlan
+	for _, pkg := range c.Packages() {
+		for _, it := range conceptualInterfaces(pkg) {
+			instances := append([]typing.DataType{}, it.Instances...)
+			sort.SliceStable(instances, func(i, j int) bool {
+				return c.packagePaths.ReprType(instances[i]) < c.packagePaths.ReprType(instances[j])
+			})
+
+			for _, dt := range instances {
+				plan, err := solver.PlanVTable(dt, it)
+				if err != nil {
+					return nil, err
+				}
+
+				vtables = append(vtables, plan)
+			}
+		}
+	}
+
+	c.vtables = vtables
+	return vtables, nil
+}
+
+// methodKindNames are the names of the kinds of methods in method tables
+var methodKindNames = map[int]string{
+	typing.MKVirtual:   "virtual",
+	typing.MKOverride:  "override",
+	typing.MKAbstract:  "abstract",
+	typing.MKImplement: "implement",
+}
+
+// WriteVTables writes the method table plans of every type for every conceptual
+// interface it implements in a readable form.  This should be called once the
+// packages have been analyzed.
+func (c *Compiler) WriteVTables(w io.Writer) error {
+	vtables, err := c.VTables()
+	if err != nil {
+		return err
+	}
+
+	if len(vtables) == 0 {
+		fmt.Fprintln(w, "No types implement any interfaces")
+		return nil
+	}
+
+	for _, plan := range vtables {
+		reprs := c.packagePaths.ReprTypes(plan.Type, plan.Interf)
+		fmt.Fprintf(w, "VTable of `%s` for `%s`\n", reprs[0], reprs[1])
+
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, entry := range plan.Entries {
+			source := fmt.Sprintf("from `%s`", c.packagePaths.ReprType(entry.Source))
+			if entry.Overrides != nil {
+				source += fmt.Sprintf(", overrides `%s`", c.packagePaths.ReprType(entry.Overrides))
+			}
+
+			fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", entry.Slot.Index, entry.Slot.Name, methodKindNames[entry.Kind], source)
+		}
+
+		tw.Flush()
+	}
+
+	return nil
+}
+
+// conceptualInterfaces gets all of the conceptual interfaces defined in a
+// package sorted by name.  The generates of generic interfaces are included
+// after their generic.
+func conceptualInterfaces(pkg *common.WhirlPackage) []*typing.InterfType {
+	names := make([]string, 0, len(pkg.GlobalTable))
+	for name, sym := range pkg.GlobalTable {
+		if sym.DefKind == common.DefKindTypeDef {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var interfs []*typing.InterfType
+	for _, name := range names {
+		switch v := pkg.GlobalTable[name].Type.(type) {
+		case *typing.InterfType:
+			if v.SrcPackageID == pkg.PackageID {
+				interfs = append(interfs, v)
+			}
+		case *typing.GenericType:
+			if it, ok := v.Template.(*typing.InterfType); ok && it.SrcPackageID == pkg.PackageID {
+				for _, gi := range v.Instances {
+					if git, ok := gi.MemoizedGenerate.(*typing.InterfType); ok {
+						interfs = append(interfs, git)
+					}
+				}
+			}
+		}
+	}
+
+	return interfs
+}
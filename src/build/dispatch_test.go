@@ -0,0 +1,56 @@
+package build
+
+import (
+	"bytes"
+	"testing"
+)
+
+// dispatchProject is a project whose types implement an interface with
+// abstract and virtual methods
+var dispatchProject = map[string]string{
+	"whirl-mod.yml": "name: proj\n",
+	"main.wrl": "!! no_prelude\nimport Shape from proj::geom\n\ntype Point {\n    x: bool\n}\n\ntype Circle {\n    r: bool\n}\n\n" +
+		"interf for Point is Shape of\n    func size() bool -> false\n\n    func area() bool -> true\n\n" +
+		"interf for Circle is Shape of\n    func area() bool -> false\n\nfunc main() -> 0\n",
+	"geom/geom.wrl": "!! no_prelude\n\nexport of\n    interf Shape of\n        func size() bool -> true\n\n" +
+		"        func area() bool\n\n        func name() bool -> true\n",
+}
+
+func TestWriteVTables(t *testing.T) {
+	c, _, _ := analyzeTestProject(t, writeTestProject(t, dispatchProject), "geom")
+
+	// the slots are sorted by name and the tables by the implementing type
+	want := "VTable of `Circle` for `Shape`\n" +
+		"  0  area  implement  from `Circle`\n" +
+		"  1  name  virtual    from `Shape`\n" +
+		"  2  size  virtual    from `Shape`\n" +
+		"VTable of `Point` for `Shape`\n" +
+		"  0  area  implement  from `Point`\n" +
+		"  1  name  virtual    from `Shape`\n" +
+		"  2  size  override   from `Point`, overrides `Shape`\n"
+
+	buff := &bytes.Buffer{}
+	if err := c.WriteVTables(buff); err != nil {
+		t.Fatal(err)
+	}
+
+	if buff.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buff.String(), want)
+	}
+}
+
+func TestVTablesWithoutBindings(t *testing.T) {
+	c, _, geomPkg := analyzeTestProject(t, writeTestProject(t, dispatchProject), "geom")
+
+	// a package without a binding registry has no bindings to dispatch to
+	geomPkg.GlobalBindings = nil
+
+	vtables, err := c.VTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vtables) != 2 {
+		t.Errorf("got %d method tables, want 2", len(vtables))
+	}
+}
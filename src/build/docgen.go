@@ -0,0 +1,846 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"whirlwind/common"
+	"whirlwind/syntax"
+	"whirlwind/typing"
+)
+
+// Documentation
+// -------------
+// The documentation generator renders the exported API of packages as static
+// HTML or Markdown.  Each package is documented on its own page which is
+// written to the output directory at the import path of the package: eg. the
+// package `geo::shapes` is documented in `<output>/geo/shapes/index.html`.  An
+// index page listing every documented package is written to the root of the
+// output directory.  Types used in signatures link to their definitions if the
+// packages defining them are also documented.
+//
+// The documentation is generated from the exported symbols, bindings, and
+// operator definitions of the packages (not from their sources) so that
+// precompiled packages can be documented as well.
+
+// docPage is the page documenting a single package
+type docPage struct {
+	pkg        *common.WhirlPackage
+	importPath string
+
+	// path is the path of the page relative to the output directory (using `/`
+	// as the separator)
+	path string
+
+	types, funcs, values []*docItem
+	bindings, operators  []*docItem
+}
+
+// docItem is a single documented definition.  `name` is used as the anchor of
+// the item: it is empty for items that can't be referenced by name (eg.
+// bindings).  `members` are the fields, variants, or methods of the item.
+type docItem struct {
+	name       string
+	signature  docText
+	docComment string
+	members    []*docItem
+}
+
+// docText is a piece of text that can contain links to documented definitions
+type docText []*docSegment
+
+// docSegment is a segment of documentation text.  If `target` is not `nil`, the
+// segment links to the definition named `anchor` on the target page.
+type docSegment struct {
+	text   string
+	target *docPage
+	anchor string
+}
+
+// href returns the link of a segment from the given page
+func (seg *docSegment) href(page *docPage) string {
+	if seg.target == page {
+		return "#" + seg.anchor
+	}
+
+	return relativeLink(page.path, seg.target.path) + "#" + seg.anchor
+}
+
+// docConstraint is a constraint that is used to name the constraints of type
+// parameters: they are always expanded into the list of types they stand for
+type docConstraint struct {
+	name  string
+	pkgID uint
+	ct    *typing.ConstraintType
+}
+
+// docGenerator stores the state used to generate documentation
+type docGenerator struct {
+	// format is the documentation format: `html` or `md`
+	format string
+
+	// pages stores the pages of all the documented packages by package ID
+	pages map[uint]*docPage
+
+	// constraints stores all of the exported constraints in the dependency
+	// graph (in a fixed order)
+	constraints []*docConstraint
+}
+
+// WriteDocs writes the documentation of all the packages in the main module to
+// the output directory in the given format (`html` or `md`).  If `includeDeps`
+// is set, all of the packages the main module depends on are documented as
+// well.  This should only be called once the packages have been analyzed.
+func (c *Compiler) WriteDocs(mainPkg *common.WhirlPackage, format, outDir string, includeDeps bool) error {
+	if format != "html" && format != "md" {
+		return errors.New("Invalid documentation format")
+	}
+
+	dg := &docGenerator{format: format, pages: make(map[uint]*docPage)}
+
+	var pages []*docPage
+	for _, pkg := range c.Packages() {
+		if !includeDeps && !inModule(pkg, mainPkg.ParentModule) {
+			continue
+		}
+
+		importPath, ok := c.importPathOf(pkg)
+		if !ok {
+			return fmt.Errorf("Unable to determine the import path of package `%s`", pkg.Name)
+		}
+
+		page := &docPage{pkg: pkg, importPath: importPath, path: importPath + "/index." + format}
+		dg.pages[pkg.PackageID] = page
+		pages = append(pages, page)
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].importPath < pages[j].importPath
+	})
+
+	for _, pkg := range c.Packages() {
+		for _, name := range exportedSymbolNames(pkg) {
+			if ct, ok := pkg.GlobalTable[name].Type.(*typing.ConstraintType); ok {
+				dg.constraints = append(dg.constraints, &docConstraint{name: name, pkgID: pkg.PackageID, ct: ct})
+			}
+		}
+	}
+
+	for _, page := range pages {
+		dg.collect(page)
+
+		if err := dg.writeFile(outDir, page.path, dg.renderPage(page)); err != nil {
+			return err
+		}
+	}
+
+	return dg.writeFile(outDir, "index."+format, dg.renderIndex(pages))
+}
+
+// writeFile writes a documentation file to the given path relative to the
+// output directory
+func (dg *docGenerator) writeFile(outDir, relPath, content string) error {
+	fpath := filepath.Join(outDir, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fpath, []byte(content), 0644)
+}
+
+// exportedSymbolNames returns the sorted names of the exported symbols of a
+// package
+func exportedSymbolNames(pkg *common.WhirlPackage) []string {
+	names := make([]string, 0, len(pkg.GlobalTable))
+	for name, sym := range pkg.GlobalTable {
+		if sym.DeclStatus == common.DSExported {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// -----------------------------------------------------------------------------
+
+// collect collects all of the documented items of the package of a page
+func (dg *docGenerator) collect(page *docPage) {
+	pkg := page.pkg
+
+	for _, name := range exportedSymbolNames(pkg) {
+		sym := pkg.GlobalTable[name]
+
+		switch sym.DefKind {
+		case common.DefKindTypeDef:
+			if item, ok := dg.typeItem(sym); ok {
+				page.types = append(page.types, item)
+			}
+		case common.DefKindConstraint:
+			if ct, ok := sym.Type.(*typing.ConstraintType); ok {
+				page.types = append(page.types, dg.constraintItem(sym, ct))
+			}
+		case common.DefKindFuncDef:
+			tb := &docTextBuilder{dg: dg}
+			tb.writeFuncSignature("func", sym.Name, sym.Type)
+			page.funcs = append(page.funcs, &docItem{name: name, signature: tb.text, docComment: sym.DocComment})
+		case common.DefKindNamedValue:
+			tb := &docTextBuilder{dg: dg}
+			if sym.Constant {
+				tb.write("const ")
+			} else {
+				tb.write("let ")
+			}
+
+			tb.write(name + ": ")
+			tb.writeType(sym.Type)
+			page.values = append(page.values, &docItem{name: name, signature: tb.text, docComment: sym.DocComment})
+		}
+	}
+
+	if pkg.GlobalBindings != nil {
+		for _, binding := range pkg.GlobalBindings.Bindings {
+			if binding.Exported {
+				page.bindings = append(page.bindings, dg.bindingItem(binding))
+			}
+		}
+	}
+
+	opKinds := make([]int, 0, len(pkg.OperatorDefinitions))
+	for opKind := range pkg.OperatorDefinitions {
+		opKinds = append(opKinds, opKind)
+	}
+
+	sort.Ints(opKinds)
+	for _, opKind := range opKinds {
+		for _, opdef := range pkg.OperatorDefinitions[opKind] {
+			if !opdef.Exported {
+				continue
+			}
+
+			tb := &docTextBuilder{dg: dg}
+			tb.writeFuncSignature("oper", "("+syntax.GetOperatorTokenValueByKind(opKind)+")", opdef.Signature)
+			page.operators = append(page.operators, &docItem{signature: tb.text})
+		}
+	}
+}
+
+// typeItem creates the documentation item for a type definition (including
+// interfaces).  It fails if the symbol is not documented on its own (eg. the
+// variants of open algebraic types which are documented with their parent).
+func (dg *docGenerator) typeItem(sym *common.Symbol) (*docItem, bool) {
+	item := &docItem{name: sym.Name, docComment: sym.DocComment}
+	tb := &docTextBuilder{dg: dg}
+
+	dt := sym.Type
+	var typeParams []*typing.WildcardType
+	if gt, ok := dt.(*typing.GenericType); ok {
+		dt = gt.Template
+		typeParams = gt.TypeParams
+	}
+
+	switch v := dt.(type) {
+	case *typing.StructType:
+		tb.write("type " + sym.Name)
+		tb.writeTypeParams(typeParams)
+
+		if v.Inherit != nil {
+			tb.write(" of ")
+			tb.writeType(v.Inherit)
+		}
+
+		fieldNames := make([]string, 0, len(v.Fields))
+		for name := range v.Fields {
+			fieldNames = append(fieldNames, name)
+		}
+
+		sort.Strings(fieldNames)
+		for _, name := range fieldNames {
+			fb := &docTextBuilder{dg: dg}
+			fb.writeValue(name, v.Fields[name])
+			item.members = append(item.members, &docItem{signature: fb.text})
+		}
+	case *typing.AlgebraicType:
+		if v.Closed {
+			tb.write("closed ")
+		}
+
+		tb.write("type " + sym.Name)
+		tb.writeTypeParams(typeParams)
+
+		for _, vari := range v.Variants {
+			vb := &docTextBuilder{dg: dg}
+			vb.write("| " + vari.Name)
+
+			if len(vari.Values) > 0 {
+				vb.write("(")
+				vb.writeTypeList(vari.Values)
+				vb.write(")")
+			}
+
+			item.members = append(item.members, &docItem{signature: vb.text})
+		}
+	case *typing.AliasType:
+		tb.write("type " + sym.Name)
+		tb.writeTypeParams(typeParams)
+		tb.write(" = ")
+		tb.writeType(v.TrueType)
+	case *typing.InterfType:
+		tb.write("interf " + sym.Name)
+		tb.writeTypeParams(typeParams)
+		item.members = dg.methodItems(v)
+	default:
+		return nil, false
+	}
+
+	item.signature = tb.text
+	return item, true
+}
+
+// constraintItem creates the documentation item for a constraint definition
+func (dg *docGenerator) constraintItem(sym *common.Symbol, ct *typing.ConstraintType) *docItem {
+	tb := &docTextBuilder{dg: dg}
+	tb.write("constraint " + sym.Name)
+
+	// intrinsic constraints have no types that can be listed
+	if !ct.Intrinsic {
+		tb.write(" = ")
+
+		for i, dt := range ct.Types {
+			if i > 0 {
+				tb.write(" | ")
+			}
+
+			tb.writeType(dt)
+		}
+	}
+
+	return &docItem{name: sym.Name, signature: tb.text, docComment: sym.DocComment}
+}
+
+// bindingItem creates the documentation item for an interface binding
+func (dg *docGenerator) bindingItem(binding *typing.Binding) *docItem {
+	tb := &docTextBuilder{dg: dg}
+	tb.write("interf")
+	tb.writeTypeParams(binding.Wildcards)
+	tb.write(" for ")
+	tb.writeType(binding.MatchType)
+
+	item := &docItem{}
+	if it, ok := binding.TypeInterf.(*typing.InterfType); ok {
+		for i, implement := range it.Implements {
+			if i == 0 {
+				tb.write(" is ")
+			} else {
+				tb.write(", ")
+			}
+
+			tb.writeType(implement)
+		}
+
+		item.members = dg.methodItems(it)
+	}
+
+	item.signature = tb.text
+	return item
+}
+
+// methodItems creates the documentation items for the methods of an interface
+// (sorted by name)
+func (dg *docGenerator) methodItems(it *typing.InterfType) []*docItem {
+	names := make([]string, 0, len(it.Methods))
+	for name := range it.Methods {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	items := make([]*docItem, len(names))
+	for i, name := range names {
+		method := it.Methods[name]
+
+		tb := &docTextBuilder{dg: dg}
+		tb.writeFuncSignature("func", name, method.Signature)
+		items[i] = &docItem{signature: tb.text, docComment: method.DocComment}
+	}
+
+	return items
+}
+
+// -----------------------------------------------------------------------------
+
+// docTextBuilder builds documentation text from types.  The text mirrors the
+// `Repr` of the types except that named types link to their definitions.
+type docTextBuilder struct {
+	dg   *docGenerator
+	text docText
+}
+
+// write writes plain text
+func (tb *docTextBuilder) write(s string) {
+	if len(tb.text) > 0 && tb.text[len(tb.text)-1].target == nil {
+		tb.text[len(tb.text)-1].text += s
+	} else {
+		tb.text = append(tb.text, &docSegment{text: s})
+	}
+}
+
+// writeLink writes a name that links to the definition of that name in the
+// given package if the package is documented
+func (tb *docTextBuilder) writeLink(name string, pkgID uint) {
+	if page, ok := tb.dg.pages[pkgID]; ok {
+		tb.text = append(tb.text, &docSegment{text: name, target: page, anchor: name})
+	} else {
+		tb.write(name)
+	}
+}
+
+// writeType writes a data type
+func (tb *docTextBuilder) writeType(dt typing.DataType) {
+	switch v := dt.(type) {
+	case typing.TupleType:
+		tb.write("(")
+		tb.writeTypeList(v)
+		tb.write(")")
+	case *typing.VectorType:
+		tb.write(fmt.Sprintf("<%d>", v.Size))
+		tb.writeType(v.ElemType)
+	case *typing.RefType:
+		tb.write("&")
+
+		if v.Constant {
+			tb.write("const ")
+		}
+
+		tb.writeType(v.ElemType)
+	case *typing.FuncType:
+		if v.Async {
+			tb.write("async")
+		} else {
+			tb.write("func")
+		}
+
+		tb.writeFuncType(v)
+	case *typing.StructType:
+		tb.writeLink(v.Name, v.SrcPackageID)
+	case *typing.InterfType:
+		if v.Name == "" {
+			tb.write(v.Repr())
+		} else {
+			tb.writeLink(v.Name, v.SrcPackageID)
+		}
+	case *typing.AlgebraicType:
+		tb.writeLink(v.Name, v.SrcPackageID)
+	case *typing.AliasType:
+		tb.writeLink(v.Name, v.SrcPackageID)
+	case *typing.AlgebraicVariant:
+		tb.writeType(v.Parent)
+		tb.write("::" + v.Name)
+
+		if len(v.Values) > 0 {
+			tb.write("(")
+			tb.writeTypeList(v.Values)
+			tb.write(")")
+		}
+	case *typing.GenericType:
+		tb.writeType(v.Template)
+		tb.writeTypeParams(v.TypeParams)
+	case *typing.GenericInstanceType:
+		tb.writeType(v.Generic.Template)
+		tb.write("<")
+		tb.writeTypeList(v.TypeParams)
+		tb.write(">")
+	case *typing.WildcardType:
+		if v.Value == nil {
+			tb.write(v.Name)
+		} else {
+			tb.writeType(v.Value)
+		}
+	case *typing.OpaqueType:
+		if v.EvalType == nil {
+			tb.write(v.Name)
+		} else {
+			tb.writeType(v.EvalType)
+		}
+	default:
+		tb.write(dt.Repr())
+	}
+}
+
+// writeTypeList writes a comma-separated list of data types
+func (tb *docTextBuilder) writeTypeList(dts []typing.DataType) {
+	for i, dt := range dts {
+		if i > 0 {
+			tb.write(", ")
+		}
+
+		tb.writeType(dt)
+	}
+}
+
+// writeTypeParams writes the type parameters of a generic along with their
+// constraints (if there are any type parameters)
+func (tb *docTextBuilder) writeTypeParams(typeParams []*typing.WildcardType) {
+	if len(typeParams) == 0 {
+		return
+	}
+
+	tb.write("<")
+	for i, tp := range typeParams {
+		if i > 0 {
+			tb.write(", ")
+		}
+
+		tb.write(tp.Name)
+
+		if len(tp.Constraints) > 0 {
+			tb.write(": ")
+			tb.writeConstraint(tp.Constraints)
+		}
+	}
+	tb.write(">")
+}
+
+// writeConstraint writes the constraint of a type parameter.  Since constraints
+// are expanded when they are applied, we write the name of the first exported
+// constraint that stands for the same types if there is one.
+func (tb *docTextBuilder) writeConstraint(constraints []typing.DataType) {
+	for _, dc := range tb.dg.constraints {
+		if len(dc.ct.Types) != len(constraints) {
+			continue
+		}
+
+		matches := true
+		for _, dt := range constraints {
+			if !typing.ContainsType(dt, dc.ct.Types) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			tb.writeLink(dc.name, dc.pkgID)
+			return
+		}
+	}
+
+	for i, dt := range constraints {
+		if i > 0 {
+			tb.write(" | ")
+		}
+
+		tb.writeType(dt)
+	}
+}
+
+// writeFuncSignature writes the signature of a named function, method or
+// operator (`keyword` is `func` or `oper`): the function type is written as it
+// is by `FuncType.Repr` with the name and type parameters of the function
+// inserted before its arguments
+func (tb *docTextBuilder) writeFuncSignature(keyword, name string, dt typing.DataType) {
+	var typeParams []*typing.WildcardType
+	if gt, ok := dt.(*typing.GenericType); ok {
+		dt = gt.Template
+		typeParams = gt.TypeParams
+	}
+
+	ft, ok := dt.(*typing.FuncType)
+	if !ok {
+		tb.write(name + ": ")
+		tb.writeType(dt)
+		return
+	}
+
+	if ft.Async {
+		keyword = "async"
+	}
+
+	tb.write(keyword + " " + name)
+	tb.writeTypeParams(typeParams)
+	tb.writeFuncType(ft)
+}
+
+// writeFuncType writes the arguments and return type of a function type
+func (tb *docTextBuilder) writeFuncType(ft *typing.FuncType) {
+	tb.write("(")
+	for i, arg := range ft.Args {
+		if i > 0 {
+			tb.write(", ")
+		}
+
+		if arg.Indefinite {
+			tb.write("...")
+		} else if arg.Optional {
+			tb.write("~")
+		}
+
+		if arg.Name != "" {
+			tb.write(arg.Name + ": ")
+		}
+
+		tb.writeType(arg.Val.Type)
+	}
+	tb.write(")(")
+
+	tb.writeType(ft.ReturnType)
+	tb.write(")")
+}
+
+// writeValue writes a named value (eg. a struct field)
+func (tb *docTextBuilder) writeValue(name string, tv *typing.TypedValue) {
+	if tv.Constant {
+		tb.write("const ")
+	}
+
+	if tv.Volatile {
+		tb.write("vol ")
+	}
+
+	tb.write(name + ": ")
+	tb.writeType(tv.Type)
+}
+
+// -----------------------------------------------------------------------------
+
+// docSection is a titled section of a documentation page
+type docSection struct {
+	title string
+	items []*docItem
+}
+
+// sections returns the non-empty sections of a page in the order they are
+// displayed
+func (page *docPage) sections() []*docSection {
+	var sections []*docSection
+	for _, section := range []*docSection{
+		{"Types", page.types},
+		{"Functions", page.funcs},
+		{"Values", page.values},
+		{"Bindings", page.bindings},
+		{"Operators", page.operators},
+	} {
+		if len(section.items) > 0 {
+			sections = append(sections, section)
+		}
+	}
+
+	return sections
+}
+
+// renderPage renders the documentation page of a package
+func (dg *docGenerator) renderPage(page *docPage) string {
+	title := fmt.Sprintf("Package %s", strings.ReplaceAll(page.importPath, "/", "::"))
+	sb := strings.Builder{}
+
+	if dg.format == "html" {
+		writeHTMLHeader(&sb, title, relativeLink(page.path, "index.html"))
+
+		for _, section := range page.sections() {
+			sb.WriteString(fmt.Sprintf("<h2>%s</h2>\n", section.title))
+
+			for _, item := range section.items {
+				dg.renderHTMLItem(&sb, page, item)
+			}
+		}
+
+		sb.WriteString("</body>\n</html>\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("[Index](%s)\n\n# %s\n", relativeLink(page.path, "index.md"), title))
+
+		for _, section := range page.sections() {
+			sb.WriteString(fmt.Sprintf("\n## %s\n", section.title))
+
+			for _, item := range section.items {
+				dg.renderMarkdownItem(&sb, page, item)
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// renderIndex renders the index page listing all of the documented packages
+func (dg *docGenerator) renderIndex(pages []*docPage) string {
+	sb := strings.Builder{}
+
+	if dg.format == "html" {
+		writeHTMLHeader(&sb, "Packages", "")
+
+		sb.WriteString("<ul>\n")
+		for _, page := range pages {
+			sb.WriteString(fmt.Sprintf("<li><a href=\"%s\">%s</a></li>\n",
+				html.EscapeString(page.path), html.EscapeString(strings.ReplaceAll(page.importPath, "/", "::"))))
+		}
+		sb.WriteString("</ul>\n</body>\n</html>\n")
+	} else {
+		sb.WriteString("# Packages\n\n")
+
+		for _, page := range pages {
+			sb.WriteString(fmt.Sprintf("- [%s](%s)\n", escapeMarkdown(strings.ReplaceAll(page.importPath, "/", "::")), page.path))
+		}
+	}
+
+	return sb.String()
+}
+
+// renderHTMLItem renders a documented item as HTML
+func (dg *docGenerator) renderHTMLItem(sb *strings.Builder, page *docPage, item *docItem) {
+	if item.name != "" {
+		sb.WriteString(fmt.Sprintf("<div class=\"item\" id=\"%s\">\n", html.EscapeString(item.name)))
+	} else {
+		sb.WriteString("<div class=\"item\">\n")
+	}
+
+	sb.WriteString("<pre class=\"signature\">")
+	dg.renderHTMLText(sb, page, item.signature)
+	sb.WriteString("</pre>\n")
+
+	if item.docComment != "" {
+		sb.WriteString(fmt.Sprintf("<p>%s</p>\n", htmlDocComment(item.docComment)))
+	}
+
+	if len(item.members) > 0 {
+		sb.WriteString("<ul class=\"members\">\n")
+
+		for _, member := range item.members {
+			sb.WriteString("<li><code>")
+			dg.renderHTMLText(sb, page, member.signature)
+			sb.WriteString("</code>")
+
+			if member.docComment != "" {
+				sb.WriteString(fmt.Sprintf("<p>%s</p>", htmlDocComment(member.docComment)))
+			}
+
+			sb.WriteString("</li>\n")
+		}
+
+		sb.WriteString("</ul>\n")
+	}
+
+	sb.WriteString("</div>\n")
+}
+
+// renderHTMLText renders documentation text as HTML
+func (dg *docGenerator) renderHTMLText(sb *strings.Builder, page *docPage, text docText) {
+	for _, seg := range text {
+		if seg.target == nil {
+			sb.WriteString(html.EscapeString(seg.text))
+		} else {
+			sb.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>",
+				html.EscapeString(seg.href(page)), html.EscapeString(seg.text)))
+		}
+	}
+}
+
+// renderMarkdownItem renders a documented item as Markdown.  Signatures are not
+// written as code so that the links in them are rendered.
+func (dg *docGenerator) renderMarkdownItem(sb *strings.Builder, page *docPage, item *docItem) {
+	sb.WriteRune('\n')
+
+	if item.name != "" {
+		sb.WriteString(fmt.Sprintf("<a id=\"%s\"></a>\n\n", item.name))
+		sb.WriteString(fmt.Sprintf("### %s\n\n", escapeMarkdown(item.name)))
+	}
+
+	sb.WriteString("> ")
+	dg.renderMarkdownText(sb, page, item.signature)
+	sb.WriteString("\n")
+
+	if item.docComment != "" {
+		sb.WriteString("\n" + item.docComment + "\n")
+	}
+
+	if len(item.members) > 0 {
+		sb.WriteRune('\n')
+
+		for _, member := range item.members {
+			sb.WriteString("- ")
+			dg.renderMarkdownText(sb, page, member.signature)
+
+			if member.docComment != "" {
+				sb.WriteString(": " + strings.ReplaceAll(member.docComment, "\n", " "))
+			}
+
+			sb.WriteRune('\n')
+		}
+	}
+}
+
+// renderMarkdownText renders documentation text as Markdown
+func (dg *docGenerator) renderMarkdownText(sb *strings.Builder, page *docPage, text docText) {
+	for _, seg := range text {
+		if seg.target == nil {
+			sb.WriteString(escapeMarkdown(seg.text))
+		} else {
+			sb.WriteString(fmt.Sprintf("[%s](%s)", escapeMarkdown(seg.text), seg.href(page)))
+		}
+	}
+}
+
+// writeHTMLHeader writes the beginning of an HTML page up to and including its
+// title.  If `indexLink` is not empty, a link to the index page is included.
+func writeHTMLHeader(sb *strings.Builder, title, indexLink string) {
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString(fmt.Sprintf("<title>%s</title>\n", html.EscapeString(title)))
+	sb.WriteString("<style>\n")
+	sb.WriteString("body { font-family: sans-serif; max-width: 60em; margin: auto; padding: 1em; }\n")
+	sb.WriteString("pre.signature { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }\n")
+	sb.WriteString(".item { margin-bottom: 1.5em; }\n")
+	sb.WriteString("</style>\n</head>\n<body>\n")
+
+	if indexLink != "" {
+		sb.WriteString(fmt.Sprintf("<nav><a href=\"%s\">Index</a></nav>\n", html.EscapeString(indexLink)))
+	}
+
+	sb.WriteString(fmt.Sprintf("<h1>%s</h1>\n", html.EscapeString(title)))
+}
+
+// htmlDocComment converts a doc comment into HTML: text between backticks is
+// rendered as code
+func htmlDocComment(docComment string) string {
+	sb := strings.Builder{}
+
+	for i, part := range strings.Split(docComment, "`") {
+		if i%2 == 1 {
+			sb.WriteString("<code>" + html.EscapeString(part) + "</code>")
+		} else {
+			sb.WriteString(html.EscapeString(part))
+		}
+	}
+
+	return sb.String()
+}
+
+// escapeMarkdown escapes all of the characters in a string that have special
+// meaning in Markdown
+func escapeMarkdown(s string) string {
+	sb := strings.Builder{}
+
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_[]<>|#~", r) {
+			sb.WriteRune('\\')
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// relativeLink returns the link from one page to another (both given relative
+// to the output directory)
+func relativeLink(from, to string) string {
+	depth := strings.Count(path.Dir(from), "/")
+	if path.Dir(from) != "." {
+		depth++
+	}
+
+	return strings.Repeat("../", depth) + to
+}
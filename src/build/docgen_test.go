@@ -0,0 +1,128 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// docProject is a project whose main package uses the types of a subpackage
+// in its exported API
+var docProject = map[string]string{
+	"whirl-mod.yml": "name: proj\n",
+	"main.wrl": "!! no_prelude\nimport Point, Shape from proj::geom\n\nexport of\n" +
+		"    # `origin` gets the origin\n    func origin(p: Point) Point -> p\n\n" +
+		"    interf for Point is Shape of\n        func area() bool -> true\n\nfunc main() -> 0\n",
+	"geom/geom.wrl": "!! no_prelude\n\nexport of\n    # `Point` is a point\n    type Point {\n        x, y: bool\n    }\n\n" +
+		"    interf Shape of\n        # `area` gets the area\n        func area() bool\n\n" +
+		"    closed type Dir | Up | Down\n",
+}
+
+// writeTestDocs writes the documentation of the project in the given directory
+// in the given format and returns the contents of the pages by path
+func writeTestDocs(t *testing.T, dir, format string) map[string]string {
+	t.Helper()
+
+	c, mainPkg, _ := analyzeTestProject(t, dir, "proj")
+
+	outDir := filepath.Join(t.TempDir(), "docs")
+	if err := c.WriteDocs(mainPkg, format, outDir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	pages := make(map[string]string)
+	err := filepath.Walk(outDir, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+
+		relPath, _ := filepath.Rel(outDir, fpath)
+		pages[filepath.ToSlash(relPath)] = string(content)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pages
+}
+
+func TestWriteDocsMarkdown(t *testing.T) {
+	pages := writeTestDocs(t, writeTestProject(t, docProject), "md")
+
+	// only the packages of the main module are documented
+	if len(pages) != 3 {
+		paths := make([]string, 0, len(pages))
+		for path := range pages {
+			paths = append(paths, path)
+		}
+
+		t.Fatalf("got the pages %v; want the index, `proj` and `proj::geom`", paths)
+	}
+
+	for path, want := range map[string][]string{
+		"index.md": {"[proj](proj/index.md)", "[proj::geom](proj/geom/index.md)"},
+		"proj/index.md": {
+			// the types of `geom` link to its page
+			"func origin(p: [Point](../proj/geom/index.md#Point))([Point](../proj/geom/index.md#Point))",
+			"`origin` gets the origin",
+			"interf for [Point](../proj/geom/index.md#Point) is [Shape](../proj/geom/index.md#Shape)",
+		},
+		"proj/geom/index.md": {
+			"type Point",
+			"`Point` is a point",
+			"x: bool",
+			"interf Shape",
+			"func area()(bool): `area` gets the area",
+			"closed type Dir",
+			"| Up",
+		},
+	} {
+		content, ok := pages[path]
+		if !ok {
+			t.Errorf("missing the page `%s`", path)
+			continue
+		}
+
+		for _, s := range want {
+			if !strings.Contains(content, s) {
+				t.Errorf("`%s` does not contain `%s`:\n%s", path, s, content)
+			}
+		}
+	}
+}
+
+func TestWriteDocsHTML(t *testing.T) {
+	pages := writeTestDocs(t, writeTestProject(t, docProject), "html")
+
+	content, ok := pages["proj/index.html"]
+	if !ok {
+		t.Fatal("missing the page `proj/index.html`")
+	}
+
+	if !strings.Contains(content, `<a href="../proj/geom/index.html#Point">Point</a>`) {
+		t.Errorf("`Point` does not link to its definition:\n%s", content)
+	}
+
+	if _, ok := pages["proj/geom/index.html"]; !ok {
+		t.Error("missing the page `proj/geom/index.html`")
+	}
+}
+
+func TestWriteDocsCachedSubpackage(t *testing.T) {
+	dir := writeTestProject(t, libProject)
+	buildTestLib(t, dir)
+
+	// `util` is loaded from the cache but it still belongs to the main module
+	pages := writeTestDocs(t, dir, "md")
+	if _, ok := pages["proj/util/index.md"]; !ok {
+		t.Error("missing the page `proj/util/index.md`")
+	}
+}
@@ -0,0 +1,177 @@
+package build
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/resolve"
+)
+
+// graphPackage is a node in the exported dependency graph
+type graphPackage struct {
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	RootDirectory string `json:"root_directory"`
+	Module        string `json:"module"`
+	Prelude       bool   `json:"prelude"`
+}
+
+// graphImport is an edge in the exported dependency graph.  `Symbols` is empty
+// if the package is imported by name.
+type graphImport struct {
+	From    uint     `json:"from"`
+	To      uint     `json:"to"`
+	Symbols []string `json:"symbols"`
+}
+
+// dependencyGraph is the exported dependency graph.  `Cycles` contains the IDs
+// of the packages in every resolution unit made up of more than one package.
+type dependencyGraph struct {
+	Packages []*graphPackage `json:"packages"`
+	Imports  []*graphImport  `json:"imports"`
+	Cycles   [][]uint        `json:"cycles"`
+}
+
+// SetDependencyGraphOutput sets the format and path of the dependency graph
+// that should be emitted during compilation.  The valid formats are `dot` and
+// `json`.  If no path is given, the graph is written to `deps.<format>`.
+func (c *Compiler) SetDependencyGraphOutput(format, path string) error {
+	switch format {
+	case "dot", "json":
+		c.graphFormat = format
+	default:
+		return errors.New("Invalid dependency graph format")
+	}
+
+	if path == "" {
+		path = "deps." + format
+	}
+
+	c.graphPath = path
+	return nil
+}
+
+// emitDependencyGraph writes the dependency graph of the main package to the
+// graph path in the graph format.  This should be called once all packages
+// have been initialized.
+func (c *Compiler) emitDependencyGraph(mainPkg *common.WhirlPackage) {
+	graph := &dependencyGraph{}
+
+	for _, pkg := range c.Packages() {
+		gpkg := &graphPackage{
+			ID:            pkg.PackageID,
+			Name:          pkg.Name,
+			RootDirectory: pkg.RootDirectory,
+			Prelude:       pkg.PreludeImport,
+		}
+
+		if pkg.ParentModule != nil {
+			gpkg.Module = pkg.ParentModule.Name
+		}
+
+		graph.Packages = append(graph.Packages, gpkg)
+
+		for _, wimport := range Imports(pkg) {
+			gimport := &graphImport{From: pkg.PackageID, To: wimport.PackageRef.PackageID, Symbols: []string{}}
+			for name := range wimport.ImportedSymbols {
+				gimport.Symbols = append(gimport.Symbols, name)
+			}
+
+			sort.Strings(gimport.Symbols)
+			graph.Imports = append(graph.Imports, gimport)
+		}
+	}
+
+	// the grouper determines which packages need to be resolved together
+	// (because they depend on each other) -- these are our cycles
+	graph.Cycles = [][]uint{}
+	for _, unit := range resolve.NewGrouper(mainPkg, c.depGraph, c.packagePaths, nil, c.jobs).GroupAll() {
+		if len(unit) > 1 {
+			cycle := make([]uint, len(unit))
+			for i, pkg := range unit {
+				cycle[i] = pkg.PackageID
+			}
+
+			graph.Cycles = append(graph.Cycles, cycle)
+		}
+	}
+
+	f, err := os.Create(c.graphPath)
+	if err != nil {
+		logging.LogInternalError("Graph", fmt.Sprintf("Unable to write dependency graph: %s", err))
+		return
+	}
+
+	defer f.Close()
+
+	if c.graphFormat == "json" {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "    ")
+		err = enc.Encode(graph)
+	} else {
+		err = graph.writeDot(f)
+	}
+
+	if err != nil {
+		logging.LogInternalError("Graph", fmt.Sprintf("Unable to write dependency graph: %s", err))
+	}
+}
+
+// writeDot writes the dependency graph in the Graphviz DOT format.  Cycles are
+// drawn as highlighted clusters.
+func (g *dependencyGraph) writeDot(w io.Writer) error {
+	sb := strings.Builder{}
+
+	sb.WriteString("digraph packages {\n")
+	sb.WriteString("    node [shape=box];\n")
+
+	for i, cycle := range g.Cycles {
+		sb.WriteString(fmt.Sprintf("\n    subgraph cluster_cycle%d {\n", i))
+		sb.WriteString(fmt.Sprintf("        label=%s;\n", dotString(fmt.Sprintf("cycle %d", i+1))))
+		sb.WriteString("        style=filled;\n        color=\"#f4cccc\";\n")
+
+		for _, id := range cycle {
+			sb.WriteString(fmt.Sprintf("        p%d;\n", id))
+		}
+
+		sb.WriteString("    }\n")
+	}
+
+	sb.WriteRune('\n')
+	for _, pkg := range g.Packages {
+		label := fmt.Sprintf("%s\n%s\nmodule: %s", pkg.Name, pkg.RootDirectory, pkg.Module)
+		if pkg.Prelude {
+			sb.WriteString(fmt.Sprintf("    p%d [label=%s, style=dashed];\n", pkg.ID, dotString(label)))
+		} else {
+			sb.WriteString(fmt.Sprintf("    p%d [label=%s];\n", pkg.ID, dotString(label)))
+		}
+	}
+
+	sb.WriteRune('\n')
+	for _, gimport := range g.Imports {
+		if len(gimport.Symbols) > 0 {
+			sb.WriteString(fmt.Sprintf("    p%d -> p%d [label=%s];\n", gimport.From, gimport.To, dotString(strings.Join(gimport.Symbols, ", "))))
+		} else {
+			sb.WriteString(fmt.Sprintf("    p%d -> p%d;\n", gimport.From, gimport.To))
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// dotString converts a string into a quoted DOT string
+func dotString(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return "\"" + strings.ReplaceAll(s, "\n", "\\n") + "\""
+}
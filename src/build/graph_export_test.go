@@ -0,0 +1,138 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+// cycleProject is a project whose `a` and `b` packages import each other
+var cycleProject = map[string]string{
+	"whirl-mod.yml": "name: proj\ncycles: allow\n",
+	"main.wrl":      "!! no_prelude\nimport proj::a\n\nfunc main() -> 0\n",
+	"a/a.wrl":       "!! no_prelude\nimport f from proj::b\n\nfunc g() -> f()\n",
+	"b/b.wrl":       "!! no_prelude\nimport proj::a\n\nexport of\n    func f() -> 1\n",
+}
+
+// loadTestGraph loads the dependency graph of a project and emits it in the
+// given format
+func loadTestGraph(t *testing.T, files map[string]string, format string) string {
+	t.Helper()
+
+	c := newTestCompiler(t, files)
+	if err := c.SetDependencyGraphOutput(format, filepath.Join(t.TempDir(), "deps."+format)); err != nil {
+		t.Fatal(err)
+	}
+
+	mainPkg, ok := c.LoadDependencyGraph()
+	if !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to load the dependency graph")
+	}
+
+	c.emitDependencyGraph(mainPkg)
+
+	data, err := os.ReadFile(c.graphPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestSetDependencyGraphOutput(t *testing.T) {
+	c := &Compiler{}
+
+	if err := c.SetDependencyGraphOutput("json", ""); err != nil || c.graphPath != "deps.json" {
+		t.Errorf("got path `%s` and error %v, want `deps.json`", c.graphPath, err)
+	}
+
+	if err := c.SetDependencyGraphOutput("svg", ""); err == nil {
+		t.Error("expected an error for an invalid format")
+	}
+}
+
+func TestEmitDependencyGraphJSON(t *testing.T) {
+	graph := &dependencyGraph{}
+	if err := json.Unmarshal([]byte(loadTestGraph(t, cycleProject, "json")), graph); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]uint)
+	for _, pkg := range graph.Packages {
+		if pkg.Module == "proj" {
+			ids[pkg.Name] = pkg.ID
+		}
+	}
+
+	if len(ids) != 3 {
+		t.Fatalf("got packages %v, want `proj`, `a` and `b`", ids)
+	}
+
+	symbols := make(map[[2]uint][]string)
+	for _, gimport := range graph.Imports {
+		symbols[[2]uint{gimport.From, gimport.To}] = gimport.Symbols
+	}
+
+	edges := []struct {
+		from, to string
+		symbols  string
+	}{
+		{"proj", "a", ""},
+		{"a", "b", "f"},
+		{"b", "a", ""},
+	}
+
+	for _, e := range edges {
+		got, ok := symbols[[2]uint{ids[e.from], ids[e.to]}]
+		if !ok {
+			t.Errorf("missing import of `%s` by `%s`", e.to, e.from)
+		} else if strings.Join(got, ",") != e.symbols {
+			t.Errorf("`%s` imports %v from `%s`, want `%s`", e.from, got, e.to, e.symbols)
+		}
+	}
+
+	if len(graph.Cycles) != 1 || len(graph.Cycles[0]) != 2 {
+		t.Fatalf("got cycles %v, want one cycle of `a` and `b`", graph.Cycles)
+	}
+
+	for _, id := range graph.Cycles[0] {
+		if id != ids["a"] && id != ids["b"] {
+			t.Errorf("package %d should not be in the cycle", id)
+		}
+	}
+}
+
+func TestEmitDependencyGraphDot(t *testing.T) {
+	dot := loadTestGraph(t, cycleProject, "dot")
+
+	if !strings.HasPrefix(dot, "digraph packages {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("malformed DOT graph:\n%s", dot)
+	}
+
+	for _, want := range []string{"subgraph cluster_cycle0", "label=\"cycle 1\"", "[label=\"f\"]"} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT graph does not contain `%s`:\n%s", want, dot)
+		}
+	}
+}
+
+func TestWriteDotEscaping(t *testing.T) {
+	graph := &dependencyGraph{
+		Packages: []*graphPackage{{ID: 1, Name: "a", RootDirectory: `C:\proj\"a"`, Module: "proj", Prelude: true}},
+	}
+
+	buff := &bytes.Buffer{}
+	if err := graph.writeDot(buff); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `p1 [label="a\nC:\\proj\\\"a\"\nmodule: proj", style=dashed];`; !strings.Contains(buff.String(), want) {
+		t.Errorf("got:\n%s\nwant a line containing:\n%s", buff.String(), want)
+	}
+}
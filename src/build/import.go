@@ -67,7 +67,8 @@ func (c *Compiler) initDependencies(pkg *common.WhirlPackage) bool {
 	// set our initialization flag before we start recurring
 	pkg.Initialized = true
 
-	for fpath, file := range pkg.Files {
+	for _, fpath := range pkg.SortedFilePaths() {
+		file := pkg.Files[fpath]
 		c.lctx.FilePath = fpath
 
 		// attach the prelude for a file as necessary
@@ -141,13 +142,17 @@ func (c *Compiler) processImport(pkg *common.WhirlPackage, file *common.WhirlFil
 	}
 
 	// calculate the absolute path to the package
-	abspath := c.getPackagePath(pkg.ParentModule, relPath)
+	abspath, parentModule := c.getPackagePath(pkg.ParentModule, relPath)
 	if abspath == "" {
+		// the path is displayed as it is written in source code
+		importPath := strings.ReplaceAll(relPath, "/", "::")
+
 		logging.LogCompileError(
 			c.lctx,
-			fmt.Sprintf("Unable to locate package at path `%s`", relPath),
+			fmt.Sprintf("Unable to locate package at path `%s`", importPath),
 			logging.LMKImport,
 			pathPosition,
+			logging.WithSuggestion(importPath, c.packagePathCandidates(pkg.ParentModule, strings.Count(relPath, "/")+1)),
 		)
 
 		return false
@@ -158,9 +163,9 @@ func (c *Compiler) processImport(pkg *common.WhirlPackage, file *common.WhirlFil
 	if !ok {
 		var initOk bool
 
-		// pass the parent module of the package importing to the new package if
-		// no parent module exists
-		newpkg, initOk = c.initPackage(abspath, pkg.ParentModule)
+		// pass the parent module determined by the package path to the new
+		// package if it has no module of its own
+		newpkg, initOk = c.initPackage(abspath, parentModule)
 		if !initOk {
 			return false
 		}
@@ -177,12 +182,16 @@ func (c *Compiler) processImport(pkg *common.WhirlPackage, file *common.WhirlFil
 	}
 
 	// now that we have processed the import, we can attach the package to a file
-	return c.attachPackageToFile(pkg, file, newpkg, importedSymbols, rename, namePosition)
+	return c.attachPackageToFile(pkg, file, newpkg, importedSymbols, rename, namePosition, pathPosition)
 }
 
 // getPackagePath determines, from a relative path, the absolute path to a
-// package (from module dir, local pkg dir, global/pub pkg dir or std pkg dir)
-func (c *Compiler) getPackagePath(parentModule *mods.Module, relpath string) string {
+// package (from module dir, module dependency dir, local pkg dir, global/pub
+// pkg dir or std pkg dir).  It also returns the module that should be used as
+// the parent module of the package: this is the module of the dependency for
+// packages located in dependencies and the given parent module otherwise.  If
+// no package can be found, the returned path is empty.
+func (c *Compiler) getPackagePath(parentModule *mods.Module, relpath string) (string, *mods.Module) {
 	validPath := func(abspath string) bool {
 		fi, err := os.Stat(abspath)
 
@@ -193,18 +202,41 @@ func (c *Compiler) getPackagePath(parentModule *mods.Module, relpath string) str
 		return false
 	}
 
-	if strings.HasPrefix(relpath, parentModule.Name) {
+	if strings.HasPrefix(relpath, parentModule.Name+"/") {
 		modRelPath := strings.TrimPrefix(relpath, parentModule.Name+"/")
 
 		// path overrides should already be checked and made absolute when
-		// module is loaded
-		if pathOverride, ok := parentModule.PathOverrides[modRelPath]; ok {
-			return pathOverride
+		// module is loaded.  If an override exists, it is the only place we
+		// look: falling back to some other package would be very confusing
+		if pathOverride, ok := parentModule.LookupPathOverride(modRelPath); ok {
+			if validPath(pathOverride) {
+				return pathOverride, parentModule
+			}
+
+			return "", nil
 		}
 
 		bdAbsPath := filepath.Join(parentModule.Path, modRelPath)
 		if validPath(bdAbsPath) {
-			return bdAbsPath
+			return bdAbsPath, parentModule
+		}
+	}
+
+	// dependencies are accessed by their module name (just like the packages
+	// of the current module) and their packages belong to their module
+	depName := strings.SplitN(relpath, "/", 2)[0]
+	if depPath, ok := parentModule.Dependencies[depName]; ok {
+		depAbsPath := filepath.Join(depPath, strings.TrimPrefix(relpath, depName))
+		if validPath(depAbsPath) {
+			depModule := c.getModule(depPath)
+
+			// if the dependency's module file is broken, the errors have
+			// already been logged so we can just fail here
+			if depModule == nil {
+				return "", nil
+			}
+
+			return depAbsPath, depModule
 		}
 	}
 
@@ -216,21 +248,92 @@ func (c *Compiler) getPackagePath(parentModule *mods.Module, relpath string) str
 		}
 
 		if validPath(localAbsPath) {
-			return localAbsPath
+			return localAbsPath, parentModule
 		}
 	}
 
 	pubdirabspath := filepath.Join(c.whirlpath, "lib/pub", relpath)
 	if validPath(pubdirabspath) {
-		return pubdirabspath
+		return pubdirabspath, parentModule
 	}
 
 	stddirabspath := filepath.Join(c.whirlpath, "lib/std", relpath)
 	if validPath(stddirabspath) {
-		return stddirabspath
+		return stddirabspath, parentModule
 	}
 
-	return ""
+	return "", nil
+}
+
+// packagePathCandidates lists the import paths (eg. `proj::util`) of the
+// packages that can be imported by a package in the given module (used to
+// suggest corrections for paths that can't be located): the packages of the
+// module and its dependencies, the packages in the local package directories
+// and the public and standard libraries.  Only paths with at most `maxDepth`
+// segments are listed.
+func (c *Compiler) packagePathCandidates(parentModule *mods.Module, maxDepth int) []string {
+	var candidates []string
+
+	// addPackages adds the packages in a directory (and its subdirectories) to
+	// the candidates.  `prefix` is the import path of the directory.
+	var addPackages func(dir, prefix string, depth int)
+	addPackages = func(dir, prefix string, depth int) {
+		if depth >= maxDepth {
+			return
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+
+		for _, entry := range entries {
+			name := syntax.NormalizeIdentifier(entry.Name())
+			if !entry.IsDir() || !mods.IsValidPackageName(name) {
+				continue
+			}
+
+			path := name
+			if prefix != "" {
+				path = prefix + "::" + name
+			}
+
+			candidates = append(candidates, path)
+			addPackages(filepath.Join(dir, entry.Name()), path, depth+1)
+		}
+	}
+
+	// module and dependency packages are imported by the name of their module
+	candidates = append(candidates, parentModule.Name)
+	addPackages(parentModule.Path, parentModule.Name, 1)
+
+	for depName, depPath := range parentModule.Dependencies {
+		candidates = append(candidates, depName)
+		addPackages(depPath, depName, 1)
+	}
+
+	for _, ldirpath := range c.localPkgDirectories {
+		addPackages(ldirpath, "", 0)
+	}
+
+	addPackages(filepath.Join(c.whirlpath, "lib/pub"), "", 0)
+	addPackages(filepath.Join(c.whirlpath, "lib/std"), "", 0)
+
+	return candidates
+}
+
+// getModule loads the module at the given path.  Modules are only loaded once
+// so that errors in their module files are only reported once and so that
+// every package in a module shares the same module instance (packages can be
+// compared by module).  It returns `nil` if the module fails to load.
+func (c *Compiler) getModule(path string) *mods.Module {
+	mod, ok := c.loadedModules[path]
+	if !ok {
+		mod, _ = mods.LoadModule(path)
+		c.loadedModules[path] = mod
+	}
+
+	return mod
 }
 
 // attachPackageToFile attaches an already loaded file to a package (completing
@@ -238,12 +341,15 @@ func (c *Compiler) getPackagePath(parentModule *mods.Module, relpath string) str
 // package (package the file is in) and `newPkg` is the new package (package
 // being attached).  NOTE: `rename` can be blank if the package is not renamed,
 // `namePosition` should point whatever token or branch is used name of the
-// package is derived from (not just rename).
+// package is derived from (not just rename).  `pathPosition` is the position
+// of the package path (`nil` for implicit imports).
 func (c *Compiler) attachPackageToFile(rootPkg *common.WhirlPackage, file *common.WhirlFile,
-	newPkg *common.WhirlPackage, importedSymbols map[string]*logging.TextPosition, rename string, namePosition *logging.TextPosition) bool {
+	newPkg *common.WhirlPackage, importedSymbols map[string]*logging.TextPosition, rename string,
+	namePosition, pathPosition *logging.TextPosition) bool {
 
 	// update the current package's imports
-	if wimport, ok := rootPkg.ImportTable[newPkg.PackageID]; ok {
+	wimport, ok := rootPkg.ImportTable[newPkg.PackageID]
+	if ok {
 		if len(importedSymbols) > 0 {
 			for name, pos := range importedSymbols {
 				if _, ok := file.LocalTable[name]; ok {
@@ -294,6 +400,9 @@ func (c *Compiler) attachPackageToFile(rootPkg *common.WhirlPackage, file *commo
 		rootPkg.ImportTable[newPkg.PackageID] = wimport
 	}
 
+	// record where the import occurs (for diagnostics about the import graph)
+	wimport.Sites = append(wimport.Sites, &common.ImportSite{FilePath: c.lctx.FilePath, Position: pathPosition})
+
 	// make the package visible if it is imported as a named entity
 	if len(importedSymbols) == 0 {
 		// rename supercedes original name
@@ -340,7 +449,7 @@ func (c *Compiler) importBindings(srcpkg *common.WhirlPackage, destfile *common.
 			fmt.Sprintf("Unable to import package `%s`; multiple implementations given for method `%s` bound to `%s`",
 				srcpkg.Name,
 				mname,
-				binding.MatchType.Repr(),
+				c.packagePaths.ReprType(binding.MatchType),
 			),
 			logging.LMKImport,
 			pos,
@@ -374,13 +483,14 @@ func (c *Compiler) importOperators(srcpkg *common.WhirlPackage, destfile *common
 		for _, gopdef := range gopdefs {
 			if gopdef.Exported {
 				if sig, isConflict := destpkg.CheckOperatorConflicts(destfile, opkind, gopdef.Signature); isConflict {
+					sigReprs := c.packagePaths.ReprTypes(sig, gopdef.Signature)
 					logging.LogCompileError(
 						c.lctx,
 						fmt.Sprintf("Unable to import package `%s`; conflicting operator definitions for `%s` of `%s` and `%s`",
 							srcpkg.Name,
 							syntax.GetOperatorTokenValueByKind(opkind),
-							sig.Repr(),
-							gopdef.Signature.Repr(),
+							sigReprs[0],
+							sigReprs[1],
 						),
 						logging.LMKImport,
 						pos,
@@ -0,0 +1,431 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/typing"
+)
+
+// Interface Files
+// ---------------
+// An interface file stores the exported interface of a package so that the
+// package can be imported without its sources (eg. when it is shipped as part
+// of a precompiled library).  Interface files are emitted for every package in
+// the main module when building with `-f lib`.  They are written to the output
+// directory at the import path of their package: eg. the package `geo::shapes`
+// is written to `<output>/geo/shapes/shapes.wri`.  A package directory that
+// contains an interface file named after the package but no source files is
+// loaded from its interface file.
+//
+// Package IDs depend on where packages are located so the interface file stores
+// the package IDs that the package and its dependencies had when it was built
+// along with the import paths of its dependencies.  When the interface file is
+// loaded, those IDs are mapped to the IDs of the packages that the import paths
+// resolve to.
+
+// InterfaceFileExtension is the file extension of a package interface file
+const InterfaceFileExtension = ".wri"
+
+// interfaceFileVersion is the version of the format of interface files.  It
+// must be incremented whenever the format of the interface files (or of the
+// encoded types) changes.
+const interfaceFileVersion = 2
+
+// interfaceFile is the contents of a package interface file
+type interfaceFile struct {
+	Version      int                    `json:"version"`
+	Target       string                 `json:"target"`
+	PackageID    uint                   `json:"package_id"`
+	Dependencies []*interfaceDependency `json:"dependencies"`
+	Interface    *packageInterface      `json:"interface"`
+}
+
+// interfaceDependency is a package that the package of an interface file
+// imports
+type interfaceDependency struct {
+	ImportPath string `json:"import_path"`
+	PackageID  uint   `json:"package_id"`
+}
+
+// packageInterface is the encoded, exported interface of a package: its
+// exported symbols, bindings, and operator definitions
+type packageInterface struct {
+	Types     *typing.TypeTable    `json:"types"`
+	Symbols   []*interfaceSymbol   `json:"symbols"`
+	Bindings  []*interfaceBinding  `json:"bindings"`
+	Operators []*interfaceOperator `json:"operators"`
+}
+
+// interfaceSymbol is an encoded, exported symbol
+type interfaceSymbol struct {
+	Name       string         `json:"name"`
+	Type       typing.TypeRef `json:"type"`
+	Constant   bool           `json:"constant,omitempty"`
+	DefKind    int            `json:"def_kind"`
+	DocComment string         `json:"doc_comment,omitempty"`
+}
+
+// interfaceBinding is an encoded, exported binding
+type interfaceBinding struct {
+	MatchType  typing.TypeRef   `json:"match_type"`
+	Wildcards  []typing.TypeRef `json:"wildcards,omitempty"`
+	TypeInterf typing.TypeRef   `json:"type_interf"`
+}
+
+// interfaceOperator is an encoded, exported operator definition
+type interfaceOperator struct {
+	Kind      int            `json:"kind"`
+	Signature typing.TypeRef `json:"signature"`
+}
+
+// -----------------------------------------------------------------------------
+
+// writeInterfaceFiles writes the interface files of all the packages in the
+// main module to the output directory (including those loaded from the build
+// cache).  If the interface file of any of them can't be written, an error is
+// logged and false is returned.  This should only be called once all the
+// packages have been successfully validated.
+func (c *Compiler) writeInterfaceFiles(mainPkg *common.WhirlPackage) bool {
+	for _, pkg := range c.Packages() {
+		if !inModule(pkg, mainPkg.ParentModule) {
+			continue
+		}
+
+		importPath, ok := c.importPathOf(pkg)
+		if !ok {
+			logging.LogInternalError("Interface", fmt.Sprintf("Unable to determine the import path of package `%s`", pkg.Name))
+			return false
+		}
+
+		ifile := &interfaceFile{
+			Version:   interfaceFileVersion,
+			Target:    c.targetos + "/" + c.targetarch,
+			PackageID: pkg.PackageID,
+		}
+
+		for _, wimport := range Imports(pkg) {
+			depImportPath, ok := c.importPathOf(wimport.PackageRef)
+			if !ok {
+				logging.LogInternalError("Interface", fmt.Sprintf("Unable to determine the import path of package `%s`", wimport.PackageRef.Name))
+				return false
+			}
+
+			ifile.Dependencies = append(ifile.Dependencies, &interfaceDependency{
+				ImportPath: depImportPath,
+				PackageID:  wimport.PackageRef.PackageID,
+			})
+		}
+
+		if ifile.Interface, ok = encodePackageInterface(pkg); !ok {
+			logging.LogInternalError("Interface", fmt.Sprintf("Unable to encode the interface of package `%s`", pkg.Name))
+			return false
+		}
+
+		data, err := json.MarshalIndent(ifile, "", "  ")
+		if err != nil {
+			logging.LogInternalError("Interface", err.Error())
+			return false
+		}
+
+		outDir := filepath.Join(c.outputPath, filepath.FromSlash(importPath))
+		if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+			logging.LogInternalError("File", err.Error())
+			return false
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(outDir, filepath.Base(outDir)+InterfaceFileExtension), data, 0644); err != nil {
+			logging.LogInternalError("File", err.Error())
+			return false
+		}
+	}
+
+	return true
+}
+
+// importPathOf determines the path by which a package can be imported from any
+// other module (using `/` as the separator).  This is the path of the package
+// relative to its module prefixed by the module's name or the path of the
+// package relative to the public or standard library directory.
+func (c *Compiler) importPathOf(pkg *common.WhirlPackage) (string, bool) {
+	relPathIn := func(dir string) (string, bool) {
+		relPath, err := filepath.Rel(dir, pkg.RootDirectory)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+
+		return filepath.ToSlash(relPath), true
+	}
+
+	if pkg.ParentModule != nil {
+		if relPath, ok := relPathIn(pkg.ParentModule.Path); ok {
+			if relPath == "." {
+				return pkg.ParentModule.Name, true
+			}
+
+			return pkg.ParentModule.Name + "/" + relPath, true
+		}
+	}
+
+	for _, libDir := range []string{"lib/pub", "lib/std"} {
+		if relPath, ok := relPathIn(filepath.Join(c.whirlpath, libDir)); ok && relPath != "." {
+			return relPath, true
+		}
+	}
+
+	return "", false
+}
+
+// interfaceFilePath returns the path to the interface file of the package at
+// the given path if the package should be loaded from it: ie. if the package
+// has an interface file but no source files.  `files` are the contents of the
+// package directory.
+func interfaceFilePath(abspath string, files []os.FileInfo) (string, bool) {
+	hasInterfaceFile := false
+	for _, finfo := range files {
+		if finfo.IsDir() {
+			continue
+		}
+
+		switch finfo.Name() {
+		case filepath.Base(abspath) + InterfaceFileExtension:
+			hasInterfaceFile = true
+		default:
+			if filepath.Ext(finfo.Name()) == SrcFileExtension {
+				return "", false
+			}
+		}
+	}
+
+	if hasInterfaceFile {
+		return filepath.Join(abspath, filepath.Base(abspath)+InterfaceFileExtension), true
+	}
+
+	return "", false
+}
+
+// loadInterfacePackage loads an initialized (but empty) package from its
+// interface file and initializes all of its dependencies.
+func (c *Compiler) loadInterfacePackage(pkg *common.WhirlPackage, ifpath string) (*common.WhirlPackage, bool) {
+	data, err := ioutil.ReadFile(ifpath)
+	if err != nil {
+		logging.LogInternalError("File", err.Error())
+		return nil, false
+	}
+
+	ifile := &interfaceFile{}
+	if err := json.Unmarshal(data, ifile); err != nil || ifile.Interface == nil {
+		logging.LogInternalError("Interface", fmt.Sprintf("Malformed interface file for package `%s`", pkg.Name))
+		return nil, false
+	}
+
+	if ifile.Version != interfaceFileVersion {
+		logging.LogInternalError("Interface", fmt.Sprintf("Interface file for package `%s` has version %d (expected version %d)",
+			pkg.Name, ifile.Version, interfaceFileVersion))
+		return nil, false
+	}
+
+	if target := c.targetos + "/" + c.targetarch; ifile.Target != target {
+		logging.LogInternalError("Interface", fmt.Sprintf("Interface file for package `%s` was built for `%s` not `%s`",
+			pkg.Name, ifile.Target, target))
+		return nil, false
+	}
+
+	pkg.Initialized = true
+	pkg.Precompiled = true
+
+	// add the package to the dependency graph before we load its dependencies
+	// so that cyclic dependencies resolve to it
+	c.addPackage(pkg)
+
+	pkgIDs := map[uint]uint{ifile.PackageID: pkg.PackageID}
+	for _, dep := range ifile.Dependencies {
+		abspath, parentModule := c.getPackagePath(pkg.ParentModule, dep.ImportPath)
+		if abspath == "" {
+			logging.LogInternalError("Interface", fmt.Sprintf("Unable to locate package at path `%s` imported by package `%s`",
+				dep.ImportPath, pkg.Name))
+			return nil, false
+		}
+
+		depPkg, ok := c.depGraph[getPackageID(abspath)]
+		if !ok {
+			if depPkg, ok = c.initPackage(abspath, parentModule); !ok {
+				return nil, false
+			}
+
+			if !c.initDependencies(depPkg) {
+				return nil, false
+			}
+		}
+
+		pkgIDs[dep.PackageID] = depPkg.PackageID
+		pkg.ImportTable[depPkg.PackageID] = &common.WhirlImport{
+			PackageRef:      depPkg,
+			ImportedSymbols: make(map[string]*common.Symbol),
+		}
+	}
+
+	if !ifile.Interface.decode(pkg, pkgIDs) {
+		logging.LogInternalError("Interface", fmt.Sprintf("Malformed interface file for package `%s`", pkg.Name))
+		return nil, false
+	}
+
+	return pkg, true
+}
+
+// encodePackageInterface encodes the exported interface of a package
+func encodePackageInterface(pkg *common.WhirlPackage) (*packageInterface, bool) {
+	te := typing.NewTypeEncoder()
+	pi := &packageInterface{Types: te.Table}
+
+	// the symbols are sorted so that identical packages produce identical
+	// entries
+	for _, name := range exportedSymbolNames(pkg) {
+		sym := pkg.GlobalTable[name]
+
+		ref, err := te.Encode(sym.Type)
+		if err != nil {
+			return nil, false
+		}
+
+		pi.Symbols = append(pi.Symbols, &interfaceSymbol{
+			Name:       name,
+			Type:       ref,
+			Constant:   sym.Constant,
+			DefKind:    sym.DefKind,
+			DocComment: sym.DocComment,
+		})
+	}
+
+	for _, binding := range pkg.GlobalBindings.Bindings {
+		if !binding.Exported {
+			continue
+		}
+
+		ib := &interfaceBinding{}
+
+		var err error
+		if ib.MatchType, err = te.Encode(binding.MatchType); err != nil {
+			return nil, false
+		}
+
+		if ib.TypeInterf, err = te.Encode(binding.TypeInterf); err != nil {
+			return nil, false
+		}
+
+		for _, wc := range binding.Wildcards {
+			wcRef, err := te.Encode(wc)
+			if err != nil {
+				return nil, false
+			}
+
+			ib.Wildcards = append(ib.Wildcards, wcRef)
+		}
+
+		pi.Bindings = append(pi.Bindings, ib)
+	}
+
+	opKinds := make([]int, 0, len(pkg.OperatorDefinitions))
+	for opKind := range pkg.OperatorDefinitions {
+		opKinds = append(opKinds, opKind)
+	}
+
+	sort.Ints(opKinds)
+	for _, opKind := range opKinds {
+		for _, opdef := range pkg.OperatorDefinitions[opKind] {
+			if !opdef.Exported {
+				continue
+			}
+
+			ref, err := te.Encode(opdef.Signature)
+			if err != nil {
+				return nil, false
+			}
+
+			pi.Operators = append(pi.Operators, &interfaceOperator{Kind: opKind, Signature: ref})
+		}
+	}
+
+	return pi, true
+}
+
+// decode decodes a package interface into the given package.  `pkgIDs` maps
+// the package IDs stored in the interface to the package IDs they should be
+// replaced with (it can be `nil`).  It returns false if the interface is
+// malformed.
+func (pi *packageInterface) decode(pkg *common.WhirlPackage, pkgIDs map[uint]uint) bool {
+	if pi.Types == nil {
+		return false
+	}
+
+	td := typing.NewTypeDecoder(pi.Types)
+	for from, to := range pkgIDs {
+		td.PackageIDs[from] = to
+	}
+
+	for _, isym := range pi.Symbols {
+		dt, err := td.Decode(isym.Type)
+		if err != nil {
+			return false
+		}
+
+		pkg.GlobalTable[isym.Name] = &common.Symbol{
+			Name:       isym.Name,
+			Type:       dt,
+			Constant:   isym.Constant,
+			DeclStatus: common.DSExported,
+			DefKind:    isym.DefKind,
+			DocComment: isym.DocComment,
+		}
+	}
+
+	for _, ib := range pi.Bindings {
+		matchType, err := td.Decode(ib.MatchType)
+		if err != nil {
+			return false
+		}
+
+		typeInterf, err := td.Decode(ib.TypeInterf)
+		if err != nil {
+			return false
+		}
+
+		binding := &typing.Binding{MatchType: matchType, TypeInterf: typeInterf, Exported: true}
+		for _, wcRef := range ib.Wildcards {
+			wc, err := td.Decode(wcRef)
+			if err != nil {
+				return false
+			}
+
+			wt, ok := wc.(*typing.WildcardType)
+			if !ok {
+				return false
+			}
+
+			binding.Wildcards = append(binding.Wildcards, wt)
+		}
+
+		pkg.GlobalBindings.Bindings = append(pkg.GlobalBindings.Bindings, binding)
+	}
+
+	for _, iop := range pi.Operators {
+		sig, err := td.Decode(iop.Signature)
+		if err != nil {
+			return false
+		}
+
+		pkg.OperatorDefinitions[iop.Kind] = append(pkg.OperatorDefinitions[iop.Kind], &common.WhirlOperatorDefinition{
+			Signature: sig,
+			Exported:  true,
+		})
+	}
+
+	return true
+}
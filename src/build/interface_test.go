@@ -0,0 +1,69 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+func TestWriteInterfaceFilesColdAndWarm(t *testing.T) {
+	dir := writeTestProject(t, libProject)
+	outDir := filepath.Join(dir, "out")
+
+	// the warm build loads `util` from the build cache but it still has to be
+	// shipped with the library
+	for _, run := range []string{"cold", "warm"} {
+		if err := os.RemoveAll(outDir); err != nil {
+			t.Fatal(err)
+		}
+
+		buildTestLib(t, dir)
+
+		for _, ifile := range []string{"proj/proj.wri", "proj/util/util.wri"} {
+			if _, err := os.Stat(filepath.Join(outDir, filepath.FromSlash(ifile))); err != nil {
+				t.Errorf("%s: missing interface file `%s`", run, ifile)
+			}
+		}
+	}
+}
+
+func TestLoadInterfacePackage(t *testing.T) {
+	libDir := writeTestProject(t, libProject)
+	buildTestLib(t, libDir)
+
+	// the library's packages are found in its output directory and they have
+	// no sources so they are loaded from their interface files
+	c := newTestCompiler(t, map[string]string{
+		"whirl-mod.yml": "name: app\n",
+		"main.wrl":      "!! no_prelude\nimport Point from proj::util\n\nfunc f(p: Point) bool -> true\n",
+	})
+
+	if err := c.AddLocalPackageDirectories(filepath.Join(libDir, "out")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.Analyze(); !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to analyze a package that imports a library")
+	}
+
+	for _, pkg := range c.Packages() {
+		if pkg.Name != "util" {
+			continue
+		}
+
+		if !pkg.Precompiled {
+			t.Error("`util` should be loaded from its interface file")
+		}
+
+		if sym, ok := pkg.GlobalTable["Point"]; !ok || sym.DocComment == "" {
+			t.Error("`Point` was not loaded with its doc comment")
+		}
+
+		return
+	}
+
+	t.Error("missing package `util`")
+}
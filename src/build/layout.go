@@ -0,0 +1,95 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"whirlwind/common"
+	"whirlwind/typing"
+)
+
+// Layouts returns the layout service for the target of the compiler.  This
+// should only be called once the compiler has been initialized.
+func (c *Compiler) Layouts() *typing.LayoutService {
+	if c.layouts == nil {
+		c.layouts = typing.NewLayoutService(c.targetarch, c.pointerSize, c.packagePaths)
+	}
+
+	return c.layouts
+}
+
+// WriteLayout writes the memory layout of a type defined in the main package
+// (or in the package named by a qualified name, eg. `io::File`) in a readable
+// form.  This should be called once the packages have been analyzed.
+func (c *Compiler) WriteLayout(w io.Writer, mainPkg *common.WhirlPackage, typeName string) error {
+	dt, err := c.lookupLayoutType(mainPkg, typeName)
+	if err != nil {
+		return err
+	}
+
+	layout, err := c.Layouts().Layout(dt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Layout of `%s` on %s (pointer size: %d)\n", typeName, c.targetarch, c.pointerSize)
+	fmt.Fprintf(w, "  size: %d, align: %d\n", layout.Size, layout.Align)
+
+	if len(layout.Fields) > 0 {
+		fmt.Fprintln(w, "  fields:")
+		writeFieldLayouts(w, layout.Fields)
+	}
+
+	if layout.TagSize > 0 {
+		fmt.Fprintf(w, "  tag: offset 0, size %d\n", layout.TagSize)
+		fmt.Fprintln(w, "  variants:")
+
+		for _, variant := range layout.Variants {
+			fmt.Fprintf(w, "    %s (tag: %d)\n", variant.Name, variant.Tag)
+			writeFieldLayouts(w, variant.Fields)
+		}
+	}
+
+	return nil
+}
+
+// writeFieldLayouts writes a table of field layouts
+func writeFieldLayouts(w io.Writer, fields []*typing.FieldLayout) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for _, field := range fields {
+		fmt.Fprintf(tw, "      %d\t%s\t%s\t(size: %d, align: %d)\n",
+			field.Offset, field.Name, typing.ReprType(field.Type), field.Size, field.Align)
+	}
+
+	tw.Flush()
+}
+
+// lookupLayoutType finds the type with the given name whose layout should be
+// written.  Unqualified names are looked up in the main package.
+func (c *Compiler) lookupLayoutType(mainPkg *common.WhirlPackage, typeName string) (typing.DataType, error) {
+	pkgs, name := []*common.WhirlPackage{mainPkg}, typeName
+	if sepNdx := strings.LastIndex(typeName, "::"); sepNdx > -1 {
+		pkgs, name = nil, typeName[sepNdx+2:]
+
+		for _, pkg := range c.Packages() {
+			if pkg.Name == typeName[:sepNdx] {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	}
+
+	for _, pkg := range pkgs {
+		if sym, ok := pkg.GlobalTable[name]; ok && sym.DefKind == common.DefKindTypeDef {
+			if _, ok := sym.Type.(*typing.GenericType); ok {
+				return nil, fmt.Errorf("Unable to compute the layout of generic type `%s`", typeName)
+			}
+
+			return sym.Type, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Unable to find type `%s`", typeName)
+}
@@ -0,0 +1,45 @@
+package build
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteLayout(t *testing.T) {
+	dir := writeTestProject(t, map[string]string{
+		"whirl-mod.yml": "name: proj\n",
+		"main.wrl": "!! no_prelude\nimport proj::geom\n\ntype Pair {\n    a: bool\n    b: geom::Flags\n}\n\n" +
+			"type Gen<T> {\n    t: T\n}\n\nfunc main() -> 0\n",
+		"geom/geom.wrl": "!! no_prelude\n\nexport of\n    type Flags {\n        x, y: bool\n    }\n",
+	})
+
+	c, mainPkg, _ := analyzeTestProject(t, dir, "proj")
+
+	buff := &bytes.Buffer{}
+	if err := c.WriteLayout(buff, mainPkg, "Pair"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"size: 3, align: 1", "0  a  bool", "1  b  Flags"} {
+		if !strings.Contains(buff.String(), want) {
+			t.Errorf("missing `%s` in:\n%s", want, buff.String())
+		}
+	}
+
+	buff.Reset()
+	if err := c.WriteLayout(buff, mainPkg, "geom::Flags"); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(buff.String(), "size: 2, align: 1") {
+		t.Errorf("wrong layout of `geom::Flags`:\n%s", buff.String())
+	}
+
+	for name, want := range map[string]string{
+		"Gen":     "Unable to compute the layout of generic type `Gen`",
+		"Missing": "Unable to find type `Missing`",
+	} {
+		if err := c.WriteLayout(buff, mainPkg, name); err == nil || err.Error() != want {
+			t.Errorf("got error %v, want `%s`", err, want)
+		}
+	}
+}
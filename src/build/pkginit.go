@@ -36,7 +36,7 @@ type initedFile struct {
 // to the package. It also returns a boolean flag indicating whether or not
 // compilation should proceed.
 func (c *Compiler) initPackage(abspath string, parentModule *mods.Module) (*common.WhirlPackage, bool) {
-	pkgName := filepath.Base(abspath)
+	pkgName := syntax.NormalizeIdentifier(filepath.Base(abspath))
 
 	// check if the package name is valid
 	if !mods.IsValidPackageName(pkgName) {
@@ -44,9 +44,15 @@ func (c *Compiler) initPackage(abspath string, parentModule *mods.Module) (*comm
 		return nil, false
 	}
 
+	// unchanged packages (other than the main package) can be loaded from the
+	// build cache instead of being parsed and built again
+	if pkg, ok := c.loadCachedPackage(abspath); ok {
+		return pkg, true
+	}
+
 	// attempt to load the module if one exists or just take the parent module
-	mod, ok := mods.LoadModule(abspath)
-	if !ok {
+	mod := c.getModule(abspath)
+	if mod == nil {
 		mod = parentModule
 	}
 
@@ -69,6 +75,12 @@ func (c *Compiler) initPackage(abspath string, parentModule *mods.Module) (*comm
 		logging.LogInternalError("File", err.Error())
 	}
 
+	// packages that are shipped without their sources are loaded from their
+	// interface files
+	if ifpath, ok := interfaceFilePath(abspath, files); ok {
+		return c.loadInterfacePackage(pkg, ifpath)
+	}
+
 	// Parse each Whirlwind file in the directory.  all file level errors (eg.
 	// syntax errors) are logged with the log module for display later -- this
 	// is to ensure that every file is walked at least once.  All files
@@ -116,7 +128,7 @@ func (c *Compiler) initPackage(abspath string, parentModule *mods.Module) (*comm
 		return nil, false
 	}
 
-	c.depGraph[pkg.PackageID] = pkg
+	c.addPackage(pkg)
 	return pkg, logging.ShouldProceed()
 }
 
@@ -3,39 +3,55 @@ package build
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 
 	"whirlwind/common"
 	"whirlwind/logging"
-	"whirlwind/mods"
 )
 
 // preludeImports is a list of the imported prelude packages (after they are loaded)
 var preludeImports = make(map[string]*common.WhirlPackage)
 
-// preludeImportPatterns enumerates all of the prelude modules and the symbols they expose
-// TODO: fix this syntax to provide an actual list of the symbols we import (or
-// have the compiler walk through all of the exported symbols)
-var preludeImportPatterns = map[string][]string{
-	// TODO: figure out remaining prelude import patterns
-	"core":         {"clamp"},
-	"core/runtime": {"temp"},
-	"core/types":   {"Iterator", "Numeric", "Integral", "Floating", "Comparable", "Ord", "int", "uint", "byte"},
-}
+// preludeImportPatterns enumerates all of the prelude packages and the symbols
+// they expose.  This is loaded from the `prelude` field of the core module file
+// (`lib/std/core/whirl-mod.yml`) so that the prelude can be changed without
+// changing the compiler.
+var preludeImportPatterns map[string][]string
+
+// preludeImportNames lists the names of the prelude packages in sorted order so
+// that they are always attached in the same order
+var preludeImportNames []string
 
 // initPrelude initializes the prelude packages thereby adding them to the
 // dependency graph and making them available to all other packages being built
 func (c *Compiler) initPrelude() bool {
 	// load the core module to be shared by all core/prelude packages
-	coreMod, ok := mods.LoadModule(filepath.Join(c.whirlpath, "lib/std/core"))
+	coreMod := c.getModule(filepath.Join(c.whirlpath, "lib/std/core"))
 
-	if !ok {
+	if coreMod == nil {
 		// the core module is essential for building the core library
 		logging.LogFatal("Unable to load core module")
 	}
 
+	// the `core` package is the prelude utils package and must always be
+	// included in the prelude (`!! no_util` refers to it)
+	if _, ok := coreMod.Prelude["core"]; !ok {
+		logging.LogFatal("Core module does not declare the `core` package as part of the prelude")
+	}
+
+	preludeImportPatterns = coreMod.Prelude
+
 	// initialize each prelude package so that they are present in the
-	// dependency graph
+	// dependency graph (in a consistent order)
+	stdpkgnames := make([]string, 0, len(preludeImportPatterns))
 	for stdpkgname := range preludeImportPatterns {
+		stdpkgnames = append(stdpkgnames, stdpkgname)
+	}
+
+	sort.Strings(stdpkgnames)
+	preludeImportNames = stdpkgnames
+
+	for _, stdpkgname := range stdpkgnames {
 		preludePath := filepath.Join(c.whirlpath, "lib/std", stdpkgname)
 
 		// it is possible for one import to be initialized as a result of
@@ -58,10 +74,12 @@ func (c *Compiler) initPrelude() bool {
 		}
 	}
 
-	// initialize the `core` prelude package dependencies which implicitly
-	// initializes all the others dependencies
-	if !c.initDependencies(preludeImports["core"]) {
-		return false
+	// initialize the dependencies of each prelude package: most of these will
+	// be initialized implicitly by the `core` package
+	for _, stdpkgname := range stdpkgnames {
+		if !c.initDependencies(preludeImports[stdpkgname]) {
+			return false
+		}
 	}
 
 	return logging.ShouldProceed()
@@ -73,26 +91,34 @@ func (c *Compiler) initPrelude() bool {
 // package imports beyond this point).  All errors that occur in this function
 // are considered fatal (as they have no direct text position).
 func (c *Compiler) attachPrelude(pkg *common.WhirlPackage, file *common.WhirlFile) {
-	for stdpkgname, preludePkg := range preludeImports {
-		if pkg.PackageID != preludePkg.PackageID {
-			// `core` is the name of the prelude utils directory and so we skip
-			// this import if the specific file requests it (via. `!! no_util`)
-			if _, ok := file.MetadataTags["no_util"]; ok && stdpkgname == "core" {
-				continue
-			}
+	// files can opt out of the prelude entirely (via. `!! no_prelude`)
+	if _, ok := file.MetadataTags["no_prelude"]; ok {
+		return
+	}
 
-			// create a map of imported symbols (all of which will have `nil`
-			// positions). If the compiler needs to throw an error involving
-			// these imports specifically and it encounters a `nil` position, it
-			// should consider the error fatal.
-			importedSymbols := make(map[string]*logging.TextPosition)
-			for _, importedSymbolName := range preludeImportPatterns[stdpkgname] {
-				importedSymbols[importedSymbolName] = nil
-			}
+	for _, stdpkgname := range preludeImportNames {
+		preludePkg := preludeImports[stdpkgname]
+		if pkg.PackageID == preludePkg.PackageID {
+			continue
+		}
+
+		// `core` is the name of the prelude utils directory and so we skip
+		// this import if the specific file requests it (via. `!! no_util`)
+		if _, ok := file.MetadataTags["no_util"]; ok && stdpkgname == "core" {
+			continue
+		}
 
-			// prelude packages are never imported by name so we can leave the
-			// fields that relate to names and renames blank
-			c.attachPackageToFile(pkg, file, preludePkg, importedSymbols, "", nil)
+		// create a map of imported symbols (all of which will have `nil`
+		// positions). If the compiler needs to throw an error involving
+		// these imports specifically and it encounters a `nil` position, it
+		// should consider the error fatal.
+		importedSymbols := make(map[string]*logging.TextPosition)
+		for _, importedSymbolName := range preludeImportPatterns[stdpkgname] {
+			importedSymbols[importedSymbolName] = nil
 		}
+
+		// prelude packages are never imported by name so we can leave the
+		// fields that relate to names and renames blank
+		c.attachPackageToFile(pkg, file, preludePkg, importedSymbols, "", nil, nil)
 	}
 }
@@ -0,0 +1,59 @@
+package build
+
+import (
+	"path/filepath"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+func TestAttachPrelude(t *testing.T) {
+	c := newTestCompiler(t, map[string]string{
+		"whirl-mod.yml": "name: proj\n",
+		"full.wrl":      "func f() -> 1\n",
+		"no_util.wrl":   "!! no_util\nfunc g() -> 1\n",
+		"none.wrl":      "!! no_prelude\nfunc h() -> 1\n",
+	})
+
+	mainPkg, ok := c.LoadDependencyGraph()
+	if !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to load the dependency graph")
+	}
+
+	tests := []struct {
+		file            string
+		hasUtil, hasInt bool
+	}{
+		{"full.wrl", true, true},
+		{"no_util.wrl", false, true},
+		{"none.wrl", false, false},
+	}
+
+	for _, test := range tests {
+		wfile := mainPkg.Files[filepath.Join(mainPkg.RootDirectory, test.file)]
+
+		// `clamp` comes from `core` and `int` comes from `core::types`
+		if _, ok := wfile.LocalTable["clamp"]; ok != test.hasUtil {
+			t.Errorf("`%s`: got `clamp` imported = %v, want %v", test.file, ok, test.hasUtil)
+		}
+
+		if _, ok := wfile.LocalTable["int"]; ok != test.hasInt {
+			t.Errorf("`%s`: got `int` imported = %v, want %v", test.file, ok, test.hasInt)
+		}
+	}
+}
+
+func TestAnalyzeWithoutPrelude(t *testing.T) {
+	// resolving `A` requires standard resolution after which the walkers used
+	// to require the prelude's `int` and `uint`
+	c := newTestCompiler(t, map[string]string{
+		"whirl-mod.yml": "name: proj\n",
+		"main.wrl":      "!! no_prelude\n\ntype A {\n    b: B\n}\n\ntype B {\n    x: bool\n}\n\nfunc f() -> 1\n",
+	})
+
+	if _, ok := c.Analyze(); !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to analyze a package without the prelude")
+	}
+}
@@ -70,7 +70,13 @@ func (c *Compiler) readMetadata(sc *syntax.Scanner) (map[string]string, bool) {
 					// nocompile automatically means we stop compilation and
 					// flags don't matter since the file won't be compiled
 					return nil, false
-				case "arch", "os", "no_util", "unsafe", "no_warn", "warn":
+				case "no_util", "no_prelude", "unsafe", "no_warn":
+					// flag tags take no value so we can just add them to the
+					// tags map and move on to the next tag
+					tags[next.Value] = ""
+					expecting = syntax.COMMA
+					continue
+				case "arch", "os", "warn":
 					currentMetaTag = next.Value
 				default:
 					logging.LogCompileError(
@@ -0,0 +1,99 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/syntax"
+	"whirlwind/typing"
+)
+
+// SetTypeTrace sets the function whose type contexts should be traced during
+// validation and the format the traces should be written in (`text` or
+// `json`).  The function is given either by name (in which case it must be in
+// the main package) or by a name qualified by its package name (eg.
+// `io::println`).
+func (c *Compiler) SetTypeTrace(target, format string) error {
+	switch format {
+	case "text", "json":
+		c.traceFormat = format
+	default:
+		return errors.New("Invalid type trace format")
+	}
+
+	qualified := false
+	if sepNdx := strings.LastIndex(target, "::"); sepNdx > -1 {
+		c.tracePackage, c.traceFunc = target[:sepNdx], target[sepNdx+2:]
+		qualified = true
+	} else {
+		c.traceFunc = target
+	}
+
+	if c.traceFunc == "" || qualified && c.tracePackage == "" {
+		return errors.New("Invalid type trace target")
+	}
+
+	return nil
+}
+
+// setupTypeTrace tells the validators of the packages containing the traced
+// function to trace its types.  This should be called once all validators
+// have been created and all definitions have been resolved.  It logs an error
+// and returns false if there is no such function or if its types can't be
+// traced.
+func (c *Compiler) setupTypeTrace(mainPkg *common.WhirlPackage) bool {
+	target := c.traceFunc
+	if c.tracePackage != "" {
+		target = c.tracePackage + "::" + c.traceFunc
+	}
+
+	found := false
+	for _, pkg := range c.Packages() {
+		if c.tracePackage == "" && pkg != mainPkg || c.tracePackage != "" && pkg.Name != c.tracePackage {
+			continue
+		}
+
+		sym, ok := pkg.GlobalTable[c.traceFunc]
+		if !ok || sym.DefKind != common.DefKindFuncDef {
+			continue
+		}
+
+		// block bodies are not walked yet so none of their type contexts would
+		// be traced
+		if fdef, ok := sym.DefNode.(*common.HIRFuncDef); ok {
+			if inc, ok := fdef.Body.(*common.HIRIncomplete); ok && (*syntax.ASTBranch)(inc).Name == "do_block" {
+				logging.LogInternalError("Type Trace", fmt.Sprintf("Unable to trace the types of `%s`: functions with block bodies can't be traced", target))
+				return false
+			}
+		}
+
+		if v, ok := c.validators[pkg.PackageID]; ok {
+			v.TraceTypes(c.traceFunc)
+			found = true
+		}
+	}
+
+	if !found {
+		logging.LogInternalError("Type Trace", fmt.Sprintf("Unable to find function `%s`", target))
+	}
+
+	return found
+}
+
+// WriteTypeTraces writes all of the solver traces recorded during validation
+// in the type trace format.  The traces are ordered by package and then by
+// file so that they are always written in the same order.
+func (c *Compiler) WriteTypeTraces(w io.Writer) error {
+	var traces []*typing.SolverTrace
+	for _, pkg := range c.Packages() {
+		if v, ok := c.validators[pkg.PackageID]; ok {
+			traces = append(traces, v.Traces()...)
+		}
+	}
+
+	return typing.WriteSolverTraces(w, traces, c.traceFormat)
+}
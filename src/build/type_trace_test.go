@@ -0,0 +1,83 @@
+package build
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+// traceProject is a project with functions with expression and block bodies
+var traceProject = map[string]string{
+	"whirl-mod.yml": "name: proj\n",
+	"main.wrl":      "!! no_prelude\nimport proj::util\n\nfunc main() -> 0\n\nfunc run() do\n    return\n",
+	"util/util.wrl": "!! no_prelude\n\nexport of\n    func f(x: bool) bool -> x\n",
+}
+
+func TestSetTypeTrace(t *testing.T) {
+	for _, c := range []struct {
+		target, format string
+		ok             bool
+	}{
+		{"main", "text", true},
+		{"util::f", "json", true},
+		{"main", "yaml", false},
+		{"", "text", false},
+		{"::f", "text", false},
+		{"util::", "text", false},
+	} {
+		comp := &Compiler{}
+		if err := comp.SetTypeTrace(c.target, c.format); (err == nil) != c.ok {
+			t.Errorf("SetTypeTrace(%q, %q) = %v, want success: %v", c.target, c.format, err, c.ok)
+		}
+	}
+}
+
+func TestTypeTraceTargets(t *testing.T) {
+	dir := writeTestProject(t, traceProject)
+
+	for _, c := range []struct {
+		target string
+		want   string
+	}{
+		{"main", ""},
+		{"util::f", ""},
+		{"nosuchfunc", "Unable to find function `nosuchfunc`"},
+		{"util::nosuchfunc", "Unable to find function `util::nosuchfunc`"},
+		{"nosuchpkg::f", "Unable to find function `nosuchpkg::f`"},
+		// `f` is not in the main package
+		{"f", "Unable to find function `f`"},
+		{"run", "functions with block bodies can't be traced"},
+	} {
+		t.Run(c.target, func(t *testing.T) {
+			var ok bool
+			output := captureStdout(t, func() {
+				comp := newCompilerIn(t, dir)
+				if err := comp.SetTypeTrace(c.target, "json"); err != nil {
+					t.Fatal(err)
+				}
+
+				_, ok = comp.Analyze()
+				logging.LogStageEnd()
+
+				if ok {
+					buff := &bytes.Buffer{}
+					if err := comp.WriteTypeTraces(buff); err != nil {
+						t.Error(err)
+					}
+				}
+			})
+
+			if c.want == "" {
+				if !ok {
+					t.Errorf("tracing failed:\n%s", output)
+				}
+			} else if ok {
+				t.Error("tracing succeeded")
+			} else if !strings.Contains(output, c.want) {
+				t.Errorf("missing `%s` in:\n%s", c.want, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"whirlwind/build"
+	"whirlwind/logging"
+)
+
+// Check executes a `check` command: it analyzes the package at the given path
+// and all of its dependencies and reports any errors without generating any
+// output.  It can also trace the type solving of a function (`wp` = whirl
+// path).
+func Check(wp string) error {
+	checkCommand := flag.NewFlagSet("check", flag.ContinueOnError)
+	checkCommand.String("l", "", "Specify additional package directories")
+	checkCommand.String("loglevel", "warning", "Set compiler log level")
+	checkCommand.String("color", "auto", "Set when errors are displayed using colors { auto | always | never }")
+	checkCommand.String("trace-types", "", "Trace the type solving of a function ( <func> | <package>::<func> )")
+	checkCommand.String("trace-format", "text", "Set the type trace format { text | json }")
+	checkCommand.String("trace-out", "", "Set the type trace output path (default: standard out)")
+	checkCommand.String("print-layout", "", "Print the memory layout of a type ( <type> | <package>::<type> )")
+	checkCommand.String("os", runtime.GOOS, "Set the target operating system")
+	checkCommand.String("a", runtime.GOARCH, "Set the target architecture (used for layouts)")
+
+	checkCommand.Int("j", runtime.NumCPU(), "Set the number of packages that can be validated concurrently")
+
+	checkCommand.Bool("print-vtables", false, "Print the method tables of the types that implement interfaces")
+	checkCommand.Bool("utf16-columns", false, "Report error columns as 1-based UTF-16 offsets (for editors)")
+
+	if err := checkCommand.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	if checkCommand.NArg() != 1 {
+		return errors.New("The `check` command takes exactly one argument: the path to the package")
+	}
+
+	pkgDir, err := filepath.Abs(checkCommand.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	compiler, err := build.NewCompiler(checkCommand.Lookup("os").Value.String(),
+		checkCommand.Lookup("a").Value.String(),
+		"", pkgDir, false, wp,
+	)
+	if err != nil {
+		return err
+	}
+
+	localDirs := checkCommand.Lookup("l").Value.String()
+	if localDirs != "" {
+		if err := compiler.AddLocalPackageDirectories(localDirs); err != nil {
+			return err
+		}
+	}
+
+	jobs := checkCommand.Lookup("j").Value.(flag.Getter).Get().(int)
+	if err := compiler.SetJobs(jobs); err != nil {
+		return err
+	}
+
+	traceTarget := checkCommand.Lookup("trace-types").Value.String()
+	if traceTarget != "" {
+		if err := compiler.SetTypeTrace(traceTarget, checkCommand.Lookup("trace-format").Value.String()); err != nil {
+			return err
+		}
+	}
+
+	logging.Initialize(pkgDir, checkCommand.Lookup("loglevel").Value.String())
+	logging.SetUTF16Columns(checkCommand.Lookup("utf16-columns").Value.String() == "true")
+	if err := logging.SetColorMode(checkCommand.Lookup("color").Value.String()); err != nil {
+		return err
+	}
+
+	mainPkg, ok := compiler.Analyze()
+
+	// the traces are written even if checking fails since they are mostly
+	// used to figure out why it failed
+	if traceTarget != "" {
+		if err := writeTypeTraces(compiler, checkCommand.Lookup("trace-out").Value.String()); err != nil {
+			return err
+		}
+	}
+
+	logging.LogFinished()
+
+	if !ok {
+		return errors.New("Checking failed")
+	}
+
+	// layouts and method tables can only be computed for packages that check
+	// successfully
+	if layoutType := checkCommand.Lookup("print-layout").Value.String(); layoutType != "" {
+		if err := compiler.WriteLayout(os.Stdout, mainPkg, layoutType); err != nil {
+			return err
+		}
+	}
+
+	if checkCommand.Lookup("print-vtables").Value.String() == "true" {
+		return compiler.WriteVTables(os.Stdout)
+	}
+
+	return nil
+}
+
+// writeTypeTraces writes the type traces recorded by the compiler to the given
+// output path (or standard out if no path is given)
+func writeTypeTraces(compiler *build.Compiler, outPath string) error {
+	var w io.Writer = os.Stdout
+
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+		w = f
+	}
+
+	return compiler.WriteTypeTraces(w)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"whirlwind/syntax"
+)
+
+// Dev executes a `dev` command: these commands are tools used for developing
+// the compiler itself (`wp` = whirl path)
+func Dev(wp string) error {
+	if len(os.Args) < 3 {
+		fmt.Println("Missing subcommand")
+		printDevHelpMessage()
+		return nil
+	}
+
+	switch os.Args[2] {
+	case "grammar-report":
+		return devGrammarReport(wp)
+	case "highlight":
+		return devHighlight(wp)
+	default:
+		fmt.Printf("Unknown subcommand `%s`\n", os.Args[2])
+		printDevHelpMessage()
+	}
+
+	return nil
+}
+
+// devGrammarReport executes the `dev grammar-report` subcommand: it builds the
+// parsing table for the grammar and reports all of its conflicts
+func devGrammarReport(wp string) error {
+	reportCommand := flag.NewFlagSet("dev grammar-report", flag.ContinueOnError)
+	reportCommand.String("grammar", filepath.Join(wp, "config/grammar.ebnf"), "Set the path to the grammar")
+	reportCommand.String("o", "", "Set the output file path (default: standard output)")
+
+	if err := reportCommand.Parse(os.Args[3:]); err != nil {
+		return err
+	}
+
+	if reportCommand.NArg() != 0 {
+		return errors.New("The `dev grammar-report` command takes no arguments")
+	}
+
+	report, err := syntax.NewGrammarReport(reportCommand.Lookup("grammar").Value.String())
+	if err != nil {
+		return err
+	}
+
+	w, err := devOutput(reportCommand.Lookup("o").Value.String())
+	if err != nil {
+		return err
+	}
+
+	defer w.Close()
+
+	report.Write(w)
+	return nil
+}
+
+// devHighlight executes the `dev highlight` subcommand: it generates a grammar
+// for editor highlighting from the grammar and the token tables
+func devHighlight(wp string) error {
+	highlightCommand := flag.NewFlagSet("dev highlight", flag.ContinueOnError)
+	highlightCommand.String("grammar", filepath.Join(wp, "config/grammar.ebnf"), "Set the path to the grammar")
+	highlightCommand.String("format", "textmate", "Set the output format { textmate | tree-sitter }")
+	highlightCommand.String("o", "", "Set the output file path (default: standard output)")
+
+	if err := highlightCommand.Parse(os.Args[3:]); err != nil {
+		return err
+	}
+
+	if highlightCommand.NArg() != 0 {
+		return errors.New("The `dev highlight` command takes no arguments")
+	}
+
+	grammarPath := highlightCommand.Lookup("grammar").Value.String()
+
+	var output []byte
+	switch highlightCommand.Lookup("format").Value.String() {
+	case "textmate":
+		tmg, err := syntax.GenerateTextMateGrammar(grammarPath)
+		if err != nil {
+			return err
+		}
+
+		output = append(tmg, '\n')
+	case "tree-sitter":
+		tsg, err := syntax.GenerateTreeSitterGrammar(grammarPath)
+		if err != nil {
+			return err
+		}
+
+		output = []byte(tsg)
+	default:
+		return errors.New("Invalid highlighting grammar format")
+	}
+
+	w, err := devOutput(highlightCommand.Lookup("o").Value.String())
+	if err != nil {
+		return err
+	}
+
+	defer w.Close()
+
+	_, err = w.Write(output)
+	return err
+}
+
+// devOutput opens the output file of a `dev` command.  If no output path is
+// given, the output is written to standard output.
+func devOutput(outputPath string) (io.WriteCloser, error) {
+	if outputPath == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	return os.Create(outputPath)
+}
+
+// nopCloser wraps a writer that should not be closed by the `dev` commands
+// (ie. standard output)
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"whirlwind/build"
+	"whirlwind/logging"
+)
+
+// Doc executes a `doc` command: it analyzes the package at the given path and
+// renders the exported API of every package in its module (`wp` = whirl path)
+func Doc(wp string) error {
+	docCommand := flag.NewFlagSet("doc", flag.ContinueOnError)
+	docCommand.String("format", "html", "Set the documentation format { html | md }")
+	docCommand.String("o", "docs", "Set the output directory")
+	docCommand.String("loglevel", "error", "Set compiler log level")
+	docCommand.String("color", "auto", "Set when errors are displayed using colors { auto | always | never }")
+	docCommand.Bool("deps", false, "Also document the packages the module depends on")
+
+	if err := docCommand.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	if docCommand.NArg() != 1 {
+		return errors.New("The `doc` command takes exactly one argument: the path to the package")
+	}
+
+	format := docCommand.Lookup("format").Value.String()
+	if format != "html" && format != "md" {
+		return errors.New("Invalid documentation format")
+	}
+
+	pkgDir, err := filepath.Abs(docCommand.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	compiler, err := build.NewCompiler(runtime.GOOS, runtime.GOARCH, "", pkgDir, false, wp)
+	if err != nil {
+		return err
+	}
+
+	logging.Initialize(pkgDir, docCommand.Lookup("loglevel").Value.String())
+	if err := logging.SetColorMode(docCommand.Lookup("color").Value.String()); err != nil {
+		return err
+	}
+
+	mainPkg, ok := compiler.Analyze()
+	logging.LogFinished()
+
+	if !ok {
+		return errors.New("Unable to document a package that fails to compile")
+	}
+
+	outDir := docCommand.Lookup("o").Value.String()
+	includeDeps := docCommand.Lookup("deps").Value.String() == "true"
+	if err := compiler.WriteDocs(mainPkg, format, outDir, includeDeps); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote documentation to `%s`\n", outDir)
+	return nil
+}
@@ -11,7 +11,6 @@ import (
 
 	"whirlwind/build"
 	"whirlwind/logging"
-	"whirlwind/mods"
 )
 
 // Execute should be called from main and initializes the compiler
@@ -40,8 +39,16 @@ func Execute() {
 	switch os.Args[1] {
 	case "build":
 		err = Build(whirlPath)
+	case "check":
+		err = Check(whirlPath)
+	case "clean":
+		err = Clean()
+	case "dev":
+		err = Dev(whirlPath)
+	case "doc":
+		err = Doc(whirlPath)
 	case "mod":
-		err = Mod()
+		err = Mod(whirlPath)
 	case "version":
 		fmt.Println("whirl v.0.1 - language version W.0.9")
 	default:
@@ -56,6 +63,26 @@ func Execute() {
 	}
 }
 
+// Clean executes a `clean` command: it purges the build cache of the build
+// directory given as its argument (or the current directory if none is given)
+func Clean() error {
+	if len(os.Args) > 3 {
+		return errors.New("The `clean` command takes at most one argument: the build directory")
+	}
+
+	buildDir := "."
+	if len(os.Args) == 3 {
+		buildDir = os.Args[2]
+	}
+
+	absBuildDir, err := filepath.Abs(buildDir)
+	if err != nil {
+		return err
+	}
+
+	return build.CleanCache(absBuildDir)
+}
+
 // Build executes a `build` command. (`wp` = whirl path)
 func Build(wp string) error {
 	// setup the build command and its flags
@@ -69,8 +96,14 @@ func Build(wp string) error {
 	buildCommand.String("l", "", "Specify additional package directories")
 	buildCommand.String("loglevel", "verbose", "Set compiler log level")
 	buildCommand.String("dl", "", "List any dynamic libraries that need to be linked with the binary") // subject to change
+	buildCommand.String("emit-graph", "", "Emit the package dependency graph { dot | json }")
+	buildCommand.String("graph-out", "", "Set the dependency graph output path (default: deps.<format>)")
+	buildCommand.String("color", "auto", "Set when errors are displayed using colors { auto | always | never }")
+
+	buildCommand.Int("j", runtime.NumCPU(), "Set the number of packages that can be validated concurrently")
 
 	buildCommand.Bool("d", false, "Compile target in debug mode")
+	buildCommand.Bool("utf16-columns", false, "Report error columns as 1-based UTF-16 offsets (for editors)")
 	buildCommand.Bool("forcegrebuild", false, "DEV OPTION: Force the compiler to rebuild grammar")
 
 	// parse and check the command line arguments from the build command
@@ -138,45 +171,30 @@ func Build(wp string) error {
 		}
 	}
 
-	// setup the global Logger (based on log level)
-	logging.Initialize(buildDir, buildCommand.Lookup("loglevel").Value.String())
-
-	// run the main compilation algorithm
-	compiler.Compile(buildCommand.Lookup("forcegrebuild").Value.String() == "true")
-
-	// the compiler will handle its own errors
-	return nil
-}
-
-// Mod executes a `mod` command
-func Mod() error {
-	if len(os.Args) < 3 {
-		fmt.Println("Missing subcommand")
-		printModHelpMessage()
-		return nil
+	jobs := buildCommand.Lookup("j").Value.(flag.Getter).Get().(int)
+	if cerr := compiler.SetJobs(jobs); cerr != nil {
+		return cerr
 	}
 
-	switch os.Args[2] {
-	case "del":
-		if len(os.Args) != 2 {
-			return errors.New("Too many arguments for the `mod del` command")
-		}
+	graphFormat := buildCommand.Lookup("emit-graph").Value.String()
+	if graphFormat != "" {
+		cerr := compiler.SetDependencyGraphOutput(graphFormat, buildCommand.Lookup("graph-out").Value.String())
 
-		return mods.Del()
-	case "new":
-		if len(os.Args) != 4 {
-			return errors.New("The `mod new` command takes exactly one argument: the name of the new module")
-		}
-
-		return mods.CreateModule(os.Args[3])
-	case "init":
-		if len(os.Args) != 4 {
-			return errors.New("The `mod init` command takes exactly one argument: the name of the new module")
+		if cerr != nil {
+			return cerr
 		}
+	}
 
-		return mods.InitModule(os.Args[3], ".")
-	case "rename":
+	// setup the global Logger (based on log level)
+	logging.Initialize(buildDir, buildCommand.Lookup("loglevel").Value.String())
+	logging.SetUTF16Columns(buildCommand.Lookup("utf16-columns").Value.String() == "true")
+	if err := logging.SetColorMode(buildCommand.Lookup("color").Value.String()); err != nil {
+		return err
 	}
 
+	// run the main compilation algorithm
+	compiler.Compile(buildCommand.Lookup("forcegrebuild").Value.String() == "true")
+
+	// the compiler will handle its own errors
 	return nil
 }
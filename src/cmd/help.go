@@ -15,6 +15,8 @@ The commands are:
 	check      check packages and output errors
 	clean      remove object files and cached data
 	del        delete installed modules
+	dev        tools for developing the compiler
+	doc        generate documentation for packages and modules
 	fetch      fetch and install a remote module
 	make       compile intermediates (asm, object, etc.)
 	mod        manage modules
@@ -26,7 +28,7 @@ The commands are:
 
 // printHelpMessage prints the general purpose help message
 func printHelpMessage() {
-	fmt.Println(helpMessage)
+	fmt.Print(helpMessage)
 }
 
 const modHelpMessage = `
@@ -36,14 +38,35 @@ Usage:
 
 The subcommands are:
 
+	add       add the module at the given path as a dependency of the current module
 	del       delete the current module (not source files)
+	graph     print the package dependency graph of the current module
 	init      initialize a new module in the current directory
 	new       create a new directory with a module of the same name initialized in it
-	rename    renames the current module
+	remove    remove a dependency from the current module
+	rename    renames the current module (and updates its imports)
+	why       show the import chain that makes the current module depend on a package
 `
 
 // printModHelpMessage prints the help message for the `mod` command when it is
 // missing or has an invalid a subcommand
 func printModHelpMessage() {
-	fmt.Println(modHelpMessage)
+	fmt.Print(modHelpMessage)
+}
+
+const devHelpMessage = `
+Usage:
+
+	whirl dev <subcommand> [arguments]
+
+The subcommands are:
+
+	grammar-report    list the conflicts in the parsing table and the productions involved
+	highlight         generate an editor highlighting grammar (TextMate or tree-sitter)
+`
+
+// printDevHelpMessage prints the help message for the `dev` command when it is
+// missing or has an invalid a subcommand
+func printDevHelpMessage() {
+	fmt.Print(devHelpMessage)
 }
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"whirlwind/build"
+	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/mods"
+)
+
+// Mod executes a `mod` command (`wp` = whirl path)
+func Mod(wp string) error {
+	if len(os.Args) < 3 {
+		fmt.Println("Missing subcommand")
+		printModHelpMessage()
+		return nil
+	}
+
+	switch os.Args[2] {
+	case "del":
+		if len(os.Args) != 3 {
+			return errors.New("Too many arguments for the `mod del` command")
+		}
+
+		return mods.Del()
+	case "new":
+		if len(os.Args) != 4 {
+			return errors.New("The `mod new` command takes exactly one argument: the name of the new module")
+		}
+
+		return mods.CreateModule(os.Args[3])
+	case "init":
+		if len(os.Args) != 4 {
+			return errors.New("The `mod init` command takes exactly one argument: the name of the new module")
+		}
+
+		return mods.InitModule(os.Args[3], ".")
+	case "rename":
+		if len(os.Args) != 4 {
+			return errors.New("The `mod rename` command takes exactly one argument: the new name of the module")
+		}
+
+		changedCount, err := mods.Rename(".", os.Args[3])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Renamed module to `%s` (updated imports in %d files)\n", os.Args[3], changedCount)
+	case "add":
+		if len(os.Args) != 4 {
+			return errors.New("The `mod add` command takes exactly one argument: the path to the module to add")
+		}
+
+		depName, err := mods.AddDependency(".", os.Args[3])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Added dependency `%s`\n", depName)
+	case "remove":
+		if len(os.Args) != 4 {
+			return errors.New("The `mod remove` command takes exactly one argument: the name of the dependency to remove")
+		}
+
+		if err := mods.RemoveDependency(".", os.Args[3]); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed dependency `%s`\n", os.Args[3])
+	case "graph":
+		return modGraph(wp)
+	case "why":
+		if len(os.Args) != 4 {
+			return errors.New("The `mod why` command takes exactly one argument: the package to explain")
+		}
+
+		return modWhy(wp, os.Args[3])
+	default:
+		fmt.Printf("Unknown subcommand `%s`\n", os.Args[2])
+		printModHelpMessage()
+	}
+
+	return nil
+}
+
+// modGraph executes the `mod graph` subcommand: it prints every package in the
+// dependency graph of the current module along with the packages it imports
+func modGraph(wp string) error {
+	graphCommand := flag.NewFlagSet("mod graph", flag.ContinueOnError)
+	showPrelude := graphCommand.Bool("prelude", false, "Include the prelude packages in the graph")
+
+	if err := graphCommand.Parse(os.Args[3:]); err != nil {
+		return err
+	}
+
+	if graphCommand.NArg() != 0 {
+		return errors.New("The `mod graph` command takes no arguments")
+	}
+
+	compiler, mainPkg, err := loadModuleGraph(wp)
+	if err != nil {
+		return err
+	}
+
+	// the prelude is imported by every package so we only show the packages
+	// that are reachable without going through the prelude unless requested
+	visible := map[uint]struct{}{mainPkg.PackageID: {}}
+	for queue := []*common.WhirlPackage{mainPkg}; len(queue) > 0; queue = queue[1:] {
+		for _, wimport := range build.Imports(queue[0]) {
+			if _, ok := visible[wimport.PackageRef.PackageID]; !ok && (*showPrelude || !wimport.PackageRef.PreludeImport) {
+				visible[wimport.PackageRef.PackageID] = struct{}{}
+				queue = append(queue, wimport.PackageRef)
+			}
+		}
+	}
+
+	// the main package is always displayed first
+	pkgs := []*common.WhirlPackage{mainPkg}
+	for _, pkg := range compiler.Packages() {
+		if _, ok := visible[pkg.PackageID]; ok && pkg != mainPkg {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	for _, pkg := range pkgs {
+		fmt.Printf("%s (%s)\n", pkg.Name, displayPackagePath(wp, pkg))
+
+		for _, wimport := range build.Imports(pkg) {
+			if _, ok := visible[wimport.PackageRef.PackageID]; !ok {
+				continue
+			}
+
+			if len(wimport.ImportedSymbols) > 0 {
+				fmt.Printf("    -> %s: %s\n", wimport.PackageRef.Name, strings.Join(importedSymbolNames(wimport), ", "))
+			} else {
+				fmt.Printf("    -> %s\n", wimport.PackageRef.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// modWhy executes the `mod why` subcommand: it prints the shortest import chain
+// from the current module to the given package (for every matching package).
+// The package can either be given by name (eg. `http`) or by its import path
+// (eg. `net::http`).
+func modWhy(wp, pkgPath string) error {
+	_, mainPkg, err := loadModuleGraph(wp)
+	if err != nil {
+		return err
+	}
+
+	pkgPath = strings.ReplaceAll(pkgPath, "::", "/")
+	chains := build.ImportChains(mainPkg, func(pkg *common.WhirlPackage) bool {
+		if strings.Contains(pkgPath, "/") {
+			return strings.HasSuffix(filepath.ToSlash(pkg.RootDirectory), "/"+pkgPath)
+		}
+
+		return pkg.Name == pkgPath
+	})
+
+	if len(chains) == 0 {
+		return fmt.Errorf("Module does not depend on a package matching `%s`", pkgPath)
+	}
+
+	for i, chain := range chains {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("# %s (%s)\n", chain[len(chain)-1].Name, displayPackagePath(wp, chain[len(chain)-1]))
+		for j, pkg := range chain {
+			if j > 0 && pkg.PreludeImport {
+				fmt.Printf("%s%s (prelude)\n", strings.Repeat("  ", j), pkg.Name)
+			} else {
+				fmt.Printf("%s%s\n", strings.Repeat("  ", j), pkg.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadModuleGraph loads the dependency graph of the module in the current
+// directory.  It returns the compiler used to load it and the main package.
+func loadModuleGraph(wp string) (*build.Compiler, *common.WhirlPackage, error) {
+	buildDir, err := filepath.Abs(".")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compiler, err := build.NewCompiler(runtime.GOOS, runtime.GOARCH, "", buildDir, false, wp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// we only want to display errors here: no compilation info
+	logging.Initialize(buildDir, "error")
+
+	mainPkg, ok := compiler.LoadDependencyGraph()
+	if !ok {
+		return nil, nil, errors.New("Unable to load the dependency graph of the current module")
+	}
+
+	return compiler, mainPkg, nil
+}
+
+// displayPackagePath returns the path of a package as it should be displayed
+// to the user: relative to the current directory or to the whirl path if
+// possible
+func displayPackagePath(wp string, pkg *common.WhirlPackage) string {
+	if wd, err := os.Getwd(); err == nil {
+		if relPath, err := filepath.Rel(wd, pkg.RootDirectory); err == nil && !strings.HasPrefix(relPath, "..") {
+			return filepath.ToSlash(relPath)
+		}
+	}
+
+	if relPath, err := filepath.Rel(wp, pkg.RootDirectory); err == nil && !strings.HasPrefix(relPath, "..") {
+		return "$WHIRL_PATH/" + filepath.ToSlash(relPath)
+	}
+
+	return pkg.RootDirectory
+}
+
+// importedSymbolNames returns the sorted names of the symbols imported by an
+// import
+func importedSymbolNames(wimport *common.WhirlImport) []string {
+	names := make([]string, 0, len(wimport.ImportedSymbols))
+	for name := range wimport.ImportedSymbols {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
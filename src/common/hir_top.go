@@ -1,6 +1,7 @@
 package common
 
 import (
+	"whirlwind/logging"
 	"whirlwind/typing"
 )
 
@@ -50,6 +51,9 @@ type HIRTypeDef struct {
 	// FieldInits is a map of all field initializers along with what fields they
 	// correspond to (used for type structs)
 	FieldInits map[string]HIRNode
+
+	// DocComment is the doc comment written before the definition (if any)
+	DocComment string
 }
 
 func (*HIRTypeDef) Kind() int {
@@ -64,6 +68,9 @@ type HIRInterfDef struct {
 	// Type is the actual, internal type of the interface, not any enclosing
 	// generics -- this is more useful later on
 	Type *typing.InterfType
+
+	// DocComment is the doc comment written before the definition (if any)
+	DocComment string
 }
 
 func (*HIRInterfDef) Kind() int {
@@ -85,6 +92,13 @@ type HIRFuncDef struct {
 	// Initializers contains all of the special modifiers to the arguments
 	// of the function (ie. volatility, initializers)
 	Initializers map[string]HIRNode
+
+	// DocComment is the doc comment written before the definition (if any)
+	DocComment string
+
+	// ReturnTypePosition is the position of the return type label of the
+	// function.  It is `nil` if the function has no explicit return type.
+	ReturnTypePosition *logging.TextPosition
 }
 
 func (*HIRFuncDef) Kind() int {
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"whirlwind/logging"
 	"whirlwind/typing"
 )
 
@@ -26,6 +27,17 @@ type Symbol struct {
 	// will be `null` for most symbols: it is only populated by type definitions
 	// and functions (declared globally)
 	DefNode HIRNode
+
+	// DocComment is the doc comment of the definition that produced this
+	// symbol.  Like `DefNode`, it is only populated for global definitions.
+	DocComment string
+
+	// DefPosition and DefFilePath are the position of the name of the
+	// definition that produced this symbol and the file it is in.  They are
+	// only populated for global definitions in the current package and are
+	// used to point to the original definition in errors.
+	DefPosition *logging.TextPosition
+	DefFilePath string
 }
 
 // VisibleExternally determines if remote packages can access this symbol
@@ -1,9 +1,39 @@
 package common
 
 import (
+	"sort"
+
 	"whirlwind/typing"
 )
 
+// SortedFilePaths returns the paths of all the files in a package in sorted
+// order.  This should be used whenever the order in which the files are
+// processed could be observed (eg. in the order of errors).
+func (pkg *WhirlPackage) SortedFilePaths() []string {
+	fpaths := make([]string, 0, len(pkg.Files))
+	for fpath := range pkg.Files {
+		fpaths = append(fpaths, fpath)
+	}
+
+	sort.Strings(fpaths)
+	return fpaths
+}
+
+// SortedImportIDs returns the IDs of the packages imported by a package sorted
+// by the root directories of those packages
+func (pkg *WhirlPackage) SortedImportIDs() []uint {
+	ids := make([]uint, 0, len(pkg.ImportTable))
+	for id := range pkg.ImportTable {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return pkg.ImportTable[ids[i]].PackageRef.RootDirectory < pkg.ImportTable[ids[j]].PackageRef.RootDirectory
+	})
+
+	return ids
+}
+
 // ImportFromNamespace attempts to import a symbol by name from the exported
 // namespace of another package.
 func (pkg *WhirlPackage) ImportFromNamespace(name string) (*Symbol, bool) {
@@ -11,8 +41,8 @@ func (pkg *WhirlPackage) ImportFromNamespace(name string) (*Symbol, bool) {
 		return sym, true
 	}
 
-	for _, wfile := range pkg.Files {
-		if wsi, ok := wfile.LocalTable[name]; ok && wsi.SymbolRef.VisibleExternally() {
+	for _, fpath := range pkg.SortedFilePaths() {
+		if wsi, ok := pkg.Files[fpath].LocalTable[name]; ok && wsi.SymbolRef.VisibleExternally() {
 			return wsi.SymbolRef, true
 		}
 	}
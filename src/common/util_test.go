@@ -0,0 +1,52 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortedFilePaths(t *testing.T) {
+	pkg := &WhirlPackage{Files: map[string]*WhirlFile{
+		"pkg/c.wrl": {},
+		"pkg/a.wrl": {},
+		"pkg/b.wrl": {},
+	}}
+
+	want := []string{"pkg/a.wrl", "pkg/b.wrl", "pkg/c.wrl"}
+	if got := pkg.SortedFilePaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSortedImportIDs(t *testing.T) {
+	// the IDs are not in the same order as the directories
+	pkg := &WhirlPackage{ImportTable: map[uint]*WhirlImport{
+		1: {PackageRef: &WhirlPackage{RootDirectory: "proj/c"}},
+		2: {PackageRef: &WhirlPackage{RootDirectory: "proj/a"}},
+		3: {PackageRef: &WhirlPackage{RootDirectory: "proj/b"}},
+	}}
+
+	want := []uint{2, 3, 1}
+	if got := pkg.SortedImportIDs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestImportFromNamespaceOrder(t *testing.T) {
+	// both files define `x`: the symbol of the first file by path is imported
+	a := &Symbol{Name: "x", DeclStatus: DSExported}
+	b := &Symbol{Name: "x", DeclStatus: DSExported}
+	pkg := &WhirlPackage{
+		GlobalTable: map[string]*Symbol{},
+		Files: map[string]*WhirlFile{
+			"pkg/b.wrl": {LocalTable: map[string]*WhirlSymbolImport{"x": {SymbolRef: b}}},
+			"pkg/a.wrl": {LocalTable: map[string]*WhirlSymbolImport{"x": {SymbolRef: a}}},
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		if sym, ok := pkg.ImportFromNamespace("x"); !ok || sym != a {
+			t.Fatal("did not import the symbol of the first file")
+		}
+	}
+}
@@ -66,6 +66,11 @@ type WhirlPackage struct {
 	// Name is the inferred name of the package based on its directory name
 	Name string
 
+	// ImportPath is the path by which the package is imported (eg.
+	// `proj::util`).  Packages that can't be imported by path (eg. local
+	// packages) just use their name.
+	ImportPath string
+
 	// RootDirectory is the directory the package's files are stored in (package
 	// directory)
 	RootDirectory string
@@ -100,6 +105,11 @@ type WhirlPackage struct {
 	// PreludeImport indicates that this package is apart of the implicitly
 	// imported prelude; used for handling errors
 	PreludeImport bool
+
+	// Precompiled indicates that this package was loaded from the build cache
+	// or from an interface file: it has no files and only its exported
+	// interface is available
+	Precompiled bool
 }
 
 // WhirlOperatorDefinition represents an operator overload definition
@@ -122,4 +132,18 @@ type WhirlImport struct {
 	// meaningless and therefore can be ignored during a namespace import.  The
 	// key is the name of the symbol (which may not be given in the SymbolRef).
 	ImportedSymbols map[string]*Symbol
+
+	// Sites lists all of the import statements that created this import (one
+	// for each file that imports the package)
+	Sites []*ImportSite
+}
+
+// ImportSite represents a single import statement that imports a package
+type ImportSite struct {
+	// FilePath is the absolute path to the file containing the import
+	FilePath string
+
+	// Position is the position of the package path in the import statement.
+	// This field is `nil` for implicit imports (ie. of the prelude).
+	Position *logging.TextPosition
 }
@@ -2,4 +2,7 @@ module whirlwind
 
 go 1.16
 
-require gopkg.in/yaml.v2 v2.4.0
+require (
+	golang.org/x/text v0.3.6
+	gopkg.in/yaml.v2 v2.4.0
+)
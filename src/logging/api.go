@@ -7,7 +7,7 @@ import (
 
 // logger is a global reference to a shared Logger (created/initialized with the
 // compiler, but separated for general usage)
-var logger Logger
+var logger *Logger
 
 // Initialize initializes the global logger with the provided log level
 func Initialize(buildPath string, loglevelname string) {
@@ -24,36 +24,71 @@ func Initialize(buildPath string, loglevelname string) {
 		loglevel = LogLevelVerbose
 	}
 
-	logger = newLogger(buildPath, loglevel)
+	l := newLogger(buildPath, loglevel)
+	logger = l
 
 	// start up our logging loop (so we can print out messages as necessary)
-	go logger.logLoop()
+	go l.logLoop()
+}
+
+// SetUTF16Columns sets whether the columns of compile messages are displayed
+// as 1-based UTF-16 code unit offsets (the columns most editors use) instead of
+// 1-based character offsets.  This should be called after `Initialize`.
+func SetUTF16Columns(enabled bool) {
+	logger.utf16Columns = enabled
 }
 
 // NOTE: All log functions will only display if the appropriate log level is
 // set.  Most log functions will simply fail silently if below their appropriate
 // log level.
 
-// LogCompileError logs and a compilation error (user-induced, bad code)
-func LogCompileError(lctx *LogContext, message string, kind int, pos *TextPosition) {
-	logger.logMsgChan <- &CompileMessage{
-		Message:  message,
-		Kind:     kind,
-		Position: pos,
-		Context:  lctx,
-		IsError:  true,
-	}
+// LogCompileError logs and a compilation error (user-induced, bad code).  Any
+// annotations given are applied to the message.
+func LogCompileError(lctx *LogContext, message string, kind int, pos *TextPosition, annotations ...Annotation) {
+	logCompileMessage(&CompileMessage{
+		Message: message,
+		Kind:    kind,
+		Context: lctx,
+		IsError: true,
+	}, pos, annotations)
+}
+
+// LogCompileWarning logs a compilation warning (user-induced, problematic
+// code).  Any annotations given are applied to the message.
+func LogCompileWarning(lctx *LogContext, message string, kind int, pos *TextPosition, annotations ...Annotation) {
+	logCompileMessage(&CompileMessage{
+		Message: message,
+		Kind:    kind,
+		Context: lctx,
+		IsError: false,
+	}, pos, annotations)
 }
 
-// LogCompileWarning logs a compilation warning (user-induced, problematic code)
-func LogCompileWarning(lctx *LogContext, message string, kind int, pos *TextPosition) {
-	logger.logMsgChan <- &CompileMessage{
-		Message:  message,
-		Kind:     kind,
-		Position: pos,
-		Context:  lctx,
-		IsError:  false,
+// logCompileMessage logs a compile message or adds it to the buffer of its
+// context if it has one.  The context is copied since log contexts are often
+// updated as the compiler moves between files and the message may not be
+// displayed until the end of the current stage.  The position is used as the
+// primary span of the message before the annotations are applied.
+func logCompileMessage(cm *CompileMessage, pos *TextPosition, annotations []Annotation) {
+	if pos != nil {
+		cm.Spans = []*Span{{Position: pos}}
+	}
+
+	for _, annot := range annotations {
+		annot(cm)
+	}
+
+	if cm.Context != nil {
+		lctx := *cm.Context
+		cm.Context = &lctx
+
+		if lctx.Buffer != nil {
+			lctx.Buffer.add(cm)
+			return
+		}
 	}
+
+	logger.logMsgChan <- cm
 }
 
 // LogInternalError logs an error related to some non-compilation related
@@ -65,7 +100,15 @@ func LogInternalError(kind, message string) {
 // LogFatal logs a fatal error message (something unexpected happened with the
 // compiler -- developer error, requires bug fix).  TERMINATES PROGRAM!
 func LogFatal(message string) {
-	logger.logMsgChan <- &FatalError{Message: message}
+	// display the errors that led up to the fatal error first
+	LogStageEnd()
+
+	// the error is displayed here rather than by the log loop so that the
+	// program can't exit before it is displayed
+	if logger.LogLevel > LogLevelSilent {
+		(&FatalError{Message: message}).display()
+	}
+
 	os.Exit(-1)
 }
 
@@ -90,6 +133,18 @@ func LogStateChange(newstate string) {
 	}
 }
 
+// LogStageEnd marks the end of a stage of compilation: all of the compile
+// errors logged during the stage are displayed sorted by package, file path, and
+// position (the warnings are sorted and displayed at the end of compilation).
+// Compile messages are buffered until this is called so that their order does
+// not depend on the order in which work was done.  This should only be called
+// once all the messages of the stage have been logged.
+func LogStageEnd() {
+	flushed := make(chan struct{})
+	logger.stageEndChan <- flushed
+	<-flushed
+}
+
 // LogFinished logs the final status of compilation and displays any warnings
 // encountered.  This should be called at the end of compilation (regardless of
 // success or failure).
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// LogBuffer collects the compile messages logged in the contexts that use it
+// instead of logging them immediately.  This is used to keep the output of the
+// compiler deterministic when work is done concurrently: the messages are only
+// logged once the buffer is flushed in some deterministic order.  It is safe
+// for concurrent use.
+type LogBuffer struct {
+	mutex sync.Mutex
+
+	messages   []*CompileMessage
+	errorCount int
+}
+
+// NewLogBuffer creates a new, empty log buffer
+func NewLogBuffer() *LogBuffer {
+	return &LogBuffer{}
+}
+
+// add adds a compile message to the buffer
+func (lb *LogBuffer) add(cm *CompileMessage) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.messages = append(lb.messages, cm)
+	if cm.IsError {
+		lb.errorCount++
+	}
+}
+
+// HasErrors checks if any errors have been logged to the buffer
+func (lb *LogBuffer) HasErrors() bool {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return lb.errorCount > 0
+}
+
+// Flush logs all of the messages in the buffer in sorted order (see
+// `sortMessages`) and clears the buffer.
+func (lb *LogBuffer) Flush() {
+	for _, cm := range lb.take() {
+		logger.logMsgChan <- cm
+	}
+}
+
+// take removes all of the messages from the buffer and returns them in sorted
+// order
+func (lb *LogBuffer) take() []*CompileMessage {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	messages := lb.messages
+	sortMessages(messages)
+
+	lb.messages = nil
+	lb.errorCount = 0
+
+	return messages
+}
+
+// sortMessages sorts compile messages by package (the directory of their file),
+// by file path and then by position.  Messages without positions come before
+// those with positions in the same file, and messages at the same position
+// retain their relative order.
+func sortMessages(messages []*CompileMessage) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := messages[i], messages[j]
+
+		if adir, bdir := filepath.Dir(a.Context.FilePath), filepath.Dir(b.Context.FilePath); adir != bdir {
+			return adir < bdir
+		}
+
+		if a.Context.FilePath != b.Context.FilePath {
+			return a.Context.FilePath < b.Context.FilePath
+		}
+
+		apos, bpos := a.Position(), b.Position()
+		switch {
+		case apos == nil:
+			return bpos != nil
+		case bpos == nil:
+			return false
+		case apos.StartLn != bpos.StartLn:
+			return apos.StartLn < bpos.StartLn
+		default:
+			return apos.StartCol < bpos.StartCol
+		}
+	})
+}
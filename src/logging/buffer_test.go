@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestMessage creates a compile message in the given file at the given
+// line and column or with no position if the line is zero
+func newTestMessage(msg, fpath string, ln, col int) *CompileMessage {
+	cm := &CompileMessage{Message: msg, Context: &LogContext{FilePath: fpath}, IsError: true}
+	if ln > 0 {
+		cm.Spans = []*Span{{Position: &TextPosition{StartLn: ln, StartCol: col, EndLn: ln, EndCol: col + 1}}}
+	}
+
+	return cm
+}
+
+func TestSortMessages(t *testing.T) {
+	a := filepath.Join("proj", "a", "a.wrl")
+	a2 := filepath.Join("proj", "a", "b.wrl")
+	sub := filepath.Join("proj", "a", "sub", "a.wrl")
+	main := filepath.Join("proj", "main.wrl")
+
+	// the messages are in the order they should be sorted into
+	want := []*CompileMessage{
+		newTestMessage("main package", main, 1, 0),
+		newTestMessage("unpositioned", a, 0, 0),
+		newTestMessage("first at 1:2", a, 1, 2),
+		newTestMessage("second at 1:2", a, 1, 2),
+		newTestMessage("at 1:5", a, 1, 5),
+		newTestMessage("at 3:0", a, 3, 0),
+		newTestMessage("other file", a2, 1, 0),
+		newTestMessage("subpackage", sub, 1, 0),
+	}
+
+	// packages are sorted by directory so the files of the subpackage come
+	// after the files of `a` even though `sub/a.wrl` < `b.wrl` and the main
+	// package comes first
+	messages := []*CompileMessage{want[7], want[4], want[6], want[1], want[5], want[2], want[0], want[3]}
+	sortMessages(messages)
+
+	for i, cm := range messages {
+		if cm != want[i] {
+			t.Errorf("message %d is `%s`; want `%s`", i, cm.Message, want[i].Message)
+		}
+	}
+}
+
+func TestLogBufferTake(t *testing.T) {
+	lb := NewLogBuffer()
+	lb.add(newTestMessage("b", "b.wrl", 1, 0))
+	lb.add(&CompileMessage{Message: "warning", Context: &LogContext{FilePath: "a.wrl"}})
+	lb.add(newTestMessage("a", "a.wrl", 1, 0))
+
+	if !lb.HasErrors() {
+		t.Fatal("the buffer should have errors")
+	}
+
+	messages := lb.take()
+	if len(messages) != 3 || messages[0].Message != "warning" || messages[1].Message != "a" || messages[2].Message != "b" {
+		t.Errorf("took the wrong messages: %v", messages)
+	}
+
+	if lb.HasErrors() || len(lb.take()) != 0 {
+		t.Error("taking the messages should clear the buffer")
+	}
+}
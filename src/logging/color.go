@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+)
+
+// Enumeration of the color modes of the logger
+const (
+	ColorAuto   = iota // use colors if the output is a terminal (DEFAULT)
+	ColorAlways        // always use colors
+	ColorNever         // never use colors
+)
+
+// SetColorMode sets whether the logger displays messages using ANSI colors.
+// The mode must be one of `auto`, `always`, or `never`.  This should be called
+// after `Initialize`.
+func SetColorMode(modename string) error {
+	switch modename {
+	case "", "auto":
+		logger.useColor = stdoutIsTerminal()
+	case "always":
+		logger.useColor = true
+	case "never":
+		logger.useColor = false
+	default:
+		return fmt.Errorf("invalid color mode: `%s`", modename)
+	}
+
+	return nil
+}
+
+// stdoutIsTerminal checks if standard out is a terminal that should display
+// colors.  The `NO_COLOR` environment variable is respected.
+func stdoutIsTerminal() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	finfo, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+
+	return finfo.Mode()&os.ModeCharDevice != 0
+}
+
+// ANSI escape codes for the styles used in messages
+const (
+	styleReset  = "\x1b[0m"
+	styleBold   = "\x1b[1m"
+	styleRed    = "\x1b[1;31m"
+	styleYellow = "\x1b[1;33m"
+	styleBlue   = "\x1b[1;34m"
+	styleCyan   = "\x1b[1;36m"
+)
+
+// colorize wraps text in the given style if the logger is using colors
+func colorize(style, text string) string {
+	if !logger.useColor || text == "" {
+		return text
+	}
+
+	return style + text + styleReset
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -31,98 +32,221 @@ var errorKindStringTable = map[int]string{
 }
 
 func (cm *CompileMessage) display() {
-	displayLogMessageHeader(logger.buildPath, cm)
+	severity, style := "warning", styleYellow
+	if cm.IsError {
+		severity, style = "error", styleRed
+	}
 
-	if cm.Position == nil {
-		fmt.Println(cm.Message)
-		return
+	fmt.Println()
+	fmt.Printf("%s %s\n",
+		colorize(style, fmt.Sprintf("%s[%s]:", severity, errorKindStringTable[cm.Kind])),
+		colorize(styleBold, cm.Message),
+	)
+
+	// the gutter is wide enough for the largest line number displayed
+	gutterWidth := 0
+	for _, span := range cm.Spans {
+		if n := len(strconv.Itoa(span.Position.EndLn)); n > gutterWidth {
+			gutterWidth = n
+		}
 	}
 
-	fmt.Printf("%s at (Ln: %d, Col: %d)\n\n", cm.Message, cm.Position.StartLn, cm.Position.StartCol+1)
+	gutter := strings.Repeat(" ", gutterWidth+1)
 
-	// `f` should be guaranteed to exist since it was opened earlier (unless the
-	// user deleted the file in between running the compiler and this function
-	// being called which should cause a panic -- not a compiler error)
-	f, _ := os.Open(cm.Context.FilePath)
-	defer f.Close()
+	if len(cm.Spans) == 0 {
+		fmt.Printf("%s%s %s\n", gutter, colorize(styleBlue, "-->"), displayPath(cm.Context.FilePath))
+	} else {
+		for i, group := range cm.groupSpans() {
+			group.display(gutterWidth, style, i == 0)
+		}
+	}
 
-	sc := bufio.NewScanner(f)
+	if len(cm.Notes) > 0 || len(cm.Help) > 0 {
+		fmt.Printf("%s%s\n", gutter, colorize(styleBlue, "|"))
+	}
 
-	// we need to make sure the line is scanned in and ready to go; we can
-	// assume the line number is correct (since it was determined by our scanner
-	// before this function was called -- if it isn't, panic)
-	for i := 0; sc.Scan() && i < cm.Position.StartLn-1; i++ {
+	for _, note := range cm.Notes {
+		fmt.Printf("%s%s %s %s\n", gutter, colorize(styleBlue, "="), colorize(styleBold, "note:"), note)
 	}
 
-	displayCodeSelection(sc, cm.Position)
+	for _, help := range cm.Help {
+		fmt.Printf("%s%s %s %s\n", gutter, colorize(styleBlue, "="), colorize(styleCyan, "help:"), help)
+	}
 }
 
-// displayCompileMessageHeader displays the header/banner that is placed on top
-// of every formal compiler message (contains error kind, filepath, etc)
-func displayLogMessageHeader(buildPath string, cm *CompileMessage) {
-	fmt.Print("\n\n--- ")
+// displayPath shortens the path to a file for display: files in the build
+// directory and the standard library are displayed relative to those
+// directories.
+func displayPath(fpath string) string {
+	rpath, _ := filepath.Rel(logger.buildPath, fpath)
 
-	sb := strings.Builder{}
-	sb.WriteString(errorKindStringTable[cm.Kind])
+	// anything that is not in the build directory may be in the library
+	// directory so we can check for that (simplify our paths)
+	if strings.HasPrefix(rpath, "..") {
+		rpath, _ = filepath.Rel(filepath.Join(os.Getenv("WHIRL_PATH"), "lib"), fpath)
 
-	if cm.IsError {
-		sb.WriteString(" Error ")
-	} else {
-		sb.WriteString(" Warning ")
+		// if it also not in our library directory, then we just use the abspath
+		if strings.HasPrefix(rpath, "..") {
+			rpath = fpath
+		}
 	}
 
-	sb.WriteString(strings.Repeat("-", 28-sb.Len()))
-	fmt.Print(sb.String())
+	return filepath.Clean(rpath)
+}
 
-	fpath, _ := filepath.Rel(buildPath, cm.Context.FilePath)
+// FormatPosition formats the start of a position in a file as `path:line:col`
+// in the same way that the locations of compile messages are displayed.  This
+// is used to refer to other positions in the text of messages.
+func FormatPosition(fpath string, pos *TextPosition) string {
+	source := readSourceLines(fpath, pos.StartLn)
+	return formatLocation(fpath, pos, source[pos.StartLn-1])
+}
 
-	// anything that is not in the build directory may be in the library
-	// directory so we can check for that (simplify our paths)
-	if strings.HasPrefix(fpath, "..") {
-		fpath, _ = filepath.Rel(filepath.Join(os.Getenv("WHIRL_PATH"), "lib"), cm.Context.FilePath)
+// formatLocation formats the start of a position as `path:line:col` given the
+// source text of the line it starts on
+func formatLocation(fpath string, pos *TextPosition, line string) string {
+	return fmt.Sprintf("%s:%d:%d", displayPath(fpath), pos.StartLn, displayColumn(line, pos.StartCol))
+}
 
-		// if it also not in our library directory, then we just use the abspath
-		if strings.HasPrefix(fpath, "..") {
+// spanGroup is a group of spans of a compile message in the same file
+type spanGroup struct {
+	fpath string
+	spans []*Span
+}
+
+// groupSpans groups the spans of a compile message by file.  The group
+// containing the primary span always comes first and the spans within each
+// group retain their relative order.
+func (cm *CompileMessage) groupSpans() []*spanGroup {
+	var groups []*spanGroup
+
+	for _, span := range cm.Spans {
+		fpath := span.FilePath
+		if fpath == "" {
 			fpath = cm.Context.FilePath
 		}
+
+		found := false
+		for _, group := range groups {
+			if group.fpath == fpath {
+				group.spans = append(group.spans, span)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			groups = append(groups, &spanGroup{fpath: fpath, spans: []*Span{span}})
+		}
 	}
 
-	fmt.Printf(" (file: %s)\n", filepath.Clean(fpath))
+	return groups
 }
 
-// displayCodeSelection displays the code that an error occurs on and highlights
-// the relevant lines.  This function takes a scanner that should be positioned
-// at the starting line for that code (calling scanner.Text() yields that line).
-func displayCodeSelection(sc *bufio.Scanner, pos *TextPosition) {
-	minLnNumberLen := len(strconv.Itoa(pos.EndLn))
-	lnNumberFmtStr := "%-" + strconv.Itoa(minLnNumberLen) + "v | "
-
-	for line := pos.StartLn; line <= pos.EndLn; line++ {
-		fmt.Printf(lnNumberFmtStr, line)
-
-		// convert all tabs to four spaces (for consistency)
-		fmt.Println(strings.ReplaceAll(sc.Text(), "\t", "    "))
-
-		fmt.Print(strings.Repeat(" ", minLnNumberLen+3))
-
-		if pos.StartLn == pos.EndLn {
-			fmt.Print(strings.Repeat(" ", pos.StartCol))
-			fmt.Println(strings.Repeat("^", pos.EndCol-pos.StartCol))
-		} else {
-			if line == pos.StartLn {
-				fmt.Print(strings.Repeat(" ", pos.StartCol))
-				fmt.Println(strings.Repeat("^", len(sc.Text())-pos.StartCol))
-			} else if line == pos.EndLn {
-				fmt.Println(strings.Repeat("^", pos.EndCol+1))
-			} else {
-				fmt.Println(strings.Repeat("^", len(sc.Text())))
+// display displays the code selection for a group of spans.  The primary span
+// (if it is in the group) is highlighted in the style of the message; all other
+// spans are secondary.
+func (sg *spanGroup) display(gutterWidth int, style string, primary bool) {
+	gutter := strings.Repeat(" ", gutterWidth+1)
+
+	// collect all the lines that need to be displayed
+	lineSet := make(map[int]struct{})
+	maxLine := 0
+	for _, span := range sg.spans {
+		for line := span.Position.StartLn; line <= span.Position.EndLn; line++ {
+			lineSet[line] = struct{}{}
+		}
+
+		if span.Position.EndLn > maxLine {
+			maxLine = span.Position.EndLn
+		}
+	}
+
+	lines := make([]int, 0, len(lineSet))
+	for line := range lineSet {
+		lines = append(lines, line)
+	}
+
+	sort.Ints(lines)
+
+	source := readSourceLines(sg.fpath, maxLine)
+
+	// the location is the start of the first span in the group
+	first := sg.spans[0].Position
+	arrow := ":::"
+	if primary {
+		arrow = "-->"
+	}
+
+	fmt.Printf("%s%s %s\n", gutter[1:], colorize(styleBlue, arrow), formatLocation(sg.fpath, first, source[first.StartLn-1]))
+	fmt.Printf("%s%s\n", gutter, colorize(styleBlue, "|"))
+
+	for i, line := range lines {
+		// elide the lines between spans
+		if i > 0 && line > lines[i-1]+1 {
+			fmt.Println(colorize(styleBlue, "..."))
+		}
+
+		dl := newDisplayLine(source[line-1])
+
+		fmt.Printf("%s %s %s\n", colorize(styleBlue, fmt.Sprintf("%-*d", gutterWidth, line)), colorize(styleBlue, "|"), dl.text)
+
+		for j, span := range sg.spans {
+			if line < span.Position.StartLn || line > span.Position.EndLn {
+				continue
+			}
+
+			marker, markerStyle := "-", styleBlue
+			if primary && j == 0 {
+				marker, markerStyle = "^", style
 			}
 
-			sc.Scan()
+			start, end := dl.selection(span.Position, line)
+
+			highlight := strings.Repeat(marker, end-start)
+			if line == span.Position.EndLn && span.Label != "" {
+				highlight += " " + span.Label
+			}
+
+			fmt.Printf("%s%s %s%s\n", gutter, colorize(styleBlue, "|"), strings.Repeat(" ", start), colorize(markerStyle, highlight))
 		}
 	}
 }
 
+// readSourceLines reads the first `n` lines of a file.  If the file is shorter
+// than that (or can't be read), the missing lines are empty.
+func readSourceLines(fpath string, n int) []string {
+	lines := make([]string, n)
+
+	// the file should be guaranteed to exist since it was opened earlier
+	// (unless the user deleted it in between running the compiler and this
+	// function being called in which case we just display nothing)
+	f, err := os.Open(fpath)
+	if err != nil {
+		return lines
+	}
+
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for i := 0; i < n && sc.Scan(); i++ {
+		lines[i] = sc.Text()
+	}
+
+	return lines
+}
+
+// displayColumn converts a character column in a line into the 1-indexed
+// column that is displayed to the user: either in characters or, for editors,
+// in UTF-16 code units
+func displayColumn(line string, col int) int {
+	if logger.utf16Columns {
+		return utf16Column(line, col)
+	}
+
+	return col + 1
+}
+
 func (ie *InternalError) display() {
 	fmt.Println("\nConfiguration Error:", ie.Message)
 }
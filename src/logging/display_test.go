@@ -0,0 +1,188 @@
+package logging
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout captures everything written to stdout while running `f`
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	f()
+
+	w.Close()
+	return <-output
+}
+
+// writeTestSource writes a source file into a new build directory and sets up
+// the logger for that directory.  It returns the path to the file.
+func writeTestSource(t *testing.T, src string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "pkg", "file.wrl")
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fpath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger = newLogger(dir, LogLevelError)
+	return fpath
+}
+
+func TestFormatPosition(t *testing.T) {
+	fpath := writeTestSource(t, "let a = 1\nlet \U0001F600 = x\n")
+	pos := &TextPosition{StartLn: 2, StartCol: 8, EndLn: 2, EndCol: 9}
+
+	if got, want := FormatPosition(fpath, pos), filepath.Join("pkg", "file.wrl")+":2:9"; got != want {
+		t.Errorf("got `%s`, want `%s`", got, want)
+	}
+
+	// the emoji is two UTF-16 code units
+	logger.utf16Columns = true
+	if got, want := FormatPosition(fpath, pos), filepath.Join("pkg", "file.wrl")+":2:10"; got != want {
+		t.Errorf("got `%s` with UTF-16 columns, want `%s`", got, want)
+	}
+}
+
+// displayedCarets finds the lines of displayed output that contain the given
+// marker and returns them with the gutter stripped
+func displayedCarets(output, marker string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if i := strings.Index(line, "| "); i >= 0 && strings.Contains(line, marker) {
+			lines = append(lines, line[i+2:])
+		}
+	}
+
+	return lines
+}
+
+func TestDisplayCarets(t *testing.T) {
+	fpath := writeTestSource(t, "\tlet 界 = \tx\n\tlet y =\n\t\tz\n")
+
+	for _, c := range []struct {
+		name string
+		pos  *TextPosition
+		want []string
+	}{
+		// the tab before `x` expands to the next tab stop
+		{"tab", &TextPosition{StartLn: 1, StartCol: 8, EndLn: 1, EndCol: 9}, []string{"            ^"}},
+		// `界` is two columns wide
+		{"wide", &TextPosition{StartLn: 1, StartCol: 5, EndLn: 1, EndCol: 6}, []string{"        ^^"}},
+		{"multi-line", &TextPosition{StartLn: 2, StartCol: 5, EndLn: 3, EndCol: 3}, []string{"        ^^^", "^^^^^^^^^"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			cm := &CompileMessage{
+				Message: "message",
+				Context: &LogContext{FilePath: fpath},
+				IsError: true,
+				Spans:   []*Span{{Position: c.pos}},
+			}
+
+			got := displayedCarets(captureStdout(t, cm.display), "^")
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("got carets\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDisplayMessage(t *testing.T) {
+	fpath := writeTestSource(t, "let x = 1\nlet x = 2\n")
+	other := filepath.Join(filepath.Dir(fpath), "other.wrl")
+	if err := os.WriteFile(other, []byte("import x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &CompileMessage{
+		Message: "Symbol `x` already defined",
+		Kind:    LMKName,
+		Context: &LogContext{FilePath: fpath},
+		IsError: true,
+		Spans:   []*Span{{Position: &TextPosition{StartLn: 2, StartCol: 4, EndLn: 2, EndCol: 5}}},
+	}
+
+	for _, annot := range []Annotation{
+		WithLabel("redefined here"),
+		WithSpan(&TextPosition{StartLn: 1, StartCol: 4, EndLn: 1, EndCol: 5}, "first defined here"),
+		WithSpanIn(other, &TextPosition{StartLn: 1, StartCol: 7, EndLn: 1, EndCol: 8}, "imported here"),
+		// spans without positions are ignored
+		WithSpan(nil, "nowhere"),
+		WithNote("symbols can only be defined once"),
+		WithHelp("rename one of the definitions"),
+	} {
+		annot(cm)
+	}
+
+	output := captureStdout(t, cm.display)
+
+	want := strings.Join([]string{
+		"",
+		"error[Name]: Symbol `x` already defined",
+		" --> " + filepath.Join("pkg", "file.wrl") + ":2:5",
+		"  |",
+		"1 | let x = 1",
+		"  |     - first defined here",
+		"2 | let x = 2",
+		"  |     ^ redefined here",
+		" ::: " + filepath.Join("pkg", "other.wrl") + ":1:8",
+		"  |",
+		"1 | import x",
+		"  |        - imported here",
+		"  |",
+		"  = note: symbols can only be defined once",
+		"  = help: rename one of the definitions",
+		"",
+	}, "\n")
+
+	if output != want {
+		t.Errorf("got\n%s\nwant\n%s", output, want)
+	}
+}
+
+func TestColorMode(t *testing.T) {
+	writeTestSource(t, "")
+
+	if err := SetColorMode("always"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := colorize(styleRed, "error"), "\x1b[1;31merror\x1b[0m"; got != want {
+		t.Errorf("got %q with colors, want %q", got, want)
+	}
+
+	if err := SetColorMode("never"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := colorize(styleRed, "error"); got != "error" {
+		t.Errorf("got %q without colors", got)
+	}
+
+	if err := SetColorMode("sometimes"); err == nil {
+		t.Error("expected an error for an invalid color mode")
+	}
+}
@@ -14,11 +14,26 @@ type Logger struct {
 	// buildPath is used to shorten display paths in errors
 	buildPath string
 
+	// utf16Columns indicates that columns should be displayed as 1-based UTF-16
+	// code unit offsets (for editors) instead of character offsets
+	utf16Columns bool
+
+	// useColor indicates whether messages are displayed using ANSI colors
+	useColor bool
+
 	// prevUpdate is used to hold the last time when the state updated
 	prevUpdate time.Time
 
 	// logMsgChan is the channel used for passing `LogMessages` to the Logger
 	logMsgChan chan LogMessage
+
+	// stage buffers the compile messages logged during the current stage of
+	// compilation so that they can be displayed in a deterministic order
+	stage *LogBuffer
+
+	// stageEndChan is used to signal the end of a stage to the log loop.  The
+	// channel sent is closed once the messages of the stage have been displayed
+	stageEndChan chan chan struct{}
 }
 
 // Enumeration of the different log levels
@@ -30,10 +45,12 @@ const (
 )
 
 // newLogger creates a new logger struct
-func newLogger(buildPath string, loglevel int) Logger {
-	l := Logger{buildPath: buildPath, LogLevel: loglevel}
+func newLogger(buildPath string, loglevel int) *Logger {
+	l := &Logger{buildPath: buildPath, LogLevel: loglevel, useColor: stdoutIsTerminal()}
 
 	l.logMsgChan = make(chan LogMessage)
+	l.stage = NewLogBuffer()
+	l.stageEndChan = make(chan chan struct{})
 
 	return l
 }
@@ -47,18 +64,42 @@ func newLogger(buildPath string, loglevel int) Logger {
 // the compiler to function.  It also handles all updates to the Logger's state
 func (l *Logger) logLoop() {
 	for {
-		if lm, ok := <-l.logMsgChan; ok {
+		select {
+		case lm := <-l.logMsgChan:
 			if lm.isError() {
 				l.ErrorCount++
+			}
 
+			// compile messages are displayed at the end of their stage; all
+			// other messages are not associated with any particular position
+			// and so can be handled immediately
+			if cm, ok := lm.(*CompileMessage); ok {
+				l.stage.add(cm)
+			} else if lm.isError() {
 				if l.LogLevel > LogLevelSilent {
 					lm.display()
 				}
 			} else {
 				l.warnings = append(l.warnings, lm)
 			}
+		case flushed := <-l.stageEndChan:
+			l.endStage()
+			close(flushed)
+		}
+	}
+}
+
+// endStage displays all of the errors logged during the current stage and
+// stores the warnings (in sorted order) to be displayed at the end of
+// compilation
+func (l *Logger) endStage() {
+	for _, cm := range l.stage.take() {
+		if cm.IsError {
+			if l.LogLevel > LogLevelSilent {
+				cm.display()
+			}
 		} else {
-			break
+			l.warnings = append(l.warnings, cm)
 		}
 	}
 }
@@ -0,0 +1,16 @@
+package logging
+
+import "testing"
+
+func TestInitializeErrorCount(t *testing.T) {
+	Initialize(t.TempDir(), "silent")
+
+	LogCompileError(&LogContext{FilePath: "a.wrl"}, "error", LMKName, nil)
+	LogCompileWarning(&LogContext{FilePath: "a.wrl"}, "warning", LMKName, nil)
+	LogStageEnd()
+
+	// the messages handled by the log loop are counted by the global logger
+	if ShouldProceed() || logger.ErrorCount != 1 || len(logger.warnings) != 1 {
+		t.Errorf("got %d errors and %d warnings, want 1 of each", logger.ErrorCount, len(logger.warnings))
+	}
+}
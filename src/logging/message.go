@@ -18,11 +18,86 @@ type LogMessage interface {
 // alert the user of some fault or possible issue with their code.  This is a
 // standard compiler error.
 type CompileMessage struct {
-	Message  string
-	Kind     int
+	Message string
+	Kind    int
+	Context *LogContext
+	IsError bool
+
+	// Spans are the labeled positions in the source text that the message
+	// refers to.  The first span is the primary span: the position the message
+	// occurred at.  The remaining spans provide additional context (eg. where a
+	// symbol was originally defined).  This is empty if the message has no
+	// position.
+	Spans []*Span
+
+	// Notes and Help are the `note:` and `help:` lines displayed after the code
+	// selection of the message
+	Notes, Help []string
+}
+
+// Position returns the position of the primary span of the message or `nil` if
+// the message has no position
+func (cm *CompileMessage) Position() *TextPosition {
+	if len(cm.Spans) == 0 {
+		return nil
+	}
+
+	return cm.Spans[0].Position
+}
+
+// Span is a labeled position in the source text
+type Span struct {
 	Position *TextPosition
-	Context  *LogContext
-	IsError  bool
+
+	// Label is the text displayed beside the highlighted code (can be empty)
+	Label string
+
+	// FilePath is the path to the file the span is in.  If it is empty, the span
+	// is in the file of the message it belongs to.
+	FilePath string
+}
+
+// Annotation is used to attach extra information (labels, secondary spans,
+// notes and help lines) to a compile message when it is logged
+type Annotation func(cm *CompileMessage)
+
+// WithLabel labels the primary span of a compile message
+func WithLabel(label string) Annotation {
+	return func(cm *CompileMessage) {
+		if len(cm.Spans) > 0 {
+			cm.Spans[0].Label = label
+		}
+	}
+}
+
+// WithSpan adds a secondary span in the file of the compile message.  Spans
+// without positions are ignored.
+func WithSpan(pos *TextPosition, label string) Annotation {
+	return WithSpanIn("", pos, label)
+}
+
+// WithSpanIn adds a secondary span in the file at the given path.  If the path
+// is empty, the span is in the file of the compile message.
+func WithSpanIn(fpath string, pos *TextPosition, label string) Annotation {
+	return func(cm *CompileMessage) {
+		if pos != nil && len(cm.Spans) > 0 {
+			cm.Spans = append(cm.Spans, &Span{Position: pos, Label: label, FilePath: fpath})
+		}
+	}
+}
+
+// WithNote adds a `note:` line to a compile message
+func WithNote(note string) Annotation {
+	return func(cm *CompileMessage) {
+		cm.Notes = append(cm.Notes, note)
+	}
+}
+
+// WithHelp adds a `help:` line to a compile message
+func WithHelp(help string) Annotation {
+	return func(cm *CompileMessage) {
+		cm.Help = append(cm.Help, help)
+	}
 }
 
 // Enumeration of the different kinds of a compile messages
@@ -45,7 +120,10 @@ const (
 	// TODO: add more as needed
 )
 
-// TextPosition represents a positional range in the source text
+// TextPosition represents a positional range in the source text.  Columns are
+// counted in characters (Unicode code points): tabs and wide characters count
+// as a single column.  How wide the text is actually displayed is calculated
+// when the position is displayed.
 type TextPosition struct {
 	StartLn, StartCol int // starting line, starting 0-indexed column
 	EndLn, EndCol     int // ending Line, column trailing token (one over)
@@ -55,6 +133,22 @@ type TextPosition struct {
 type LogContext struct {
 	PackageID uint
 	FilePath  string
+
+	// Buffer is the buffer that compile messages logged in this context are
+	// collected in.  If it is `nil`, the messages are logged immediately.
+	Buffer *LogBuffer
+}
+
+// ShouldProceed checks if there are any errors that should block compilation
+// from proceeding in this context.  If the context is buffered, only the errors
+// in its buffer are considered: the errors logged elsewhere may be logged
+// concurrently.
+func (lctx *LogContext) ShouldProceed() bool {
+	if lctx.Buffer != nil {
+		return !lctx.Buffer.HasErrors()
+	}
+
+	return ShouldProceed()
 }
 
 func (cm *CompileMessage) isError() bool {
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithSuggestion adds a `help:` line suggesting the candidate closest to a
+// misspelled name (eg. "did you mean `println`?").  If no candidate is close
+// enough to the name, nothing is added.
+func WithSuggestion(name string, candidates []string) Annotation {
+	return func(cm *CompileMessage) {
+		if suggestion, ok := SuggestName(name, candidates); ok {
+			cm.Help = append(cm.Help, fmt.Sprintf("did you mean `%s`?", suggestion))
+		}
+	}
+}
+
+// SuggestName finds the candidate closest to a (probably misspelled) name by
+// edit distance.  Candidates that differ from the name only by case are always
+// preferred.  Otherwise, only candidates within a third of the length of the
+// name (at least one edit) are considered close enough.  Ties are broken by
+// picking the alphabetically first candidate so that suggestions are always
+// the same.
+func SuggestName(name string, candidates []string) (string, bool) {
+	best := ""
+	bestDist := len([]rune(name)) / 3
+	if bestDist < 1 {
+		bestDist = 1
+	}
+
+	for _, candidate := range candidates {
+		if candidate == name || candidate == "" {
+			continue
+		}
+
+		// candidates that only differ by case are treated as exact matches
+		dist := 0
+		if !strings.EqualFold(candidate, name) {
+			dist = editDistance(name, candidate)
+		}
+
+		if dist < bestDist || dist == bestDist && (best == "" || candidate < best) {
+			best, bestDist = candidate, dist
+		}
+	}
+
+	return best, best != ""
+}
+
+// editDistance calculates the optimal string alignment distance between two
+// strings: the number of insertions, deletions, substitutions and
+// transpositions of adjacent characters needed to turn one into the other
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	// only the last three rows of the distance matrix are needed
+	prev2 := make([]int, len(br)+1)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				curr[j] = minInt(curr[j], prev2[j-2]+1)
+			}
+		}
+
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(br)]
+}
+
+// minInt returns the smaller of two integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,55 @@
+package logging
+
+import "testing"
+
+func TestSuggestName(t *testing.T) {
+	for _, c := range []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		// short names may be one edit away
+		{"Bx", []string{"B", "Cy"}, "B"},
+		{"fo", []string{"foo", "bar"}, "foo"},
+		{"x", []string{"y"}, "y"},
+		{"x", []string{"yz"}, ""},
+		// longer names may be a third of their length away
+		{"prntln", []string{"println", "print"}, "println"},
+		{"lenght", []string{"length"}, "length"},
+		{"abcdef", []string{"uvwxyz"}, ""},
+		// case differences are preferred over any other edit
+		{"point", []string{"paint", "Point"}, "Point"},
+		// ties are broken alphabetically
+		{"bat", []string{"cat", "bag", "rat"}, "bag"},
+		// the name itself is never suggested
+		{"foo", []string{"foo"}, ""},
+		{"foo", nil, ""},
+		{"proj::utl", []string{"proj", "proj::util", "proj::util::fmt"}, "proj::util"},
+	} {
+		got, ok := SuggestName(c.name, c.candidates)
+		if got != c.want || ok != (c.want != "") {
+			t.Errorf("SuggestName(%q, %v) = (%q, %v), want %q", c.name, c.candidates, got, ok, c.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"abc", "abd", 1},
+		{"abc", "ab", 1},
+		{"ab", "abc", 1},
+		// adjacent transpositions are a single edit
+		{"abc", "bac", 1},
+		{"größe", "grösse", 2},
+	} {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
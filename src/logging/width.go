@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// tabWidth is the number of columns between tab stops when source text is
+// displayed
+const tabWidth = 4
+
+// displayLine is a line of source text prepared for display: tabs are
+// expanded and the display column of each character is calculated
+type displayLine struct {
+	// text is the line with all tabs expanded to spaces
+	text string
+
+	// cols maps each character (code point) index in the original line to the
+	// display column it begins at.  It has one extra entry at the end which is
+	// the total display width of the line.
+	cols []int
+}
+
+// newDisplayLine prepares a line of source text for display
+func newDisplayLine(line string) *displayLine {
+	dl := &displayLine{}
+	sb := strings.Builder{}
+
+	col := 0
+	for _, r := range line {
+		dl.cols = append(dl.cols, col)
+
+		if r == '\t' {
+			n := tabWidth - col%tabWidth
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+
+		sb.WriteRune(r)
+		col += runeDisplayWidth(r)
+	}
+
+	dl.cols = append(dl.cols, col)
+	dl.text = sb.String()
+	return dl
+}
+
+// column converts a character column into a display column.  Columns past the
+// end of the line are treated as being at the end of the line.
+func (dl *displayLine) column(col int) int {
+	if col < 0 {
+		return 0
+	} else if col >= len(dl.cols) {
+		return dl.width()
+	}
+
+	return dl.cols[col]
+}
+
+// selection calculates the range of display columns a text position covers on
+// a given line of the position.  It always covers at least one column so that
+// positions at the end of a line (eg. an unexpected newline) are still visible.
+func (dl *displayLine) selection(pos *TextPosition, line int) (int, int) {
+	start, end := 0, dl.width()
+	if line == pos.StartLn {
+		start = dl.column(pos.StartCol)
+	}
+
+	if line == pos.EndLn {
+		end = dl.column(pos.EndCol)
+	}
+
+	if end <= start {
+		end = start + 1
+	}
+
+	return start, end
+}
+
+// width returns the display width of the whole line
+func (dl *displayLine) width() int {
+	return dl.cols[len(dl.cols)-1]
+}
+
+// runeDisplayWidth calculates the number of columns a character takes up when
+// it is displayed in a terminal: combining marks and other zero-width
+// characters take up no columns and East Asian wide characters take up two
+func runeDisplayWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc) {
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+
+	return 1
+}
+
+// utf16Column converts a character column in a line into a 1-based column
+// counted in UTF-16 code units (as used by most editors and the language
+// server protocol)
+func utf16Column(line string, col int) int {
+	units := 0
+
+	i := 0
+	for _, r := range line {
+		if i == col {
+			break
+		}
+
+		// characters outside of the BMP are encoded as surrogate pairs
+		if r > 0xffff {
+			units += 2
+		} else {
+			units++
+		}
+
+		i++
+	}
+
+	// columns past the end of the line are still counted as single units
+	return units + col - i + 1
+}
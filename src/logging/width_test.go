@@ -0,0 +1,101 @@
+package logging
+
+import "testing"
+
+func TestRuneDisplayWidth(t *testing.T) {
+	for _, c := range []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{'ß', 1},
+		{'界', 2},
+		{'\U0001F600', 2},
+		{'Ａ', 2},
+		// combining acute accent
+		{'\u0301', 0},
+		// zero width joiner
+		{'\u200d', 0},
+	} {
+		if got := runeDisplayWidth(c.r); got != c.want {
+			t.Errorf("runeDisplayWidth(%U) = %d, want %d", c.r, got, c.want)
+		}
+	}
+}
+
+func TestDisplayLine(t *testing.T) {
+	for _, c := range []struct {
+		line, text string
+		cols       []int
+	}{
+		{"ab", "ab", []int{0, 1, 2}},
+		// tabs expand to the next tab stop
+		{"\tx", "    x", []int{0, 4, 5}},
+		{"ab\tx", "ab  x", []int{0, 1, 2, 4, 5}},
+		// wide characters take up two columns and combining marks none
+		{"界x", "界x", []int{0, 2, 3}},
+		{"e\u0301x", "e\u0301x", []int{0, 1, 1, 2}},
+	} {
+		dl := newDisplayLine(c.line)
+		if dl.text != c.text {
+			t.Errorf("newDisplayLine(%q).text = %q, want %q", c.line, dl.text, c.text)
+		}
+
+		if len(dl.cols) != len(c.cols) {
+			t.Errorf("newDisplayLine(%q).cols = %v, want %v", c.line, dl.cols, c.cols)
+			continue
+		}
+
+		for i := range c.cols {
+			if dl.cols[i] != c.cols[i] {
+				t.Errorf("newDisplayLine(%q).cols = %v, want %v", c.line, dl.cols, c.cols)
+				break
+			}
+		}
+	}
+}
+
+func TestDisplayLineSelection(t *testing.T) {
+	dl := newDisplayLine("\t界 = x")
+
+	for _, c := range []struct {
+		pos        *TextPosition
+		line       int
+		start, end int
+	}{
+		// `界` is the second character
+		{&TextPosition{StartLn: 1, StartCol: 1, EndLn: 1, EndCol: 2}, 1, 4, 6},
+		{&TextPosition{StartLn: 1, StartCol: 5, EndLn: 1, EndCol: 6}, 1, 9, 10},
+		// positions at the end of the line still cover a column
+		{&TextPosition{StartLn: 1, StartCol: 6, EndLn: 1, EndCol: 6}, 1, 10, 11},
+		// multi-line positions cover the rest of the first line, the start
+		// of the last line and all of the lines in between
+		{&TextPosition{StartLn: 1, StartCol: 3, EndLn: 3, EndCol: 2}, 1, 7, 10},
+		{&TextPosition{StartLn: 1, StartCol: 3, EndLn: 3, EndCol: 2}, 2, 0, 10},
+		{&TextPosition{StartLn: 1, StartCol: 3, EndLn: 3, EndCol: 2}, 3, 0, 6},
+	} {
+		if start, end := dl.selection(c.pos, c.line); start != c.start || end != c.end {
+			t.Errorf("selection(%+v, %d) = (%d, %d), want (%d, %d)", *c.pos, c.line, start, end, c.start, c.end)
+		}
+	}
+}
+
+func TestUTF16Column(t *testing.T) {
+	for _, c := range []struct {
+		line      string
+		col, want int
+	}{
+		{"abc", 0, 1},
+		{"abc", 2, 3},
+		{"ß = x", 4, 5},
+		// characters outside the BMP are two code units
+		{"\U0001F600 = x", 4, 6},
+		{"\U0001F600\U0001F600x", 2, 5},
+		// columns past the end of the line
+		{"ab", 4, 5},
+	} {
+		if got := utf16Column(c.line, c.col); got != c.want {
+			t.Errorf("utf16Column(%q, %d) = %d, want %d", c.line, c.col, got, c.want)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
 	"whirlwind/logging"
+	"whirlwind/syntax"
 
 	"gopkg.in/yaml.v2"
 )
@@ -13,6 +15,7 @@ import (
 // CreateModule creates a new directory for a module and initializes it with
 // the given name
 func CreateModule(name string) error {
+	name = syntax.NormalizeIdentifier(name)
 	finfo, err := os.Stat(name)
 
 	// we want the directory to not exist so we can create a new module
@@ -41,6 +44,7 @@ func CreateModule(name string) error {
 // InitModule initializes a module with a given name in the given directory
 func InitModule(name, path string) error {
 	// first we need to check if the module will be valid
+	name = syntax.NormalizeIdentifier(name)
 	if !IsValidPackageName(name) {
 		return fmt.Errorf("`%s` is not a valid module name", name)
 	}
@@ -1,107 +1,408 @@
 package mods
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"whirlwind/logging"
+	"whirlwind/syntax"
 
 	"gopkg.in/yaml.v2"
 )
 
+// moduleSchema is the typed schema of a module file.  The module file is
+// decoded strictly into this struct so that unknown fields and values of the
+// wrong type are reported along with the line they occur on.
+type moduleSchema struct {
+	Name         string              `yaml:"name"`
+	CustomPaths  map[string]string   `yaml:"custom_paths,omitempty"`
+	Dependencies map[string]string   `yaml:"dependencies,omitempty"`
+	Cycles       string              `yaml:"cycles,omitempty"`
+	Prelude      map[string][]string `yaml:"prelude,omitempty"`
+}
+
 // LoadModule attempts to load a module in the given package directory.  If no
 // module is found or some other error occurs, the return flag is false.  `path`
 // must be an absolute path
 func LoadModule(path string) (*Module, bool) {
 	modulePath := filepath.Join(path, moduleFileName)
 
-	finfo, err := os.Stat(modulePath)
+	fbytes, err := readModuleFile(modulePath)
 
 	if err != nil {
-		// module file does not exist => no module; no error
-		if os.IsNotExist(err) {
-			return nil, false
-		}
-
 		// something else went wrong, report it as fatal
 		logging.LogFatal("Fatal error loading module: " + err.Error())
 		return nil, false
 	}
 
-	// some directory named `whirl-mod.yml` instead of a file
-	if finfo.IsDir() {
+	// module file does not exist => no module; no error
+	if fbytes == nil {
 		return nil, false
 	}
 
-	fbytes, err := os.ReadFile(modulePath)
+	mod, errs := parseModuleYAML(fbytes, path)
+
+	// any module parsing errors are not fatal: they should simply be logged and
+	// then the compiler should proceed as if there is no module there
+	if len(errs) > 0 {
+		for _, err := range errs {
+			logging.LogInternalError("Module", fmt.Sprintf("%s: %s", modulePath, err))
+		}
 
-	if err != nil {
-		// weird error loading module that didn't occur during os.Stat should be
-		// reported...
-		logging.LogFatal("Fatal error loading module: " + err.Error())
 		return nil, false
 	}
 
-	// unmarshall the yaml into a map
-	moduleData := make(map[string]interface{})
-	err = yaml.Unmarshal(fbytes, &moduleData)
+	return mod, true
+}
 
-	// invalid yaml -- log error and but do not exit fatally as we can simply
-	// continue compilation as if there was no module
+// OpenModule loads the module in the given directory for use outside of
+// compilation (eg. by the `mod` subcommands).  Unlike `LoadModule`, it returns
+// all of the problems it encounters as an error instead of logging them.
+func OpenModule(path string) (*Module, error) {
+	path, err := filepath.Abs(path)
 	if err != nil {
-		logging.LogInternalError("Module", fmt.Sprintf("YAML Error Decoding module: %s", err))
-		return nil, false
+		return nil, err
 	}
 
-	mod, err := parseModuleYAML(moduleData, path)
+	modulePath := filepath.Join(path, moduleFileName)
 
-	// any module parsing errors are not fatal: they should simply be logged and
-	// then the compiler should proceed as if there is no module there
+	fbytes, err := readModuleFile(modulePath)
 	if err != nil {
-		logging.LogInternalError("Module", err.Error())
-		return nil, false
+		return nil, err
+	} else if fbytes == nil {
+		return nil, fmt.Errorf("No module exists in `%s`", path)
 	}
 
-	return mod, true
+	mod, errs := parseModuleYAML(fbytes, path)
+	if len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = fmt.Sprintf("%s: %s", modulePath, err)
+		}
+
+		return nil, errors.New(strings.Join(msgs, "\n"))
+	}
+
+	return mod, nil
+}
+
+// readModuleFile reads the contents of the module file at the given path.  If
+// the module file does not exist, then both return values are `nil`.
+func readModuleFile(modulePath string) ([]byte, error) {
+	finfo, err := os.Stat(modulePath)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	// some directory named `whirl-mod.yml` instead of a file
+	if finfo.IsDir() {
+		return nil, nil
+	}
+
+	return os.ReadFile(modulePath)
 }
 
-// parseModuleYAML walks the unmarshaled yaml data of the module
-func parseModuleYAML(modData map[string]interface{}, path string) (*Module, error) {
-	if nameField, ok := modData["name"]; ok {
-		if name, ok := nameField.(string); ok {
-			if !IsValidPackageName(name) {
-				return nil, fmt.Errorf("Invalid module name: `%s`", name)
+// decodeModuleSchema decodes the contents of a module file into its schema
+// without performing any validation beyond type checking
+func decodeModuleSchema(src []byte) (*moduleSchema, []error) {
+	schema := &moduleSchema{}
+
+	if err := yaml.UnmarshalStrict(src, schema); err != nil {
+		// type errors are a list of messages of the form `line n: ...` so we
+		// can just return them as individual errors
+		if terr, ok := err.(*yaml.TypeError); ok {
+			errs := make([]error, len(terr.Errors))
+			for i, msg := range terr.Errors {
+				errs[i] = errors.New(msg)
 			}
 
-			mod := &Module{
-				Name:          name,
-				Path:          path,
-				PathOverrides: make(map[string]string),
+			return nil, errs
+		}
+
+		// invalid yaml -- the message already contains the line number
+		return nil, []error{err}
+	}
+
+	return schema, nil
+}
+
+// parseModuleYAML decodes and validates the contents of a module file.  `path`
+// is the absolute path to the module directory: all relative custom paths are
+// resolved against it.  All of the errors encountered are returned.
+func parseModuleYAML(src []byte, path string) (*Module, []error) {
+	schema, errs := decodeModuleSchema(src)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if schema.Name == "" {
+		return nil, []error{errors.New("module missing required field `name`")}
+	}
+
+	// names are compared to the identifiers in source code which are always
+	// normalized
+	modName := syntax.NormalizeIdentifier(schema.Name)
+	if !IsValidPackageName(modName) {
+		return nil, []error{moduleFieldError(src, "", "name", "invalid module name: `%s`", schema.Name)}
+	}
+
+	mod := &Module{
+		Name:          modName,
+		Path:          path,
+		PathOverrides: make(map[string]string),
+		Dependencies:  make(map[string]string),
+		Prelude:       make(map[string][]string),
+	}
+
+	switch schema.Cycles {
+	case "", "allow":
+		mod.CyclePolicy = CyclesAllow
+	case "warn":
+		mod.CyclePolicy = CyclesWarn
+	case "deny":
+		mod.CyclePolicy = CyclesDeny
+	default:
+		errs = append(errs, moduleFieldError(src, "", "cycles", "invalid cycle policy: `%s` (expected `allow`, `warn` or `deny`)", schema.Cycles))
+	}
+
+	for _, pattern := range sortedFieldKeys(src, "custom_paths", schema.CustomPaths) {
+		if err := mod.addPathOverride(pattern, schema.CustomPaths[pattern]); err != nil {
+			errs = append(errs, moduleFieldError(src, "custom_paths", pattern, "%s", err))
+		}
+	}
+
+	for _, depName := range sortedFieldKeys(src, "dependencies", schema.Dependencies) {
+		normDepName := syntax.NormalizeIdentifier(depName)
+
+		if !IsValidPackageName(normDepName) {
+			errs = append(errs, moduleFieldError(src, "dependencies", depName, "invalid dependency name: `%s`", depName))
+		} else if normDepName == mod.Name {
+			errs = append(errs, moduleFieldError(src, "dependencies", depName, "module cannot depend on itself"))
+		} else {
+			mod.Dependencies[normDepName] = mod.resolvePath(schema.Dependencies[depName])
+		}
+	}
+
+	for _, pkgPath := range sortedFieldKeys(src, "prelude", schema.Prelude) {
+		if err := mod.addPreludePackage(pkgPath, schema.Prelude[pkgPath]); err != nil {
+			errs = append(errs, moduleFieldError(src, "prelude", pkgPath, "%s", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// the most specific glob patterns must be tested first
+	sort.Slice(mod.GlobOverrides, func(i, j int) bool {
+		return len(mod.GlobOverrides[i].Prefix) > len(mod.GlobOverrides[j].Prefix)
+	})
+
+	return mod, nil
+}
+
+// sortedFieldKeys returns the keys of a mapping field of the module file in the
+// order that they appear in the file.  Map iteration order is random so this is
+// used to make sure that errors are reported in a sensible order.
+func sortedFieldKeys(src []byte, parent string, m interface{}) []string {
+	var keys []string
+	switch v := m.(type) {
+	case map[string]string:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	case map[string][]string:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return findKeyLine(src, parent, keys[i]) < findKeyLine(src, parent, keys[j])
+	})
+
+	return keys
+}
+
+// resolvePath converts a path in the module file to an absolute path.  Paths
+// are relative to the module, not to wherever the compiler is invoked from.
+func (m *Module) resolvePath(fpath string) string {
+	if !filepath.IsAbs(fpath) {
+		fpath = filepath.Join(m.Path, fpath)
+	}
+
+	return filepath.Clean(fpath)
+}
+
+// addPathOverride validates a single `custom_paths` entry and adds it to the
+// module.  Import paths may be written using either `/` or `::` as separators.
+// If the pattern ends in `*`, then it is a glob override and its target must
+// also end in `*`: the rest of the import path is substituted in for it.
+func (m *Module) addPathOverride(pattern, target string) error {
+	pattern = syntax.NormalizeIdentifier(strings.ReplaceAll(pattern, "::", "/"))
+
+	if strings.HasSuffix(pattern, "*") != strings.HasSuffix(target, "*") {
+		return fmt.Errorf("custom path `%s` and its target `%s` must both be glob patterns or neither be", pattern, target)
+	}
+
+	isGlob := strings.HasSuffix(pattern, "*")
+	if isGlob {
+		pattern = strings.TrimSuffix(pattern, "*")
+		target = strings.TrimSuffix(target, "*")
+
+		// `*` must take up an entire path segment (eg. `net/*` not `net*`)
+		if pattern != "" && !strings.HasSuffix(pattern, "/") {
+			return fmt.Errorf("glob `*` must be a full path segment in custom path `%s*`", pattern)
+		}
+
+		if target != "" && !strings.HasSuffix(target, "/") && !strings.HasSuffix(target, "\\") {
+			return fmt.Errorf("glob `*` must be a full path segment in target `%s*`", target)
+		}
+	}
+
+	// a lone `*` overrides every path in the module => no segments to check
+	if pattern != "" {
+		for _, segment := range strings.Split(strings.TrimSuffix(pattern, "/"), "/") {
+			if strings.Contains(segment, "*") {
+				return fmt.Errorf("glob `*` may only appear at the end of custom path `%s`", pattern)
+			} else if !IsValidPackageName(segment) {
+				return fmt.Errorf("`%s` is not a valid package name in custom path `%s`", segment, pattern)
 			}
+		}
+	}
+
+	if strings.Contains(target, "*") {
+		return fmt.Errorf("glob `*` may only appear at the end of target `%s`", target)
+	}
+
+	target = m.resolvePath(target)
+
+	if isGlob {
+		m.GlobOverrides = append(m.GlobOverrides, &GlobOverride{Prefix: pattern, Target: target})
+	} else if _, ok := m.PathOverrides[pattern]; ok {
+		// different spellings of the same path (eg. `a::b` and `a/b`)
+		return fmt.Errorf("multiple custom paths given for `%s`", pattern)
+	} else {
+		m.PathOverrides[pattern] = target
+	}
 
-			customPathsField, ok := modData["custom_paths"]
-			if ok {
-				if customPaths, ok := customPathsField.(map[string]string); ok {
-					for relPath, cpath := range customPaths {
-						absCPath, err := filepath.Abs(cpath)
-
-						if err != nil {
-							return nil, fmt.Errorf("Bad custom path: %s", err.Error())
-						}
-
-						mod.PathOverrides[relPath] = absCPath
-					}
-				} else {
-					return nil, fmt.Errorf("Module field `custom_paths` must be a mapping of path strings")
-				}
+	return nil
+}
+
+// addPreludePackage validates a single `prelude` entry and adds it to the
+// module.  The package must belong to the module and the symbols must be valid
+// identifiers.
+func (m *Module) addPreludePackage(pkgPath string, symbols []string) error {
+	pkgPath = syntax.NormalizeIdentifier(strings.ReplaceAll(pkgPath, "::", "/"))
+
+	segments := strings.Split(pkgPath, "/")
+	if segments[0] != m.Name {
+		return fmt.Errorf("prelude package `%s` is not in module `%s`", pkgPath, m.Name)
+	}
+
+	for _, segment := range segments {
+		if !IsValidPackageName(segment) {
+			return fmt.Errorf("`%s` is not a valid package name in prelude package `%s`", segment, pkgPath)
+		}
+	}
+
+	if _, ok := m.Prelude[pkgPath]; ok {
+		return fmt.Errorf("multiple prelude entries given for `%s`", pkgPath)
+	}
+
+	seen := make(map[string]struct{})
+	for i, symbol := range symbols {
+		symbol = syntax.NormalizeIdentifier(symbol)
+		symbols[i] = symbol
+
+		if !IsValidPackageName(symbol) {
+			return fmt.Errorf("`%s` is not a valid symbol name", symbol)
+		} else if _, ok := seen[symbol]; ok {
+			return fmt.Errorf("symbol `%s` listed multiple times", symbol)
+		}
+
+		seen[symbol] = struct{}{}
+	}
+
+	m.Prelude[pkgPath] = symbols
+	return nil
+}
+
+// moduleFieldError creates a new error for a field of the module file prefixed
+// with the line the field is declared on (if it can be found)
+func moduleFieldError(src []byte, parent, key, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+
+	if line := findKeyLine(src, parent, key); line > 0 {
+		return fmt.Errorf("line %d: %s", line, msg)
+	}
+
+	return errors.New(msg)
+}
+
+// findKeyLine attempts to find the (1-indexed) line that a key is declared on
+// in the module file.  `parent` is the top-level key that the key is nested in
+// or "" if the key is itself top-level.  If the key can't be found, the line of
+// its parent is returned instead (or 0 if that can't be found either -- eg. for
+// flow-style mappings).
+func findKeyLine(src []byte, parent, key string) int {
+	if keyLine, parentLine := locateKey(src, parent, key); keyLine > 0 {
+		return keyLine
+	} else {
+		return parentLine
+	}
+}
+
+// locateKey finds the (1-indexed) lines of a key and of its parent in the
+// module file.  yaml.v2 doesn't expose the positions of decoded values so we
+// have to do a (simple) scan of the source ourselves.  Either line is 0 if it
+// could not be found.
+func locateKey(src []byte, parent, key string) (int, int) {
+	sc := bufio.NewScanner(bytes.NewReader(src))
+
+	var currentTopLevel string
+	parentLine := 0
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		trimmed := strings.TrimSpace(text)
+
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+
+		// keys may themselves contain `::` so we look for the `: ` separator
+		colon := strings.Index(trimmed, ": ")
+		if colon == -1 {
+			if !strings.HasSuffix(trimmed, ":") {
+				continue
 			}
 
-			return mod, nil
+			colon = len(trimmed) - 1
 		}
 
-		return nil, errors.New("Module field `name` must be a string")
+		lineKey := strings.Trim(trimmed[:colon], "\"' ")
+		if len(text) == len(strings.TrimLeft(text, " \t")) {
+			currentTopLevel = lineKey
+
+			if parent == "" && lineKey == key {
+				return line, 0
+			} else if parent != "" && lineKey == parent {
+				parentLine = line
+			}
+		} else if parent != "" && currentTopLevel == parent && lineKey == key {
+			return line, parentLine
+		}
 	}
 
-	return nil, errors.New("Module missing required field `name`")
+	return 0, parentLine
 }
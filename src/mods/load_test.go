@@ -0,0 +1,180 @@
+package mods
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseModuleYAML(t *testing.T) {
+	modPath := filepath.FromSlash("/work/proj")
+
+	src := []byte(`name: proj
+custom_paths:
+  net/*: ../forks/net/*
+  net/http: ../http
+  util::fmt: vendor/fmt
+cycles: warn
+dependencies:
+  other: ../other
+`)
+
+	mod, errs := parseModuleYAML(src, modPath)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if mod.Name != "proj" || mod.Path != modPath {
+		t.Errorf("got module `%s` at `%s`", mod.Name, mod.Path)
+	}
+
+	if mod.CyclePolicy != CyclesWarn {
+		t.Errorf("got cycle policy %d, want %d", mod.CyclePolicy, CyclesWarn)
+	}
+
+	if got, want := mod.Dependencies["other"], filepath.FromSlash("/work/other"); got != want {
+		t.Errorf("got dependency path `%s`, want `%s`", got, want)
+	}
+
+	overrides := []struct {
+		importPath, want string
+		ok               bool
+	}{
+		// exact overrides take precedence over globs
+		{"net/http", "/work/http", true},
+		{"net/tcp", "/work/forks/net/tcp", true},
+		{"net/tcp/ip", "/work/forks/net/tcp/ip", true},
+		// `::` separators are normalized
+		{"util/fmt", "/work/proj/vendor/fmt", true},
+		// the glob must match at least one segment
+		{"net", "", false},
+		{"io", "", false},
+	}
+
+	for _, o := range overrides {
+		got, ok := mod.LookupPathOverride(o.importPath)
+		if ok != o.ok || ok && got != filepath.FromSlash(o.want) {
+			t.Errorf("LookupPathOverride(%q) = (%q, %v), want (%q, %v)", o.importPath, got, ok, o.want, o.ok)
+		}
+	}
+}
+
+func TestParseModuleYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"missing name", "cycles: warn\n", "missing required field `name`"},
+		{"unknown field", "name: proj\nversion: 1\n", "line 2: field version not found"},
+		{"wrong type", "name: proj\ncustom_paths: [a]\n", "line 2"},
+		{"bad cycle policy", "name: proj\ncycles: sometimes\n", "invalid cycle policy"},
+		{"mismatched glob", "name: proj\ncustom_paths:\n  net/*: ../net\n", "must both be glob patterns"},
+		{"partial glob segment", "name: proj\ncustom_paths:\n  net*: ../net*\n", "full path segment"},
+		{"glob in middle", "name: proj\ncustom_paths:\n  a/*/b: ../b\n", "may only appear at the end"},
+		{"duplicate spelling", "name: proj\ncustom_paths:\n  a/b: x\n  a::b: y\n", "multiple custom paths"},
+		{"self dependency", "name: proj\ndependencies:\n  proj: .\n", "cannot depend on itself"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, errs := parseModuleYAML([]byte(test.src), filepath.FromSlash("/work/proj"))
+			if len(errs) == 0 {
+				t.Fatalf("expected an error containing %q", test.want)
+			}
+
+			for _, err := range errs {
+				if strings.Contains(err.Error(), test.want) {
+					return
+				}
+			}
+
+			t.Errorf("got errors %v, want one containing %q", errs, test.want)
+		})
+	}
+}
+
+func TestParseModulePrelude(t *testing.T) {
+	src := []byte("name: core\nprelude:\n  core: [clamp]\n  core::types: [int, Numeric]\n")
+
+	mod, errs := parseModuleYAML(src, filepath.FromSlash("/lib/std/core"))
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if got := mod.Prelude["core/types"]; len(got) != 2 || got[0] != "int" || got[1] != "Numeric" {
+		t.Errorf("got `core/types` prelude symbols %v", got)
+	}
+
+	if _, ok := mod.Prelude["core"]; !ok {
+		t.Error("missing `core` prelude package")
+	}
+
+	tests := []struct {
+		name, prelude, want string
+	}{
+		{"foreign package", "other: [x]", "is not in module"},
+		{"invalid package", "core::1x: [x]", "not a valid package name"},
+		{"duplicate package", "core/a: [x]\n  core::a: [y]", "multiple prelude entries"},
+		{"invalid symbol", "core: [\"a b\"]", "not a valid symbol name"},
+		{"duplicate symbol", "core: [x, x]", "listed multiple times"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, errs := parseModuleYAML([]byte("name: core\nprelude:\n  "+test.prelude+"\n"), filepath.FromSlash("/lib/std/core"))
+			for _, err := range errs {
+				if strings.Contains(err.Error(), test.want) {
+					return
+				}
+			}
+
+			t.Errorf("got errors %v, want one containing %q", errs, test.want)
+		})
+	}
+}
+
+func TestParseModuleYAMLUnicode(t *testing.T) {
+	// the names are written with combining characters and are normalized
+	src := []byte("name: gro\u0308ße\ndependencies:\n  cafe\u0301: ../cafe\nprelude:\n  gro\u0308ße: [π]\n")
+
+	mod, errs := parseModuleYAML(src, filepath.FromSlash("/work/größe"))
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if mod.Name != "größe" {
+		t.Errorf("got module name %q, want %q", mod.Name, "größe")
+	}
+
+	if _, ok := mod.Dependencies["café"]; !ok {
+		t.Errorf("got dependencies %v, want `café`", mod.Dependencies)
+	}
+
+	if got := mod.Prelude["größe"]; len(got) != 1 || got[0] != "π" {
+		t.Errorf("got `größe` prelude symbols %v", got)
+	}
+
+	if _, errs := parseModuleYAML([]byte("name: a·b·\ndependencies:\n  ·x: ../x\n"), filepath.FromSlash("/work/x")); len(errs) != 1 {
+		t.Errorf("got errors %v, want an invalid dependency name", errs)
+	}
+}
+
+func TestIsValidPackageName(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		want bool
+	}{
+		{"proj", true},
+		{"größe", true},
+		{"π", true},
+		{"_v2", true},
+		{"", false},
+		{"2d", false},
+		{"my-pkg", false},
+		// the first byte of `é` is not a valid identifier on its own
+		{"\xc3", false},
+	} {
+		if got := IsValidPackageName(c.name); got != c.want {
+			t.Errorf("IsValidPackageName(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -1,15 +1,41 @@
 package mods
 
-import "whirlwind/syntax"
+import (
+	"path/filepath"
+	"strings"
+
+	"whirlwind/syntax"
+)
 
 // Note: Module files are essentially just YAML config files that are used to
 // store all the data that describes the module.  The possible fields of the
 // module file are: `name` (module name - required), `custom_paths` (maps import
 // paths to other paths; used for overriding imports in a module -- optional).
+// The import paths in `custom_paths` are relative to the module (ie. they don't
+// include the module name) and may end in a `*` glob (eg. `net/*: ../forks/net/*`)
+// which matches every package below that path.  `dependencies` (optional) maps
+// the names of other modules to the directories they are located in.  Packages
+// from a dependency are imported using its module name (eg. `import foo::bar`).
+// `cycles` (optional) is the module's policy for import cycles between its
+// packages: `allow` (default), `warn` or `deny`.  `prelude` (optional) maps the
+// import paths of packages in the module (eg. `core::types`) to the symbols
+// that they implicitly export to every file: it is only used for the `core`
+// module in the standard library.  All paths in the module file are relative
+// to the module directory.
 
 // moduleFileName is the name of the module file
 const moduleFileName = "whirl-mod.yml"
 
+// ModuleFilePath returns the path to the module file of the module in the given
+// directory
+func ModuleFilePath(path string) string {
+	return filepath.Join(path, moduleFileName)
+}
+
+// srcFileExtension is the file extension of Whirlwind source files (same as
+// `build.SrcFileExtension` which can't be imported here)
+const srcFileExtension = ".wrl"
+
 // Module represents a single Whirlwind module.  It stores all the data
 // extracted from the module file so that it can be used during compilation
 type Module struct {
@@ -18,22 +44,64 @@ type Module struct {
 	// PathOverrides stores all the custom path overrides in the module (to
 	// replace an import path within the module with a different path)
 	PathOverrides map[string]string
+
+	// GlobOverrides stores all the glob-style custom path overrides in the
+	// module ordered from most to least specific
+	GlobOverrides []*GlobOverride
+
+	// Dependencies maps the names of the modules this module depends on to the
+	// absolute paths of their module directories
+	Dependencies map[string]string
+
+	// CyclePolicy determines how import cycles involving the packages of this
+	// module are handled (see enumeration below)
+	CyclePolicy int
+
+	// Prelude maps the import paths (using `/` as the separator) of the
+	// packages in the module that make up the prelude to the names of the
+	// symbols they export to every file
+	Prelude map[string][]string
+}
+
+// Enumeration of the possible cycle policies of a module
+const (
+	CyclesAllow = iota // Cycles are resolved normally
+	CyclesWarn         // Cycles are resolved but a warning is logged
+	CyclesDeny         // Cycles are compile errors
+)
+
+// GlobOverride is a custom path override that matches all the import paths
+// below a given path (eg. `net/*`)
+type GlobOverride struct {
+	// Prefix is the pattern with the trailing `*` removed (eg. `net/`)
+	Prefix string
+
+	// Target is the absolute path that replaces the prefix
+	Target string
+}
+
+// LookupPathOverride looks up the custom path override for a given import path
+// relative to the module.  Exact overrides take precedence over globs.  The
+// returned path is absolute.
+func (m *Module) LookupPathOverride(modRelPath string) (string, bool) {
+	if pathOverride, ok := m.PathOverrides[modRelPath]; ok {
+		return pathOverride, true
+	}
+
+	for _, glob := range m.GlobOverrides {
+		if len(modRelPath) > len(glob.Prefix) && strings.HasPrefix(modRelPath, glob.Prefix) {
+			return filepath.Join(glob.Target, filepath.FromSlash(modRelPath[len(glob.Prefix):])), true
+		}
+	}
+
+	return "", false
 }
 
 // IsValidPackageName checks if a name is valid for a package (or module).
 // Specifically, this function tests if the name would be a valid (usable)
 // identifier within Whirlwind (as a package must be referenceable by name in
-// the language)
+// the language).  The name should already be normalized using
+// `syntax.NormalizeIdentifier`.
 func IsValidPackageName(name string) bool {
-	if syntax.IsLetter(rune(name[0])) || name[0] == '_' {
-		for i := 1; i < len(name); i++ {
-			if !syntax.IsLetter(rune(name[i])) && !syntax.IsDigit(rune(name[i])) && name[i] != '_' {
-				return false
-			}
-		}
-
-		return true
-	}
-
-	return false
+	return syntax.IsValidIdentifier(name)
 }
@@ -3,9 +3,19 @@ package mods
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+	"whirlwind/syntax"
+
+	"gopkg.in/yaml.v2"
 )
 
+// Del deletes the module file in the current directory.  The rest of the
+// module's files are left untouched.
 func Del() error {
 	finfo, err := os.Stat(moduleFileName)
 
@@ -21,3 +31,432 @@ func Del() error {
 
 	return err
 }
+
+// Rename renames the module in the given directory.  It updates the module
+// file and rewrites all of the imports of the module's own packages in its
+// source files.  It returns the number of source files that were rewritten.
+func Rename(path, newName string) (int, error) {
+	newName = syntax.NormalizeIdentifier(newName)
+	if !IsValidPackageName(newName) {
+		return 0, fmt.Errorf("`%s` is not a valid module name", newName)
+	}
+
+	mod, err := OpenModule(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if mod.Name == newName {
+		return 0, fmt.Errorf("Module is already named `%s`", newName)
+	}
+
+	if err := updateModuleFile(mod, func(schema *moduleSchema) {
+		schema.Name = newName
+	}, func(src []byte) ([]byte, bool) {
+		return setModuleField(src, "", "name", newName)
+	}); err != nil {
+		return 0, err
+	}
+
+	return renameSelfImports(mod.Path, mod.Name, newName)
+}
+
+// AddDependency adds the module located at `depPath` as a dependency of the
+// module in the given directory.  The dependency is named by its module name.
+// It returns the name of the dependency that was added.
+func AddDependency(path, depPath string) (string, error) {
+	mod, err := OpenModule(path)
+	if err != nil {
+		return "", err
+	}
+
+	dep, err := OpenModule(depPath)
+	if err != nil {
+		return "", err
+	}
+
+	if dep.Name == mod.Name {
+		return "", fmt.Errorf("Module `%s` cannot depend on itself", mod.Name)
+	}
+
+	if existingPath, ok := mod.Dependencies[dep.Name]; ok {
+		return "", fmt.Errorf("Module already has a dependency named `%s` (at `%s`)", dep.Name, existingPath)
+	}
+
+	// store the path relative to module so that the module can be moved around
+	// along with its dependencies (eg. in the same repository)
+	relPath, err := filepath.Rel(mod.Path, dep.Path)
+	if err != nil {
+		relPath = dep.Path
+	}
+
+	relPath = filepath.ToSlash(relPath)
+
+	return dep.Name, updateModuleFile(mod, func(schema *moduleSchema) {
+		if schema.Dependencies == nil {
+			schema.Dependencies = make(map[string]string)
+		}
+
+		schema.Dependencies[dep.Name] = relPath
+	}, func(src []byte) ([]byte, bool) {
+		return setModuleField(src, "dependencies", dep.Name, relPath)
+	})
+}
+
+// RemoveDependency removes the dependency with the given name from the module
+// in the given directory
+func RemoveDependency(path, depName string) error {
+	mod, err := OpenModule(path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := mod.Dependencies[depName]; !ok {
+		return fmt.Errorf("Module has no dependency named `%s`", depName)
+	}
+
+	return updateModuleFile(mod, func(schema *moduleSchema) {
+		delete(schema.Dependencies, depName)
+	}, func(src []byte) ([]byte, bool) {
+		return deleteModuleField(src, "dependencies", depName)
+	})
+}
+
+// -----------------------------------------------------------------------------
+
+// updateModuleFile updates the module file of a module.  `update` applies the
+// change to the decoded schema of the module file.  `edit` attempts to apply
+// the same change directly to the source text so that the user's formatting
+// and comments are preserved.  If the edit fails or doesn't produce the same
+// result as `update`, then the whole file is rewritten from the schema instead.
+func updateModuleFile(mod *Module, update func(*moduleSchema), edit func([]byte) ([]byte, bool)) error {
+	modulePath := filepath.Join(mod.Path, moduleFileName)
+
+	src, err := os.ReadFile(modulePath)
+	if err != nil {
+		return err
+	}
+
+	// the module has already been loaded so this should never fail
+	expected, errs := decodeModuleSchema(src)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	update(expected)
+
+	newSrc, ok := edit(src)
+	if ok {
+		if actual, errs := decodeModuleSchema(newSrc); len(errs) > 0 || !schemasEqual(actual, expected) {
+			ok = false
+		}
+	}
+
+	if !ok {
+		newSrc, err = yaml.Marshal(expected)
+		if err != nil {
+			return err
+		}
+	}
+
+	finfo, err := os.Stat(modulePath)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(modulePath, newSrc, finfo.Mode())
+}
+
+// schemasEqual checks if two module schemas are equivalent
+func schemasEqual(a, b *moduleSchema) bool {
+	// empty and nil mappings are considered the same
+	mappingsEqual := func(ma, mb map[string]string) bool {
+		return (len(ma) == 0 && len(mb) == 0) || reflect.DeepEqual(ma, mb)
+	}
+
+	return a.Name == b.Name && a.Cycles == b.Cycles && mappingsEqual(a.CustomPaths, b.CustomPaths) &&
+		mappingsEqual(a.Dependencies, b.Dependencies) && ((len(a.Prelude) == 0 && len(b.Prelude) == 0) || reflect.DeepEqual(a.Prelude, b.Prelude))
+}
+
+// setModuleField sets the value of a field in the source text of a module
+// file.  `parent` and `key` are the same as for `locateKey`.  If the field
+// doesn't exist, it is added.
+func setModuleField(src []byte, parent, key, value string) ([]byte, bool) {
+	lines := strings.Split(string(src), "\n")
+	keyLine, parentLine := locateKey(src, parent, key)
+
+	if keyLine > 0 {
+		line := lines[keyLine-1]
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		// keep any trailing comment on the line (along with its spacing)
+		var comment string
+		if ndx := strings.Index(line, " #"); ndx != -1 {
+			comment = line[len(strings.TrimRight(line[:ndx], " \t")):]
+		}
+
+		lines[keyLine-1] = indent + yamlScalar(key) + ": " + yamlScalar(value) + comment
+	} else if parent == "" {
+		lines = insertLine(lines, 0, yamlScalar(key)+": "+yamlScalar(value))
+	} else if parentLine > 0 {
+		// the parent must be a block mapping for us to insert into it
+		if trimmed := strings.TrimSpace(lines[parentLine-1]); !strings.HasSuffix(trimmed, ":") {
+			return nil, false
+		}
+
+		lines = insertLine(lines, parentLine, "  "+yamlScalar(key)+": "+yamlScalar(value))
+	} else {
+		// make sure we are appending on a new line
+		if lines[len(lines)-1] != "" {
+			lines = append(lines, "")
+		}
+
+		lines[len(lines)-1] = parent + ":"
+		lines = append(lines, "  "+yamlScalar(key)+": "+yamlScalar(value), "")
+	}
+
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// deleteModuleField removes a field from the source text of a module file.  It
+// only handles fields that are contained on a single line.  If the field was
+// the last field in its parent, the parent is removed as well.
+func deleteModuleField(src []byte, parent, key string) ([]byte, bool) {
+	keyLine, parentLine := locateKey(src, parent, key)
+	if keyLine == 0 {
+		return nil, false
+	}
+
+	lines := strings.Split(string(src), "\n")
+	lines = append(lines[:keyLine-1], lines[keyLine:]...)
+
+	if parentLine > 0 {
+		// the parent is empty if the next non-blank line is not indented
+		isEmpty := true
+		for _, line := range lines[parentLine:] {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && trimmed[0] != '#' {
+				isEmpty = line[0] != ' ' && line[0] != '\t'
+				break
+			}
+		}
+
+		if isEmpty {
+			lines = append(lines[:parentLine-1], lines[parentLine:]...)
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// insertLine inserts a line into a slice of lines at the given index
+func insertLine(lines []string, ndx int, line string) []string {
+	lines = append(lines, "")
+	copy(lines[ndx+1:], lines[ndx:])
+	lines[ndx] = line
+	return lines
+}
+
+// yamlScalar converts a string into a YAML scalar (quoting it as necessary)
+func yamlScalar(value string) string {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// -----------------------------------------------------------------------------
+
+// renameSelfImports rewrites all of the imports of a module's own packages in
+// the source files of the module to use the module's new name.  Any
+// subdirectories that are their own modules are skipped.  It returns the number
+// of files that were changed.
+func renameSelfImports(modPath, oldName, newName string) (int, error) {
+	changedCount := 0
+	err := filepath.WalkDir(modPath, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if fpath != modPath {
+				if _, err := os.Stat(filepath.Join(fpath, moduleFileName)); err == nil {
+					return filepath.SkipDir
+				}
+			}
+
+			return nil
+		}
+
+		if filepath.Ext(fpath) != srcFileExtension {
+			return nil
+		}
+
+		src, err := os.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+
+		if newSrc, changed := renameImports(string(src), oldName, newName); changed {
+			finfo, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			changedCount++
+			return os.WriteFile(fpath, []byte(newSrc), finfo.Mode())
+		}
+
+		return nil
+	})
+
+	return changedCount, err
+}
+
+// renameImports renames the module that the packages imported by a source file
+// belong to.  It returns the new source text and whether it changed.
+func renameImports(src, oldName, newName string) (string, bool) {
+	modNames := importModuleNames(src)
+
+	// the names are replaced from last to first so that the offsets of the
+	// names that have yet to be replaced don't change
+	changed := false
+	for i := len(modNames) - 1; i >= 0; i-- {
+		if name := modNames[i]; syntax.NormalizeIdentifier(name.value) == oldName {
+			src = src[:name.start] + newName + src[name.end:]
+			changed = true
+		}
+	}
+
+	return src, changed
+}
+
+// importToken is a token in the import statements of a source file
+type importToken struct {
+	value string
+
+	// start and end are the byte offsets of the token in the source text
+	start, end int
+}
+
+// importModuleNames finds the first segment of the package path of every
+// import statement in a source file: the name of the module the imported
+// package belongs to.  Imports must come before any other code (only comments
+// and metadata tags may precede them) so only the start of the file is read.
+func importModuleNames(src string) []*importToken {
+	sc := &importScanner{src: src, lineStart: true}
+
+	var modNames []*importToken
+	for {
+		tok := sc.next()
+		if tok == nil {
+			return modNames
+		} else if tok.value == "\n" {
+			continue
+		} else if tok.value != "import" {
+			return modNames
+		}
+
+		// an import statement ends at the first newline that is not inside
+		// parentheses: `import (a,\n b) from pkg`
+		var stmt []*importToken
+		depth := 0
+		for tok = sc.next(); tok != nil && (tok.value != "\n" || depth > 0); tok = sc.next() {
+			switch tok.value {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			case "\n":
+				continue
+			}
+
+			stmt = append(stmt, tok)
+		}
+
+		// the package path either comes after `from` (if symbols are being
+		// imported) or directly after `import`
+		pathNdx := 0
+		for i, stmtTok := range stmt {
+			if stmtTok.value == "from" {
+				pathNdx = i + 1
+				break
+			}
+		}
+
+		// a package path of `.` refers to the current package
+		if pathNdx < len(stmt) && stmt[pathNdx].value != "." {
+			modNames = append(modNames, stmt[pathNdx])
+		}
+	}
+}
+
+// importScanner splits the start of a source file into the tokens of its
+// import statements.  Whitespace and comments are skipped.  Newlines are
+// returned as `\n` tokens.
+type importScanner struct {
+	src string
+	pos int
+
+	// lineStart indicates whether only whitespace has been read on the current
+	// line (metadata tags must begin their line)
+	lineStart bool
+}
+
+// next reads the next token.  It returns `nil` at the end of the file.
+func (sc *importScanner) next() *importToken {
+	for sc.pos < len(sc.src) {
+		r, size := utf8.DecodeRuneInString(sc.src[sc.pos:])
+		start := sc.pos
+
+		switch {
+		case r == '\n':
+			sc.pos += size
+			sc.lineStart = true
+			return &importToken{value: "\n", start: start, end: sc.pos}
+		case r == ' ' || r == '\t' || r == '\r':
+			sc.pos += size
+			continue
+		case strings.HasPrefix(sc.src[sc.pos:], "#!"):
+			// block comments may span several lines
+			if end := strings.Index(sc.src[sc.pos+2:], "!#"); end > -1 {
+				sc.pos += end + 4
+			} else {
+				sc.pos = len(sc.src)
+			}
+
+			continue
+		case r == '#' || sc.lineStart && strings.HasPrefix(sc.src[sc.pos:], "!!"):
+			// line comments and metadata tags run until the end of the line
+			if end := strings.IndexByte(sc.src[sc.pos:], '\n'); end > -1 {
+				sc.pos += end
+			} else {
+				sc.pos = len(sc.src)
+			}
+
+			continue
+		}
+
+		sc.lineStart = false
+
+		if syntax.IsIdentStart(r) {
+			for sc.pos < len(sc.src) {
+				r, size := utf8.DecodeRuneInString(sc.src[sc.pos:])
+				if !syntax.IsIdentContinue(r) {
+					break
+				}
+
+				sc.pos += size
+			}
+		} else if strings.HasPrefix(sc.src[sc.pos:], "::") {
+			sc.pos += 2
+		} else {
+			sc.pos += size
+		}
+
+		return &importToken{value: sc.src[start:sc.pos], start: start, end: sc.pos}
+	}
+
+	return nil
+}
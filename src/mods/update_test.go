@@ -0,0 +1,190 @@
+package mods
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles writes a set of files (by slash-separated path) into a
+// directory
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		fpath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTestFile reads a file (by slash-separated path) from a directory
+func readTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestRenameImports(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			"bare import",
+			"import proj\n\nfunc f() -> 1\n",
+			"import renamed\n\nfunc f() -> 1\n",
+		},
+		{
+			"subpackage with rename",
+			"import proj::util as u\n",
+			"import renamed::util as u\n",
+		},
+		{
+			"symbols",
+			"import a, b from proj::util\n",
+			"import a, b from renamed::util\n",
+		},
+		{
+			"multi-line symbols",
+			"import (\n    a,\n    b\n) from proj::util\nimport proj::io\n",
+			"import (\n    a,\n    b\n) from renamed::util\nimport renamed::io\n",
+		},
+		{
+			"comments and metadata",
+			"!! no_util\n# uses proj::util\n#! import proj::x !#\nimport proj::util # proj\n",
+			"!! no_util\n# uses proj::util\n#! import proj::x !#\nimport renamed::util # proj\n",
+		},
+		{
+			"other modules and similar names",
+			"import other::proj\nimport project\nimport proj_x::y\nimport proj from core\n",
+			"import other::proj\nimport project\nimport proj_x::y\nimport proj from core\n",
+		},
+		{
+			"imports only at the top",
+			"import proj::a\n\nfunc f() do\n    import proj::b\n",
+			"import renamed::a\n\nfunc f() do\n    import proj::b\n",
+		},
+		{
+			"current package",
+			"import x from .\nimport proj::a\n",
+			"import x from .\nimport renamed::a\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, changed := renameImports(test.src, "proj", "renamed")
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+
+			if changed != (test.src != test.want) {
+				t.Errorf("got changed = %v", changed)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"whirl-mod.yml":     "# the module\nname: proj\n",
+		"main.wrl":          "import proj::util\nimport (\n    f\n) from proj::util::io\n",
+		"util/util.wrl":     "import proj\n",
+		"dep/whirl-mod.yml": "name: dep\n",
+		"dep/dep.wrl":       "import proj::util\n",
+	})
+
+	changedCount, err := Rename(dir, "renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changedCount != 2 {
+		t.Errorf("got %d changed files, want 2", changedCount)
+	}
+
+	if got, want := readTestFile(t, dir, "whirl-mod.yml"), "# the module\nname: renamed\n"; got != want {
+		t.Errorf("got module file:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got, want := readTestFile(t, dir, "main.wrl"), "import renamed::util\nimport (\n    f\n) from renamed::util::io\n"; got != want {
+		t.Errorf("got main.wrl:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got, want := readTestFile(t, dir, "util/util.wrl"), "import renamed\n"; got != want {
+		t.Errorf("got util.wrl:\n%s\nwant:\n%s", got, want)
+	}
+
+	// nested modules are not part of the module being renamed
+	if got, want := readTestFile(t, dir, "dep/dep.wrl"), "import proj::util\n"; got != want {
+		t.Errorf("got dep.wrl:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := Rename(dir, "renamed"); err == nil {
+		t.Error("expected an error renaming a module to its own name")
+	}
+
+	if _, err := Rename(dir, "1bad"); err == nil {
+		t.Error("expected an error renaming a module to an invalid name")
+	}
+}
+
+func TestAddRemoveDependency(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"proj/whirl-mod.yml":  "name: proj\n",
+		"other/whirl-mod.yml": "name: other\n",
+	})
+
+	projDir := filepath.Join(dir, "proj")
+	depName, err := AddDependency(projDir, filepath.Join(dir, "other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if depName != "other" {
+		t.Errorf("got dependency name `%s`, want `other`", depName)
+	}
+
+	mod, err := OpenModule(projDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := mod.Dependencies["other"], filepath.Join(dir, "other"); got != want {
+		t.Errorf("got dependency path `%s`, want `%s`", got, want)
+	}
+
+	if _, err := AddDependency(projDir, filepath.Join(dir, "other")); err == nil {
+		t.Error("expected an error adding the same dependency twice")
+	}
+
+	if _, err := AddDependency(projDir, projDir); err == nil {
+		t.Error("expected an error adding a module as its own dependency")
+	}
+
+	if err := RemoveDependency(projDir, "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty `dependencies` field is removed along with the dependency
+	if got := readTestFile(t, projDir, "whirl-mod.yml"); strings.Contains(got, "dependencies") || strings.Contains(got, "other") {
+		t.Errorf("dependency not removed from module file:\n%s", got)
+	}
+
+	if err := RemoveDependency(projDir, "other"); err == nil {
+		t.Error("expected an error removing a dependency that does not exist")
+	}
+}
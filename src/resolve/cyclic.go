@@ -2,7 +2,6 @@ package resolve
 
 import (
 	"whirlwind/common"
-	"whirlwind/logging"
 	"whirlwind/syntax"
 	"whirlwind/typing"
 )
@@ -41,7 +40,8 @@ func (r *Resolver) resolveCyclic() bool {
 	opaqueDefs = nil
 
 	// resolve all remaining type definitions (step 3c)
-	for pkgid, pa := range r.assemblers {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
 		for pa.DefQueue.Len() > 0 {
 			// we don't individually care which resolve and which don't
 			r.cyclicResolveDef(pkgid, pa.DefQueue.Peek())
@@ -51,15 +51,16 @@ func (r *Resolver) resolveCyclic() bool {
 
 	// clear the opaque symbol table -- we no longer need them and mark all
 	// walkers as having finished resolution
-	for _, pa := range r.assemblers {
-		for _, walker := range pa.walkers {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
+		for _, walker := range pa.orderedWalkers() {
 			walker.ResolutionDone()
 		}
 	}
 
 	r.sharedOpaqueSymbolTable = nil
 
-	return logging.ShouldProceed()
+	return !r.buffer.HasErrors()
 }
 
 // createAllOpaques creates all of the opaques necessary for cyclic symbol
@@ -78,7 +79,8 @@ func (r *Resolver) createAllOpaques() map[uint]map[string]*OpaqueDefinition {
 	// definitions that resolve something.  This is determined based on whether
 	// or not that definition exists as a dependent to some other definition.
 	// Also populate the resolve list as necessary
-	for pkgid, pa := range r.assemblers {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
 		for i := 0; i < pa.DefQueue.Len(); i++ {
 			def := pa.DefQueue.Peek()
 
@@ -109,12 +111,13 @@ func (r *Resolver) createAllOpaques() map[uint]map[string]*OpaqueDefinition {
 	// and create all our opaque symbols as necessary.  Also dequeue any
 	// definitions that are made into opaque symbols so we know not to try to
 	// resolve them again in step 3c
-	for pkgid, pa := range r.assemblers {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
 		// mark stores the initial non-opaque definition we encounter so that
 		// we know when we hit it again to stop processing this queue
 		var mark *Definition
 
-		for mark != pa.DefQueue.Peek() {
+		for pa.DefQueue.Len() > 0 && mark != pa.DefQueue.Peek() {
 			def := pa.DefQueue.Peek()
 			if odef, ok := opaqueDefs[pkgid][def.Name]; ok {
 				odef.Def = def
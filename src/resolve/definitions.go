@@ -97,3 +97,17 @@ func (dq *DefinitionQueue) Rotate() {
 func (dq *DefinitionQueue) Len() int {
 	return dq.len
 }
+
+// Names returns the names of all the definitions in the queue in order
+func (dq *DefinitionQueue) Names() []string {
+	names := make([]string, 0, dq.len)
+
+	// the queue may be circular after rotations so we go by its length
+	node := dq.start
+	for i := 0; i < dq.len; i++ {
+		names = append(names, node.Value.Name)
+		node = node.Next
+	}
+
+	return names
+}
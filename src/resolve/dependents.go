@@ -262,8 +262,8 @@ func (se *SymbolExtractor) extractFromTypeLabelCore(labelCore *syntax.ASTBranch)
 		if accessedName == "" {
 			se.addDependent(rootName, rootPos)
 		} else {
-			for _, wimport := range se.srcpkg.ImportTable {
-				if wimport.PackageRef.Name == accessedName {
+			for _, id := range se.srcpkg.SortedImportIDs() {
+				if wimport := se.srcpkg.ImportTable[id]; wimport.PackageRef.Name == accessedName {
 					se.dependents[accessedName] = &DependentSymbol{
 						Name:           accessedName,
 						Position:       accessedPos,
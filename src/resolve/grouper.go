@@ -1,7 +1,15 @@
 package resolve
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/mods"
+	"whirlwind/typing"
 	"whirlwind/validate"
 )
 
@@ -10,15 +18,16 @@ import (
 // Definitions/Rules:
 // - A `cycle root` is a package that is awaiting resolution and encountered
 //   as a dependency of another package during grouping.
-// - A group/unit that is considered a `resolution unit` is immediately resolved
-//   and the unit is to be cleared.
-// - Any group that fails to resolve is grounds for immediate failure of grouping.
+// - A group/unit that is considered a `resolution unit` is immediately added
+//   to the list of resolution units and the unit is to be cleared.
+// - Any group that violates the cycle policy of its modules is grounds for
+//   immediate failure of grouping.
 // Algorithm:
 // 1. Start at the root package.
-// 2. From the current package, evaluate all of its connections.
-// 3. If all of its connections resolve, consider the current package
+// 2. From the current package, evaluate all of its connections (in order).
+// 3. If all of its connections are grouped, consider the current package
 //    its own discrete group.
-// 4. If one or more of its connections don't fully resolve, then:
+// 4. If one or more of its connections aren't fully grouped, then:
 //    i. If one cycle root is determined, then
 //       a. If the current package is the cycle root, consider all
 //          packages in the current unit + the current package a
@@ -31,10 +40,21 @@ import (
 //       b. Otherwise, add the current package to the current unit
 //          and return.
 //
+// Resolution Scheduling
+// ---------------------
+// The resolution units form a DAG: a unit depends on every other unit that
+// contains a package imported by one of its packages.  Since the units are
+// formed depth-first, every unit comes after all of the units it depends on.
+// Any unit whose dependencies have all been resolved is resolved immediately
+// (concurrently with any other such units).  If a unit fails to resolve, none
+// of the units that depend on it (directly or indirectly) are resolved since
+// their failures would be meaningless.  The messages logged during resolution
+// are buffered per unit and logged in the order the units were formed once all
+// resolution has finished so that the output is deterministic.
 
 // Grouper is responsible for analyzing the package graph and determining the
-// resolution units to be passed to the resolver.  The grouper also passes the
-// groups it creates to the resolver as it creates them.
+// resolution units to be passed to the resolver.  The grouper also schedules
+// the resolution of the units it creates.
 type Grouper struct {
 	// rootPackage is the package from which compilation began (for applications
 	// this the main package -- the package to be built into an executable).
@@ -43,59 +63,201 @@ type Grouper struct {
 	// depGraph is the dependency graph constructed by the compiler to be analyzed
 	depGraph map[uint]*common.WhirlPackage
 
+	// packagePaths is the table of the import paths of the packages in the
+	// dependency graph used to qualify types in diagnostics
+	packagePaths typing.PackagePaths
+
 	// validators is a map of predicate validators to populated during grouping
 	validators map[uint]*validate.PredicateValidator
 
-	// resolutionStatuses stores the IDs of the packages that have a known
-	// resolution status.  A false value indicates that a package has yet to be
-	// resolved a true value indicates that it has already been resolved.
-	resolutionStatuses map[uint]bool
+	// jobs is the maximum number of units that can be resolved at once
+	jobs int
+
+	// groupStatuses stores the IDs of the packages that have a known grouping
+	// status.  A false value indicates that a package has yet to be grouped a
+	// true value indicates that it has already been grouped.
+	groupStatuses map[uint]bool
 
 	// currentUnit is the resolution unit being constructed
 	currentUnit map[uint]*common.WhirlPackage
+
+	// units is the list of resolution units that have been formed in the order
+	// they were formed.  The packages of each unit are sorted by their root
+	// directories.
+	units [][]*common.WhirlPackage
+
+	// enforceCycles indicates whether or not the cycle policies of modules
+	// should be checked as multi-package resolution units are formed
+	enforceCycles bool
 }
 
-// NewGrouper creates a new grouper for the given dep-g and root package
-func NewGrouper(rootpkg *common.WhirlPackage, depg map[uint]*common.WhirlPackage, pvs map[uint]*validate.PredicateValidator) *Grouper {
+// NewGrouper creates a new grouper for the given dep-g and root package.  At
+// most `jobs` resolution units will be resolved at once.
+func NewGrouper(rootpkg *common.WhirlPackage, depg map[uint]*common.WhirlPackage, pp typing.PackagePaths, pvs map[uint]*validate.PredicateValidator, jobs int) *Grouper {
 	return &Grouper{
-		rootPackage:        rootpkg,
-		depGraph:           depg,
-		resolutionStatuses: make(map[uint]bool),
-		currentUnit:        make(map[uint]*common.WhirlPackage),
-		validators:         pvs,
+		rootPackage:   rootpkg,
+		depGraph:      depg,
+		packagePaths:  pp,
+		groupStatuses: make(map[uint]bool),
+		currentUnit:   make(map[uint]*common.WhirlPackage),
+		validators:    pvs,
+		jobs:          jobs,
+		enforceCycles: true,
 	}
 }
 
 // ResolveAll runs the grouping algorithm starting from the root package and
-// resolves all the groups as it arranges them.
+// then resolves all of the groups (see the scheduling described above).
 func (g *Grouper) ResolveAll() bool {
-	_, ok := g.groupFrom(g.rootPackage)
+	if !g.groupFrom(g.rootPackage) {
+		return false
+	}
+
+	// unitIndices maps each package to the index of its unit
+	unitIndices := make(map[uint]int)
+	for i, unit := range g.units {
+		for _, pkg := range unit {
+			unitIndices[pkg.PackageID] = i
+		}
+	}
+
+	// pending stores the number of dependencies each unit is waiting on, and
+	// dependents stores the indices of the units that depend on each unit
+	pending := make([]int, len(g.units))
+	dependents := make([][]int, len(g.units))
+	for i, unit := range g.units {
+		deps := make(map[int]struct{})
+		for _, pkg := range unit {
+			for id := range pkg.ImportTable {
+				if j := unitIndices[id]; j != i {
+					deps[j] = struct{}{}
+				}
+			}
+		}
+
+		pending[i] = len(deps)
+		for j := range deps {
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	// the resolvers are created ahead of time since creating them updates the
+	// packages' files and the shared validators map
+	resolvers := make([]*Resolver, len(g.units))
+	for i, unit := range g.units {
+		resolvers[i] = NewResolver(unit, g.depGraph, g.packagePaths)
+		resolvers[i].CreateValidators(g.validators)
+	}
+
+	type unitResult struct {
+		index int
+		ok    bool
+	}
+
+	results := make(chan unitResult)
+	wg := sync.WaitGroup{}
+
+	var ready []int
+	for i, n := range pending {
+		if n == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	// skipped marks the units that won't be resolved because one of their
+	// dependencies failed to resolve
+	skipped := make([]bool, len(g.units))
+	var skip func(i int)
+	skip = func(i int) {
+		for _, j := range dependents[i] {
+			if !skipped[j] {
+				skipped[j] = true
+				skip(j)
+			}
+		}
+	}
+
+	allResolved := true
+	for running := 0; len(ready) > 0 || running > 0; {
+		// start as many ready units as we are allowed to
+		for ; len(ready) > 0 && running < g.jobs; running++ {
+			i := ready[0]
+			ready = ready[1:]
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- unitResult{index: i, ok: resolvers[i].Resolve()}
+			}()
+		}
+
+		result := <-results
+		running--
+
+		if !result.ok {
+			allResolved = false
+			skip(result.index)
+			continue
+		}
+
+		for _, j := range dependents[result.index] {
+			pending[j]--
+
+			if pending[j] == 0 && !skipped[j] {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	wg.Wait()
+
+	// log the messages of all the units in order
+	for _, r := range resolvers {
+		r.FlushLog()
+	}
+
+	return allResolved
+}
+
+// GroupAll runs the grouping algorithm starting from the root package without
+// resolving any of the groups.  It returns all of the resolution units in the
+// order they would be resolved in if they were resolved one at a time.  The
+// packages of each unit are sorted by their root directories.
+func (g *Grouper) GroupAll() [][]*common.WhirlPackage {
+	// we are only reporting the groups so cycles are not errors here
+	g.enforceCycles = false
+
+	g.groupFrom(g.rootPackage)
+	return g.units
+}
+
+// groupFrom contains the main grouping algorithm (as described above) starting
+// from the given package.  It returns a boolean indicating if the grouping was
+// successful (the cycle roots are only tracked by `groupPackage`).  Grouping
+// only fails if a unit violates a cycle policy in which case we unwind
+// immediately without any further grouping.
+func (g *Grouper) groupFrom(pkg *common.WhirlPackage) bool {
+	_, ok := g.groupPackage(pkg)
 	return ok
 }
 
-// groupFrom contains the main grouping and resolution algorithm (as described
-// above). The return values from this function are: the cycle roots -- this
-// will be `nil` if there is no cycle root (we are resolving linearly) and a
-// boolean indicating if the resolution of the previous groups was successful.
-// Since this function works recursively, any failure in resolution will more
-// often than not cause issues with every other package above it that depends on
-// it.  Therefore, in order to prevent a cascade of meaningless failures, we
-// consider resolution failure a base case for recursion -- prompting an
-// immediate unwind without any further grouping or analysis.
-func (g *Grouper) groupFrom(pkg *common.WhirlPackage) (map[uint]struct{}, bool) {
-	// mark the current package as awaiting resolution
-	g.resolutionStatuses[pkg.PackageID] = false
+// groupPackage groups a single package and (recursively) all of the packages
+// it depends on.  It returns the cycle roots that the package is a part of and
+// whether or not grouping succeeded.
+func (g *Grouper) groupPackage(pkg *common.WhirlPackage) (map[uint]struct{}, bool) {
+	// mark the current package as awaiting grouping
+	g.groupStatuses[pkg.PackageID] = false
 
 	// store the accumulated cycle roots
 	roots := make(map[uint]struct{})
-	for id := range pkg.ImportTable {
-		// if the package has a known status and has not already been resolved,
+	for _, id := range pkg.SortedImportIDs() {
+		// if the package has a known status and has not already been grouped,
 		// then we consider that package to be a cycle root.
-		if status, ok := g.resolutionStatuses[id]; ok {
+		if status, ok := g.groupStatuses[id]; ok {
 			if !status {
 				roots[id] = struct{}{}
 			}
-		} else if nroots, ok := g.groupFrom(g.depGraph[id]); ok {
+		} else if nroots, ok := g.groupPackage(g.depGraph[id]); ok {
 			// if the package is unknown (ungrouped), then we explore its path
 			// and if it is groupable, we add its new roots to our current cycle
 			// roots
@@ -103,8 +265,7 @@ func (g *Grouper) groupFrom(pkg *common.WhirlPackage) (map[uint]struct{}, bool)
 				roots[nroot] = struct{}{}
 			}
 		} else {
-			// if the package has no status and fails to group, then is has
-			// failed resolution and we need to fail immediately
+			// if the package fails to group, then we need to fail immediately
 			return nil, false
 		}
 	}
@@ -112,19 +273,23 @@ func (g *Grouper) groupFrom(pkg *common.WhirlPackage) (map[uint]struct{}, bool)
 	switch len(roots) {
 	case 0:
 		// if we have no cycle roots, then we can consider the current single package
-		// its own distinct resolution unit and resolve it accordingly
-		rok := g.resolveSingle(pkg)
-		return nil, rok
+		// its own distinct resolution unit
+		g.addSingleUnit(pkg)
+		return nil, true
 	case 1:
 		// if there is only one cycle root, we add the current package to the
 		// current unit (happens in either case) and then we check if the
-		// current package is the cycle root and if it is, we resolve the whole
+		// current package is the cycle root and if it is, we add the whole
 		// unit and clear it.  Otherwise, we return.
 		g.currentUnit[pkg.PackageID] = pkg
 
 		if _, ok := roots[pkg.PackageID]; ok {
-			rok := g.resolveCurrentUnit()
-			return nil, rok
+			if !g.checkCyclePolicy() {
+				return nil, false
+			}
+
+			g.addCurrentUnit()
+			return nil, true
 		}
 
 		return roots, true
@@ -139,50 +304,196 @@ func (g *Grouper) groupFrom(pkg *common.WhirlPackage) (map[uint]struct{}, bool)
 			}
 		}
 
-		// if all the cycle roots are in the current unit, then we resolve and
-		// clear the current unit, and then resolve the current package
+		// if all the cycle roots are in the current unit, then we add and
+		// clear the current unit, and then add the current package
 		// independently.
-		rok := g.resolveCurrentUnit()
-
-		if !rok {
+		if !g.checkCyclePolicy() {
 			return nil, false
 		}
 
-		rok = g.resolveSingle(pkg)
-		return nil, rok
+		g.addCurrentUnit()
+		g.addSingleUnit(pkg)
+		return nil, true
 	}
 }
 
-// resolveSingle resolves a single package as a distinct resolution unit
-func (g *Grouper) resolveSingle(pkg *common.WhirlPackage) bool {
-	// whether or not resolution succeeds is unimportant.  If it fails, the
-	// statuses won't matter anyway
-	g.resolutionStatuses[pkg.PackageID] = true
-
-	r := NewResolver([]*common.WhirlPackage{pkg}, g.depGraph)
-	r.CreateValidators(g.validators)
-	return r.Resolve()
+// addSingleUnit adds a single package as a distinct resolution unit
+func (g *Grouper) addSingleUnit(pkg *common.WhirlPackage) {
+	g.groupStatuses[pkg.PackageID] = true
+	g.units = append(g.units, []*common.WhirlPackage{pkg})
 }
 
-// resolveCurrentUnit resolves the current unit as one resolution unit and
-// clears the current unit.
-func (g *Grouper) resolveCurrentUnit() bool {
-	// whether or not resolution succeeds is unimportant.  If it fails, the
-	// statuses won't matter anyway -- mark every package as resolved,
-	// create the resolution unit, and clear the current unit in one pass
-	runit := make([]*common.WhirlPackage, len(g.currentUnit))
-
-	n := 0
+// addCurrentUnit adds the current unit as one resolution unit and clears the
+// current unit.
+func (g *Grouper) addCurrentUnit() {
+	// mark every package as grouped, create the resolution unit, and clear the
+	// current unit in one pass
+	runit := make([]*common.WhirlPackage, 0, len(g.currentUnit))
 	for id, pkg := range g.currentUnit {
-		g.resolutionStatuses[id] = true
-		runit[n] = pkg
-		n++
+		g.groupStatuses[id] = true
+		runit = append(runit, pkg)
 
 		delete(g.currentUnit, id)
 	}
 
-	// resolve the current unit and return the status
-	r := NewResolver(runit, g.depGraph)
-	r.CreateValidators(g.validators)
-	return r.Resolve()
+	sort.Slice(runit, func(i, j int) bool {
+		return runit[i].RootDirectory < runit[j].RootDirectory
+	})
+
+	g.units = append(g.units, runit)
+}
+
+// checkCyclePolicy checks the current unit against the cycle policies of the
+// modules of its packages.  Any unit containing more than one package is an
+// import cycle.  The strictest policy of all the modules involved is applied.
+// Precompiled packages (from the build cache or interface files) are checked
+// using their import tables but have no import sites to report.  If the cycle
+// is denied, an error is logged and false is returned.
+func (g *Grouper) checkCyclePolicy() bool {
+	if !g.enforceCycles || len(g.currentUnit) < 2 {
+		return true
+	}
+
+	// sort the unit so that we always report the same cycle
+	runit := make([]*common.WhirlPackage, 0, len(g.currentUnit))
+	for _, pkg := range g.currentUnit {
+		runit = append(runit, pkg)
+	}
+
+	sort.Slice(runit, func(i, j int) bool {
+		return runit[i].RootDirectory < runit[j].RootDirectory
+	})
+
+	// the cycle is reported starting from the first package that belongs to
+	// the module with the strictest policy
+	var start *common.WhirlPackage
+	policy := mods.CyclesAllow
+	for _, pkg := range runit {
+		if pkg.ParentModule != nil && pkg.ParentModule.CyclePolicy > policy {
+			start = pkg
+			policy = pkg.ParentModule.CyclePolicy
+		}
+	}
+
+	if policy == mods.CyclesAllow {
+		return true
+	}
+
+	path := g.findCyclePath(start)
+
+	names := make([]string, len(path))
+	for i, pkg := range path {
+		names[i] = "`" + pkg.ImportPath + "`"
+	}
+
+	message := fmt.Sprintf("Import cycle %s -> `%s` ", strings.Join(names, " -> "), start.ImportPath)
+	if policy == mods.CyclesDeny {
+		message += fmt.Sprintf("is not allowed in module `%s`", start.ParentModule.Name)
+	} else {
+		message += fmt.Sprintf("in module `%s`", start.ParentModule.Name)
+	}
+
+	// each import in the cycle is listed in a note.  The message is reported
+	// at the first import of the cycle if it has a position: otherwise, it is
+	// just reported for the directory of the first package.
+	lctx := &logging.LogContext{PackageID: start.PackageID, FilePath: start.RootDirectory}
+	var pos *logging.TextPosition
+	var notes []logging.Annotation
+	for i, pkg := range path {
+		next := start
+		if i < len(path)-1 {
+			next = path[i+1]
+		}
+
+		note := fmt.Sprintf("`%s` imports `%s`", pkg.ImportPath, next.ImportPath)
+
+		site := firstImportSite(pkg.ImportTable[next.PackageID])
+		switch {
+		case site == nil:
+			// the import was not created by an import statement or its
+			// package was precompiled
+		case site.Position == nil:
+			note += " (prelude)"
+		default:
+			note += " at " + logging.FormatPosition(site.FilePath, site.Position)
+
+			if i == 0 {
+				lctx.FilePath = site.FilePath
+				pos = site.Position
+			}
+		}
+
+		notes = append(notes, logging.WithNote(note))
+	}
+
+	if policy == mods.CyclesDeny {
+		logging.LogCompileError(lctx, message, logging.LMKImport, pos, notes...)
+		return false
+	}
+
+	logging.LogCompileWarning(lctx, message, logging.LMKImport, pos, notes...)
+	return true
+}
+
+// findCyclePath finds the shortest import cycle in the current unit beginning
+// and ending at the given package.  The returned path begins with the start
+// package and does not repeat it at the end.
+func (g *Grouper) findCyclePath(start *common.WhirlPackage) []*common.WhirlPackage {
+	// breadth-first search back to the start package: `prev` stores the package
+	// each package was reached from
+	prev := make(map[uint]*common.WhirlPackage)
+	queue := []*common.WhirlPackage{start}
+
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+
+		for _, id := range pkg.SortedImportIDs() {
+			if id == start.PackageID {
+				// walk back along the path to the start package
+				path := []*common.WhirlPackage{pkg}
+				for p := pkg; p != start; p = prev[p.PackageID] {
+					path = append([]*common.WhirlPackage{prev[p.PackageID]}, path...)
+				}
+
+				return path
+			}
+
+			if npkg, ok := g.currentUnit[id]; ok {
+				if _, visited := prev[id]; !visited {
+					prev[id] = pkg
+					queue = append(queue, npkg)
+				}
+			}
+		}
+	}
+
+	// every package in a multi-package unit is part of a cycle so this should
+	// never happen
+	return []*common.WhirlPackage{start}
+}
+
+// firstImportSite returns the import site of an import that occurs first (by
+// file path and then by position).  Explicit imports are preferred over
+// implicit ones.  If the import has no sites, `nil` is returned.
+func firstImportSite(wimport *common.WhirlImport) *common.ImportSite {
+	var first *common.ImportSite
+	if wimport == nil {
+		return nil
+	}
+
+	for _, site := range wimport.Sites {
+		switch {
+		case first == nil:
+			first = site
+		case site.Position == nil:
+			continue
+		case first.Position == nil, site.FilePath < first.FilePath:
+			first = site
+		case site.FilePath == first.FilePath && site.Position.StartLn < first.Position.StartLn:
+			first = site
+		}
+	}
+
+	return first
 }
@@ -0,0 +1,106 @@
+package resolve
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"whirlwind/common"
+	"whirlwind/logging"
+	"whirlwind/mods"
+)
+
+// captureStdout returns everything written to stdout while running a function
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	f()
+
+	w.Close()
+	return <-output
+}
+
+// newCyclePackages creates two packages, `a` and `b`, in a module with the
+// given cycle policy that import each other.  `a` imports `b` by an import
+// statement and `b` imports `a` without any import sites.
+func newCyclePackages(t *testing.T, policy int) (*common.WhirlPackage, map[uint]*common.WhirlPackage) {
+	t.Helper()
+
+	dir := t.TempDir()
+	mod := &mods.Module{Name: "proj", Path: dir, CyclePolicy: policy}
+
+	apath := filepath.Join(dir, "a", "a.wrl")
+	if err := os.MkdirAll(filepath.Dir(apath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(apath, []byte("import proj::b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &common.WhirlPackage{PackageID: 1, Name: "a", ImportPath: "proj::a", RootDirectory: filepath.Dir(apath), ParentModule: mod}
+	b := &common.WhirlPackage{PackageID: 2, Name: "b", ImportPath: "proj::b", RootDirectory: filepath.Join(dir, "b"), ParentModule: mod}
+
+	a.ImportTable = map[uint]*common.WhirlImport{
+		b.PackageID: {PackageRef: b, Sites: []*common.ImportSite{
+			{FilePath: apath, Position: &logging.TextPosition{StartLn: 1, StartCol: 7, EndLn: 1, EndCol: 14}},
+		}},
+	}
+
+	b.ImportTable = map[uint]*common.WhirlImport{a.PackageID: {PackageRef: a}}
+
+	logging.Initialize(dir, "error")
+	return a, map[uint]*common.WhirlPackage{a.PackageID: a, b.PackageID: b}
+}
+
+func TestCyclePolicyDeny(t *testing.T) {
+	a, depg := newCyclePackages(t, mods.CyclesDeny)
+
+	var ok bool
+	output := captureStdout(t, func() {
+		ok = NewGrouper(a, depg, nil, nil, 1).ResolveAll()
+		logging.LogStageEnd()
+	})
+
+	if ok {
+		t.Fatal("expected the cycle to be denied")
+	}
+
+	for _, want := range []string{
+		"Import cycle `proj::a` -> `proj::b` -> `proj::a` is not allowed in module `proj`\n",
+		"--> " + filepath.Join("a", "a.wrl") + ":1:8\n",
+		"note: `proj::a` imports `proj::b` at " + filepath.Join("a", "a.wrl") + ":1:8\n",
+		"note: `proj::b` imports `proj::a`\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestCyclePolicyGroupAll(t *testing.T) {
+	a, depg := newCyclePackages(t, mods.CyclesDeny)
+
+	// cycles are never errors when the groups are only being reported
+	units := NewGrouper(a, depg, nil, nil, 1).GroupAll()
+	if len(units) != 1 || len(units[0]) != 2 {
+		t.Errorf("got %d units, want one unit of `a` and `b`", len(units))
+	}
+}
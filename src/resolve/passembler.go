@@ -2,9 +2,11 @@ package resolve
 
 import (
 	"fmt"
+	"sort"
 	"whirlwind/common"
 	"whirlwind/logging"
 	"whirlwind/syntax"
+	"whirlwind/typing"
 	"whirlwind/validate"
 )
 
@@ -20,13 +22,17 @@ type PAssembler struct {
 	// walkers stores all the file-specific definition walkers for this package
 	walkers map[*common.WhirlFile]*validate.Walker
 
+	// files stores the files of the package sorted by their paths so that they
+	// are always processed in the same order
+	files []*common.WhirlFile
+
 	// handledImportedSymbols is used to make sure errors for misimported
 	// symbols are not logged multiple times -- keep the output clean
 	handledImportedSymbols map[string]struct{}
 }
 
 // NewPAssembler creates a new package assembler for the given package
-func NewPAssembler(srcpkg *common.WhirlPackage, ost common.OpaqueSymbolTable) *PAssembler {
+func NewPAssembler(srcpkg *common.WhirlPackage, ost common.OpaqueSymbolTable, pp typing.PackagePaths) *PAssembler {
 	pa := &PAssembler{
 		SrcPackage:             srcpkg,
 		DefQueue:               &DefinitionQueue{},
@@ -34,20 +40,32 @@ func NewPAssembler(srcpkg *common.WhirlPackage, ost common.OpaqueSymbolTable) *P
 		walkers:                make(map[*common.WhirlFile]*validate.Walker),
 	}
 
-	for fpath, wfile := range srcpkg.Files {
-		pa.walkers[wfile] = validate.NewWalker(srcpkg, wfile, fpath, ost)
+	for _, fpath := range srcpkg.SortedFilePaths() {
+		wfile := srcpkg.Files[fpath]
+		pa.walkers[wfile] = validate.NewWalker(srcpkg, wfile, fpath, ost, pp)
+		pa.files = append(pa.files, wfile)
 	}
 
 	return pa
 }
 
+// orderedWalkers returns the walkers of the package in the order of their files
+func (pa *PAssembler) orderedWalkers() []*validate.Walker {
+	walkers := make([]*validate.Walker, len(pa.files))
+	for i, wfile := range pa.files {
+		walkers[i] = pa.walkers[wfile]
+	}
+
+	return walkers
+}
+
 // initialPass performs stage 1 of the resolution algorithm.  It traverses each
 // file in the package and extracts all determinate definitions that depend on
 // unknown values.  All other definitions it resolves immediately. It also
 // returns a value indicating whether or not the later stages of resolution need
 // to occu based solely on its analysis of its package
 func (pa *PAssembler) initialPass() bool {
-	for wfile := range pa.walkers {
+	for _, wfile := range pa.files {
 		// import processing should already have been run so the only things that
 		// remain should be `top_level` and `export_block`
 		for _, item := range wfile.AST.Content {
@@ -109,7 +127,7 @@ func (pa *PAssembler) initialPassOnBlock(wfile *common.WhirlFile, block *syntax.
 // them if possible; it does not indicate whether or not all definitions
 // resolved successfully.
 func (pa *PAssembler) finalPass() {
-	for wfile := range pa.walkers {
+	for _, wfile := range pa.files {
 		for _, item := range wfile.AST.Content {
 			block := item.(*syntax.ASTBranch)
 			if block.Name == "export_block" {
@@ -150,8 +168,18 @@ func (pa *PAssembler) finalPassOnBlock(wfile *common.WhirlFile, block *syntax.AS
 
 // checkImports checks if all the explicitly imported symbols of this package resolved
 func (pa *PAssembler) checkImports() {
-	for _, walker := range pa.walkers {
-		for name, wsi := range walker.SrcFile.LocalTable {
+	for _, walker := range pa.orderedWalkers() {
+		// symbols imported by namespace imports have no positions so we sort
+		// the names to keep the order of their messages consistent
+		names := make([]string, 0, len(walker.SrcFile.LocalTable))
+		for name := range walker.SrcFile.LocalTable {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			wsi := walker.SrcFile.LocalTable[name]
 			if wsi.SymbolRef.Name == "" {
 				// if it is still empty here, then it may be unresolveable
 				// or it may simply have never been used.  We test to
@@ -216,6 +244,8 @@ func (pa *PAssembler) logUnresolved(wfile *common.WhirlFile, dep *DependentSymbo
 			pa.handledImportedSymbols[dep.Name] = struct{}{}
 		}
 	} else {
-		w.LogUndefined(dep.Name, dep.Position)
+		// the definitions that haven't been resolved are also candidates for
+		// suggestions since they are not in the global table yet
+		w.LogUndefined(dep.Name, dep.Position, pa.DefQueue.Names()...)
 	}
 }
@@ -3,6 +3,7 @@ package resolve
 import (
 	"whirlwind/common"
 	"whirlwind/logging"
+	"whirlwind/typing"
 	"whirlwind/validate"
 )
 
@@ -58,55 +59,86 @@ type Resolver struct {
 	// prototypes but not actually fully defined.  They are used to facilitate
 	// cyclic dependency resolution.
 	sharedOpaqueSymbolTable common.OpaqueSymbolTable
+
+	// buffer collects all the messages logged during resolution so that they
+	// can be logged in a deterministic order (units are resolved concurrently)
+	buffer *logging.LogBuffer
+
+	// pkgIDs stores the IDs of the packages being resolved in the order they
+	// were given so that the assemblers are always processed in the same order
+	pkgIDs []uint
 }
 
 // NewResolver creates a new resolver for the given set of packages
-func NewResolver(pkgs []*common.WhirlPackage, depg map[uint]*common.WhirlPackage) *Resolver {
+func NewResolver(pkgs []*common.WhirlPackage, depg map[uint]*common.WhirlPackage, pp typing.PackagePaths) *Resolver {
 	r := &Resolver{
 		assemblers:              make(map[uint]*PAssembler),
 		depGraph:                depg,
 		sharedOpaqueSymbolTable: make(common.OpaqueSymbolTable),
+		buffer:                  logging.NewLogBuffer(),
 	}
 
 	for _, pkg := range pkgs {
-		r.assemblers[pkg.PackageID] = NewPAssembler(pkg, r.sharedOpaqueSymbolTable)
+		pa := NewPAssembler(pkg, r.sharedOpaqueSymbolTable, pp)
+		for _, walker := range pa.orderedWalkers() {
+			walker.Context.Buffer = r.buffer
+		}
+
+		r.assemblers[pkg.PackageID] = pa
+		r.pkgIDs = append(r.pkgIDs, pkg.PackageID)
 		r.sharedOpaqueSymbolTable[pkg.PackageID] = make(map[string]*common.OpaqueSymbol)
 	}
 
 	return r
 }
 
+// FlushLog logs all of the messages that were logged during resolution.  The
+// messages logged by the walkers after this is called are logged immediately.
+func (r *Resolver) FlushLog() {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
+		for _, walker := range pa.orderedWalkers() {
+			walker.Context.Buffer = nil
+		}
+	}
+
+	r.buffer.Flush()
+}
+
 // Resolve runs the main resolution algorithm on all the packages in resolution
 func (r *Resolver) Resolve() bool {
 	allResolved := true
-	for _, pa := range r.assemblers {
-		allResolved = allResolved && pa.initialPass()
-	}
-
-	if allResolved {
-		return true
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
+		// every package needs an initial pass even if an earlier package has
+		// definitions left to resolve
+		if !pa.initialPass() {
+			allResolved = false
+		}
 	}
 
-	// if standard resolution works, then we can just skip cyclic
-	if r.resolveStandard() {
+	// if standard resolution works (or there was nothing left for it to do
+	// after the initial pass), then we can just skip cyclic
+	if allResolved || r.resolveStandard() {
 		// make sure to resolve all the other definitions
 		r.resolveRemaining()
 
 		// mark all walkers as having finished resolution
-		for _, pa := range r.assemblers {
-			for _, walker := range pa.walkers {
+		for _, pkgid := range r.pkgIDs {
+			pa := r.assemblers[pkgid]
+			for _, walker := range pa.orderedWalkers() {
 				walker.ResolutionDone()
 			}
 		}
 
-		return logging.ShouldProceed()
+		return !r.buffer.HasErrors()
 	}
 
 	// standard resolution failed, need to use cyclic resolution
 	if r.resolveCyclic() {
 		// make sure to resolve all the other definitions
 		r.resolveRemaining()
-		return logging.ShouldProceed()
+		return !r.buffer.HasErrors()
 	}
 
 	// cyclic resolution failed; return
@@ -117,8 +149,9 @@ func (r *Resolver) Resolve() bool {
 // of the package assemblers.  This can be called before `Resolve` since all the
 // package assemblers (and therefore walkers) will already have been created
 func (r *Resolver) CreateValidators(pvs map[uint]*validate.PredicateValidator) {
-	for pkgid, pa := range r.assemblers {
-		pvs[pkgid] = validate.NewPredicateValidator(pa.walkers)
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
+		pvs[pkgid] = validate.NewPredicateValidator(pa.orderedWalkers())
 	}
 }
 
@@ -133,7 +166,8 @@ func (r *Resolver) resolveStandard() bool {
 	// resolution, we can't just use a list to store them and slice off the
 	// front for each one we process.
 	unprocessedQueues := make(map[uint]*DefinitionQueue)
-	for _, pa := range r.assemblers {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
 		if pa.DefQueue.Len() > 0 {
 			unprocessedQueues[pa.SrcPackage.PackageID] = pa.DefQueue
 		}
@@ -144,9 +178,11 @@ func (r *Resolver) resolveStandard() bool {
 		return true
 	}
 
-	// symbolsDefined is a flag used to indicate that some number of symbols were
-	// defined in between the mark being set and being encountered again
-	symbolsDefined := false
+	// definedCount is the number of symbols defined so far during this pass.
+	// It is compared against the count recorded when a mark was set to
+	// determine whether any symbols were defined in between the mark being set
+	// and being encountered again
+	definedCount := 0
 
 	// resolutionSucceeded is a flag used to indicate whether or not resolution
 	// succeeded -- we have to set a flag instead of just returning so that we
@@ -161,12 +197,15 @@ func (r *Resolver) resolveStandard() bool {
 
 	// nextQueue automatically fetches the next available queue for processing
 	// from `unprocessedQueues` and stores it into `currQueue` (and updated
-	// `currPkgID`).  It returns `false` if no queues remain.
+	// `currPkgID`).  The queues are fetched in package order.  It returns
+	// `false` if no queues remain.
 	nextQueue := func() bool {
-		for pid, queue := range unprocessedQueues {
-			currPkgID = pid
-			currQueue = queue
-			return true
+		for _, pid := range r.pkgIDs {
+			if queue, ok := unprocessedQueues[pid]; ok {
+				currPkgID = pid
+				currQueue = queue
+				return true
+			}
 		}
 
 		return false
@@ -175,13 +214,21 @@ func (r *Resolver) resolveStandard() bool {
 	// call nextQueue to initialize `currQueue` and `currPkgID`
 	nextQueue()
 
-	// mark is used to store the definition that will be used to test for
-	// repeats.  It is set to be the first definition, and if that definition is
-	// encountered again with no additional defined symbols, then the evaluation
-	// loop exits.  If it is encountered again and updated (new symbols), then
-	// we simply keep going.  If it is encountered and resolved, we take the
-	// mark to be the next item in the queue and continue.
-	var mark *Definition
+	// marks stores the definition that will be used to test for repeats in
+	// each queue along with the value of `definedCount` when it was set.  A
+	// mark is set to be the first unresolved definition, and if that
+	// definition is encountered again with no additional defined symbols, then
+	// resolution of that queue fails.  If it is encountered again and updated
+	// (new symbols), then we simply keep going.  If it is encountered and
+	// resolved, we take the mark to be the next item in the queue and
+	// continue.  The marks are kept per queue since resolution can switch
+	// queues before a mark is encountered again.
+	type queueMark struct {
+		def          *Definition
+		definedCount int
+	}
+	marks := make(map[uint]*queueMark)
+
 	for {
 		top := currQueue.Peek()
 
@@ -189,19 +236,15 @@ func (r *Resolver) resolveStandard() bool {
 		// terms of marking and current queue and if necessary, rotate the top
 		// definition to the back of its queue.
 		if dep, resolved := r.resolveDef(currPkgID, top); !resolved {
-			// set the mark if it is nil => need new mark
-			if mark == nil {
-				mark = top
-
-				// clear symbols flag (new mark)
-				symbolsDefined = false
-			} else if mark == top {
+			// set the mark if there is none => need new mark
+			if mark, ok := marks[currPkgID]; !ok {
+				marks[currPkgID] = &queueMark{def: top, definedCount: definedCount}
+			} else if mark.def == top {
 				// if we are encountering the same mark twice, check if any new
 				// symbols were defined.  If so, continue.  Otherwise,
 				// resolution on this queue has failed
-				if symbolsDefined {
-					// clear the flag
-					symbolsDefined = false
+				if mark.definedCount != definedCount {
+					mark.definedCount = definedCount
 				} else {
 					// set our resolution flag to indicate failure
 					resolutionSucceeded = false
@@ -214,6 +257,8 @@ func (r *Resolver) resolveStandard() bool {
 					if !nextQueue() {
 						break
 					}
+
+					continue
 				}
 			}
 
@@ -254,12 +299,12 @@ func (r *Resolver) resolveStandard() bool {
 			}
 		} else {
 			// if the top is equal to the mark, we clear the mark
-			if top == mark {
-				mark = nil
+			if mark, ok := marks[currPkgID]; ok && mark.def == top {
+				delete(marks, currPkgID)
 			}
 
-			// we have defined a symbol, so we set the flag appropriately
-			symbolsDefined = true
+			// we have defined a symbol, so we update the count appropriately
+			definedCount++
 
 			// the definition has been finalized, so we remove it for the
 			// current queue (no need to resolve it anymore)
@@ -290,13 +335,15 @@ func (r *Resolver) resolveStandard() bool {
 // all indeterminate definitions (functions, variables, etc.) and handles
 // unresolved imports
 func (r *Resolver) resolveRemaining() {
-	for _, pa := range r.assemblers {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
 		pa.finalPass()
 	}
 
 	// only after all definitions have been resolved and final passes have
 	// occurred are we good to check imports
-	for _, pa := range r.assemblers {
+	for _, pkgid := range r.pkgIDs {
+		pa := r.assemblers[pkgid]
 		pa.checkImports()
 	}
 }
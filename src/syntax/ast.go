@@ -18,15 +18,25 @@ func (a *ASTLeaf) Position() *logging.TextPosition {
 	return TextPositionOfToken((*Token)(a))
 }
 
-// TextPositionOfToken takes in a token and returns its text position
+// TextPositionOfToken takes in a token and returns its text position.  Columns
+// are counted in characters.
 func TextPositionOfToken(tok *Token) *logging.TextPosition {
-	return &logging.TextPosition{StartLn: tok.Line, StartCol: tok.Col - len(tok.Value), EndLn: tok.Line, EndCol: tok.Col}
+	width := tok.width
+	if width == 0 {
+		width = sourceWidth(tok.Value)
+	}
+
+	return &logging.TextPosition{StartLn: tok.Line, StartCol: tok.Col - width, EndLn: tok.Line, EndCol: tok.Col}
 }
 
 // ASTBranch is a named set of leaves and branches
 type ASTBranch struct {
 	Name    string
 	Content []ASTNode
+
+	// DocComment is the doc comment preceding the branch.  It is only set for
+	// definitions (and the definitions they wrap).
+	DocComment string
 }
 
 // Position of a branch is the starting position of its first node and the
@@ -13,6 +13,10 @@ type BNFRuleTable struct {
 	// map production names to the list of rules they are related to (for
 	// fast-lookup by production name)
 	RulesByProdName map[string][]int
+
+	// AnonSources maps the names of the anonymous productions to the EBNF
+	// source of the elements they were generated from (used to display them)
+	AnonSources map[string]string
 }
 
 // BNFRule is a data structure representing a single BNF rule in the expanded
@@ -77,7 +81,10 @@ type Expander struct {
 // expandGrammar expands and generates the BNF RuleTable from a given EBNF
 // grammar.  NB: source grammar should be disposed of after this function is run!
 func expandGrammar(g Grammar) *BNFRuleTable {
-	e := &Expander{table: &BNFRuleTable{RulesByProdName: make(map[string][]int)}}
+	e := &Expander{table: &BNFRuleTable{
+		RulesByProdName: make(map[string][]int),
+		AnonSources:     make(map[string]string),
+	}}
 
 	for name, prod := range g {
 		e.currentProdName = name
@@ -147,13 +154,13 @@ func (e *Expander) expandGroup(group []GrammaticalElement) []BNFElement {
 			if len(subGroup) == 1 && subGroup[0].Kind() != GKindAlternator {
 				ruleContents[i] = e.expandGroup(subGroup)[0]
 			} else {
-				anonName := e.getAnonName()
+				anonName := e.getAnonName(item)
 				e.expandProduction(anonName, subGroup)
 				ruleContents[i] = BNFNonterminal(anonName)
 			}
 		// creates a new anonymous production with an epsilon rule
 		case GKindOptional:
-			anonName := e.getAnonName()
+			anonName := e.getAnonName(item)
 
 			e.expandProduction(anonName, item.(*GroupingElement).elements)
 			e.epsilonRule(anonName)
@@ -162,7 +169,7 @@ func (e *Expander) expandGroup(group []GrammaticalElement) []BNFElement {
 		// same process as optionals but adds a reference to itself at the end
 		// of all of the normal rules ({ F } => E' where E' -> F E' | epsilon)
 		case GKindRepeat:
-			anonName := e.getAnonName()
+			anonName := e.getAnonName(item)
 
 			e.expandProduction(anonName, item.(*GroupingElement).elements)
 			ntRef := BNFNonterminal(anonName)
@@ -187,12 +194,12 @@ func (e *Expander) expandGroup(group []GrammaticalElement) []BNFElement {
 			if len(subGroup) == 1 && subGroup[0].Kind() != GKindAlternator {
 				subGroupRef = e.expandGroup(subGroup)[0]
 			} else {
-				anonName := e.getAnonName()
+				anonName := e.getAnonName(NewGroupingElement(GKindGroup, subGroup))
 				e.expandProduction(anonName, subGroup)
 				subGroupRef = BNFNonterminal(anonName)
 			}
 
-			suiteAnonName := e.getAnonName()
+			suiteAnonName := e.getAnonName(item)
 			e.addRule(suiteAnonName, []BNFElement{subGroupRef})
 			e.addRule(suiteAnonName, []BNFElement{
 				BNFTerminal(INDENT), subGroupRef, BNFTerminal(DEDENT),
@@ -225,6 +232,7 @@ func (e *Expander) inlineAnonNonterminal(newName string, nonterminal BNFNontermi
 	// assume the nonterminal is already defined
 	ruleRefs := e.table.RulesByProdName[ntName]
 	delete(e.table.RulesByProdName, ntName)
+	delete(e.table.AnonSources, ntName)
 	e.table.RulesByProdName[newName] = ruleRefs
 
 	for _, r := range ruleRefs {
@@ -238,7 +246,11 @@ func (e *Expander) epsilonRule(name string) {
 }
 
 // getAnonName gets a new anonymous production name for the current production
-func (e *Expander) getAnonName() string {
+// and records the grammatical element the production is generated from
+func (e *Expander) getAnonName(source GrammaticalElement) string {
 	e.anonCounter++
-	return fmt.Sprintf("$%d-%s", e.anonCounter, e.currentProdName)
+
+	anonName := fmt.Sprintf("$%d-%s", e.anonCounter, e.currentProdName)
+	e.table.AnonSources[anonName] = grammarString([]GrammaticalElement{source})
+	return anonName
 }
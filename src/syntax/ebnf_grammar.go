@@ -1,5 +1,9 @@
 package syntax
 
+import (
+	"strings"
+)
+
 // Grammar is defined to be a set of named productions
 type Grammar map[string]Production
 
@@ -78,3 +82,41 @@ func (AlternatorElement) Kind() int {
 func (ae *AlternatorElement) PushFront(group []GrammaticalElement) {
 	ae.groups = append([][]GrammaticalElement{group}, ae.groups...)
 }
+
+// grammarString converts a group of grammatical elements back into the EBNF
+// source they were loaded from (normalized: whitespace and quoting may differ)
+func grammarString(group []GrammaticalElement) string {
+	elemStrings := make([]string, len(group))
+
+	for i, item := range group {
+		switch v := item.(type) {
+		case Terminal:
+			// the terminal kind -1 denotes an epsilon
+			if v == -1 {
+				elemStrings[i] = "''"
+			} else {
+				elemStrings[i] = terminalName(int(v))
+			}
+		case Nonterminal:
+			elemStrings[i] = string(v)
+		case *AlternatorElement:
+			groupStrings := make([]string, len(v.groups))
+			for j, altGroup := range v.groups {
+				groupStrings[j] = grammarString(altGroup)
+			}
+
+			elemStrings[i] = strings.Join(groupStrings, " | ")
+		case *GroupingElement:
+			delims := map[int][2]string{
+				GKindGroup:    {"(", ")"},
+				GKindOptional: {"[", "]"},
+				GKindRepeat:   {"{", "}"},
+				GKindSuite:    {"?", "?"},
+			}[v.kind]
+
+			elemStrings[i] = delims[0] + " " + grammarString(v.elements) + " " + delims[1]
+		}
+	}
+
+	return strings.Join(elemStrings, " ")
+}
@@ -0,0 +1,39 @@
+//go:build ignore
+// +build ignore
+
+// gen_ptable generates the parsing table that is embedded in the compiler when
+// it is built with the `embedptable` tag (see `ptable_embed.go`).  It is run by
+// `go generate` from the `syntax` directory.
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"whirlwind/syntax"
+)
+
+func main() {
+	// the table is loaded from the config directory if it is up to date and
+	// rebuilt otherwise
+	ptable, err := syntax.NewParsingTable(filepath.Join("..", "..", "config", "grammar.ebnf"), false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	f, err := os.Create("grammar.ptable")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	defer f.Close()
+
+	if err := gob.NewEncoder(f).Encode(ptable); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,430 @@
+package syntax
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GrammarReport describes the conflicts in the parsing table generated for a
+// grammar.  It is meant to aid development of the grammar: the parser generator
+// resolves all shift/reduce conflicts in favor of shift which makes it hard to
+// tell what a change to the grammar actually did.
+type GrammarReport struct {
+	// StateCount is the number of states in the parsing table
+	StateCount int
+
+	// Conflicts lists every conflict in the parsing table sorted by prefix
+	Conflicts []*GrammarConflict
+
+	// Productions summarizes the conflicts of each production in the grammar
+	// sorted by production name
+	Productions []*ProductionSummary
+}
+
+// GrammarConflict is a single conflict in the parsing table
+type GrammarConflict struct {
+	// ReduceReduce indicates whether this is a reduce/reduce conflict.  All
+	// other conflicts are shift/reduce conflicts that were resolved as shifts.
+	ReduceReduce bool
+
+	// State is the state of the parsing table the conflict occurs in
+	State int
+
+	// Lookahead is the name of the lookahead token the conflict occurs on
+	Lookahead string
+
+	// Items are the LR(1) items of the state that are involved in the conflict
+	// (either shifting or reducing on the lookahead).  Anonymous productions
+	// generated while expanding the grammar are displayed as the EBNF source
+	// they were generated from along with the production containing them (eg.
+	// `<package_name: { '::' IDENTIFIER }>`).
+	Items []string
+
+	// Prefix is a shortest input that reaches the state followed by the
+	// lookahead token.  It is only the start of an input: it is not
+	// necessarily valid on its own.
+	Prefix string
+
+	// productions is the set of productions involved in the conflict
+	productions map[string]struct{}
+}
+
+// ProductionSummary summarizes the conflicts a production is involved in.
+// Anonymous productions generated while expanding the grammar are counted
+// with the production they were generated from.
+type ProductionSummary struct {
+	Name string
+
+	// RuleCount is the number of BNF rules the production expanded into
+	RuleCount int
+
+	ShiftReduceCount  int
+	ReduceReduceCount int
+}
+
+// NewGrammarReport builds the parsing table for the grammar at the given path
+// and reports all of its conflicts.  Unlike `NewParsingTable`, this will not
+// fail if the grammar has reduce/reduce conflicts: they are simply reported.
+func NewGrammarReport(grammarPath string) (*GrammarReport, error) {
+	g, err := loadGrammar(grammarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ptb := &PTableBuilder{BNFRules: expandGrammar(g), firstSets: make(map[string][]int)}
+	ptb.build()
+
+	report := &GrammarReport{StateCount: len(ptb.ItemSets)}
+	prefixes := ptb.shortestPrefixes()
+
+	// multiple reduce items can conflict with the same shift so we only report
+	// each conflict once
+	reported := make(map[tableConflict]struct{})
+	for _, tc := range ptb.conflicts {
+		if _, ok := reported[tc]; ok {
+			continue
+		}
+
+		reported[tc] = struct{}{}
+		report.Conflicts = append(report.Conflicts, ptb.describeConflict(tc, prefixes[tc.state]))
+	}
+
+	sort.SliceStable(report.Conflicts, func(i, j int) bool {
+		a, b := report.Conflicts[i], report.Conflicts[j]
+
+		if a.Prefix != b.Prefix {
+			return a.Prefix < b.Prefix
+		}
+
+		return a.Lookahead < b.Lookahead
+	})
+
+	// summarize the conflicts by production
+	summaries := make(map[string]*ProductionSummary)
+	for _, rule := range ptb.BNFRules.RulesByIndex {
+		name := productionOf(rule.ProdName)
+		if name == "_END_" {
+			continue
+		}
+
+		if summary, ok := summaries[name]; ok {
+			summary.RuleCount++
+		} else {
+			summaries[name] = &ProductionSummary{Name: name, RuleCount: 1}
+		}
+	}
+
+	for _, conflict := range report.Conflicts {
+		for name := range conflict.productions {
+			if summary, ok := summaries[name]; ok {
+				if conflict.ReduceReduce {
+					summary.ReduceReduceCount++
+				} else {
+					summary.ShiftReduceCount++
+				}
+			}
+		}
+	}
+
+	for _, summary := range summaries {
+		report.Productions = append(report.Productions, summary)
+	}
+
+	sort.Slice(report.Productions, func(i, j int) bool {
+		return report.Productions[i].Name < report.Productions[j].Name
+	})
+
+	return report, nil
+}
+
+// Write writes the report in a human-readable form
+func (gr *GrammarReport) Write(w io.Writer) {
+	shiftReduceCount := 0
+	for _, conflict := range gr.Conflicts {
+		if !conflict.ReduceReduce {
+			shiftReduceCount++
+		}
+	}
+
+	fmt.Fprintln(w, "Grammar Report")
+	fmt.Fprintln(w, "==============")
+	fmt.Fprintf(w, "States: %d\n", gr.StateCount)
+	fmt.Fprintf(w, "Conflicts: %d shift/reduce (resolved as shift), %d reduce/reduce\n",
+		shiftReduceCount, len(gr.Conflicts)-shiftReduceCount)
+
+	for i, conflict := range gr.Conflicts {
+		kind := "shift/reduce"
+		if conflict.ReduceReduce {
+			kind = "reduce/reduce"
+		}
+
+		fmt.Fprintf(w, "\nConflict %d: %s on %s (state %d)\n", i+1, kind, conflict.Lookahead, conflict.State)
+		fmt.Fprintf(w, "  prefix: %s\n", conflict.Prefix)
+		fmt.Fprintln(w, "  items:")
+
+		for _, item := range conflict.Items {
+			fmt.Fprintf(w, "    %s\n", item)
+		}
+	}
+
+	fmt.Fprintln(w, "\nProduction Summary")
+	fmt.Fprintln(w, "------------------")
+
+	nameWidth := len("production")
+	for _, summary := range gr.Productions {
+		if len(summary.Name) > nameWidth {
+			nameWidth = len(summary.Name)
+		}
+	}
+
+	fmt.Fprintf(w, "%-*s  rules  shift/reduce  reduce/reduce\n", nameWidth, "production")
+	for _, summary := range gr.Productions {
+		fmt.Fprintf(w, "%-*s  %5d  %12d  %13d\n", nameWidth, summary.Name, summary.RuleCount,
+			summary.ShiftReduceCount, summary.ReduceReduceCount)
+	}
+}
+
+// describeConflict creates a full description of a conflict in the table
+func (ptb *PTableBuilder) describeConflict(tc tableConflict, prefix []int) *GrammarConflict {
+	conflict := &GrammarConflict{
+		ReduceReduce: tc.reduceReduce,
+		State:        tc.state,
+		Lookahead:    terminalName(tc.lookahead),
+		productions:  make(map[string]struct{}),
+	}
+
+	prefixNames := make([]string, len(prefix))
+	for i, terminal := range prefix {
+		prefixNames[i] = terminalName(terminal)
+	}
+
+	conflict.Prefix = strings.Join(append(prefixNames, "•", conflict.Lookahead), " ")
+
+	for item, lookaheads := range ptb.ItemSets[tc.state].Items {
+		rule := ptb.BNFRules.RulesByIndex[item.Rule]
+
+		var action string
+		if item.DotPos == len(rule.Contents) || rule.Contents[0].Kind() == BNFKindEpsilon {
+			if _, ok := lookaheads[tc.lookahead]; !ok {
+				continue
+			}
+
+			action = "reduce "
+		} else if terminal, ok := rule.Contents[item.DotPos].(BNFTerminal); ok && int(terminal) == tc.lookahead {
+			action = "shift  "
+		} else {
+			continue
+		}
+
+		conflict.Items = append(conflict.Items, action+ptb.itemString(item, tc.lookahead))
+		conflict.productions[productionOf(rule.ProdName)] = struct{}{}
+	}
+
+	sort.Strings(conflict.Items)
+	return conflict
+}
+
+// itemString converts an LR(1) item into a string using the names of the
+// terminals (as opposed to their token kinds)
+func (ptb *PTableBuilder) itemString(item LRItem, lookahead int) string {
+	rule := ptb.BNFRules.RulesByIndex[item.Rule]
+
+	sb := strings.Builder{}
+	sb.WriteString(ptb.productionName(rule.ProdName))
+	sb.WriteString(" ->")
+
+	// epsilon rules can only be reduced
+	if rule.Contents[0].Kind() == BNFKindEpsilon {
+		sb.WriteString(" ε .")
+	} else {
+		for i, elem := range rule.Contents {
+			if i == item.DotPos {
+				sb.WriteString(" .")
+			}
+
+			sb.WriteRune(' ')
+			sb.WriteString(ptb.elementName(elem))
+		}
+
+		if item.DotPos == len(rule.Contents) {
+			sb.WriteString(" .")
+		}
+	}
+
+	sb.WriteString(", ")
+	sb.WriteString(terminalName(lookahead))
+
+	return sb.String()
+}
+
+// shortestPrefixes calculates the shortest sequence of terminals that reaches
+// each state of the parsing table from the starting state.  Ties are broken by
+// comparing the sequences so that the prefixes are the same every time.
+func (ptb *PTableBuilder) shortestPrefixes() [][]int {
+	yields := ptb.shortestYields()
+
+	prefixes := make([][]int, len(ptb.ItemSets))
+	reached := make([]bool, len(ptb.ItemSets))
+	reached[0] = true
+
+	for changed := true; changed; {
+		changed = false
+
+		for state, itemSet := range ptb.ItemSets {
+			if !reached[state] {
+				continue
+			}
+
+			for elem, next := range itemSet.Conns {
+				yield, ok := elementYield(elem, yields)
+				if !ok {
+					continue
+				}
+
+				prefix := append(append([]int{}, prefixes[state]...), yield...)
+				if !reached[next] || sequenceLess(prefix, prefixes[next]) {
+					prefixes[next] = prefix
+					reached[next] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	return prefixes
+}
+
+// shortestYields calculates the shortest sequence of terminals that can be
+// derived from each nonterminal in the grammar
+func (ptb *PTableBuilder) shortestYields() map[string][]int {
+	yields := make(map[string][]int)
+
+	for changed := true; changed; {
+		changed = false
+
+	ruleloop:
+		for _, rule := range ptb.BNFRules.RulesByIndex {
+			var yield []int
+			for _, elem := range rule.Contents {
+				elemYield, ok := elementYield(elem, yields)
+				if !ok {
+					continue ruleloop
+				}
+
+				yield = append(yield, elemYield...)
+			}
+
+			if prev, ok := yields[rule.ProdName]; !ok || sequenceLess(yield, prev) {
+				yields[rule.ProdName] = yield
+				changed = true
+			}
+		}
+	}
+
+	return yields
+}
+
+// elementYield gets the shortest sequence of terminals that can be derived
+// from a BNF element.  It fails if no yield has been calculated for the element
+func elementYield(elem BNFElement, yields map[string][]int) ([]int, bool) {
+	switch v := elem.(type) {
+	case BNFTerminal:
+		return []int{int(v)}, true
+	case BNFNonterminal:
+		yield, ok := yields[string(v)]
+		return yield, ok
+	}
+
+	// epsilon
+	return nil, true
+}
+
+// sequenceLess compares two sequences of terminals: shorter sequences come
+// first, and sequences of the same length are compared element by element
+func sequenceLess(a, b []int) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return false
+}
+
+// productionOf gets the name of the grammar production a BNF production belongs
+// to: anonymous productions (named `$n-production`) belong to the production
+// they were generated from
+func productionOf(prodName string) string {
+	if strings.HasPrefix(prodName, "$") {
+		return prodName[strings.Index(prodName, "-")+1:]
+	}
+
+	return prodName
+}
+
+// productionName gets the display name of a BNF production: anonymous
+// productions are named by the EBNF source they were generated from and the
+// production containing them
+func (ptb *PTableBuilder) productionName(prodName string) string {
+	if source, ok := ptb.BNFRules.AnonSources[prodName]; ok {
+		return fmt.Sprintf("<%s: %s>", productionOf(prodName), source)
+	}
+
+	return prodName
+}
+
+// elementName gets the display name of a BNF element
+func (ptb *PTableBuilder) elementName(elem BNFElement) string {
+	switch v := elem.(type) {
+	case BNFTerminal:
+		return terminalName(int(v))
+	case BNFNonterminal:
+		return ptb.productionName(string(v))
+	}
+
+	return "ε"
+}
+
+// specialTerminalNames stores the names of the terminals that have no fixed
+// pattern (as they are written in the grammar)
+var specialTerminalNames = map[int]string{
+	IDENTIFIER: "IDENTIFIER",
+	INDENT:     "INDENT",
+	DEDENT:     "DEDENT",
+	NEWLINE:    "NEWLINE",
+	STRINGLIT:  "STRINGLIT",
+	BOOLLIT:    "BOOLLIT",
+	INTLIT:     "INTLIT",
+	FLOATLIT:   "FLOATLIT",
+	RUNELIT:    "RUNELIT",
+	EOF:        "EOF",
+}
+
+// terminalName gets the name of a terminal as it would appear in the grammar
+func terminalName(kind int) string {
+	if name, ok := specialTerminalNames[kind]; ok {
+		return name
+	}
+
+	// if multiple patterns produce the same kind, we pick the first one
+	pattern := ""
+	for _, patterns := range []map[string]int{keywordPatterns, symbolPatterns} {
+		for p, pkind := range patterns {
+			if pkind == kind && (pattern == "" || p < pattern) {
+				pattern = p
+			}
+		}
+	}
+
+	if pattern == "" {
+		return fmt.Sprintf("<%d>", kind)
+	}
+
+	return "'" + pattern + "'"
+}
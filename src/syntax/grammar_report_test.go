@@ -0,0 +1,99 @@
+package syntax
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// danglingElseGrammar has a single shift/reduce conflict: the dangling else
+const danglingElseGrammar = `
+file = stmt { stmt } ;
+stmt = 'if' 'IDENTIFIER' stmt ['else' stmt] | 'IDENTIFIER' 'NEWLINE' ;
+`
+
+func newTestGrammarReport(t *testing.T, grammar string) *GrammarReport {
+	t.Helper()
+
+	grammarPath := filepath.Join(t.TempDir(), "grammar.ebnf")
+	if err := os.WriteFile(grammarPath, []byte(grammar), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := NewGrammarReport(grammarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return report
+}
+
+func TestGrammarReport(t *testing.T) {
+	report := newTestGrammarReport(t, danglingElseGrammar)
+
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("got %d conflicts; want 1", len(report.Conflicts))
+	}
+
+	conflict := report.Conflicts[0]
+	if conflict.ReduceReduce || conflict.Lookahead != "'else'" {
+		t.Errorf("got a conflict on %s; want a shift/reduce conflict on 'else'", conflict.Lookahead)
+	}
+
+	// the state is reached by a single if statement (the lookahead is only
+	// ambiguous when the statement is nested)
+	if want := "'if' IDENTIFIER IDENTIFIER NEWLINE • 'else'"; conflict.Prefix != want {
+		t.Errorf("got the prefix `%s`; want `%s`", conflict.Prefix, want)
+	}
+
+	// anonymous productions are displayed by their source and epsilons as `ε`
+	wantItems := []string{
+		"reduce <stmt: [ 'else' stmt ]> -> ε ., 'else'",
+		"shift  <stmt: [ 'else' stmt ]> -> . 'else' stmt, 'else'",
+	}
+
+	if strings.Join(conflict.Items, "\n") != strings.Join(wantItems, "\n") {
+		t.Errorf("got the items:\n%s\nwant:\n%s", strings.Join(conflict.Items, "\n"), strings.Join(wantItems, "\n"))
+	}
+
+	for _, summary := range report.Productions {
+		switch summary.Name {
+		case "stmt":
+			if summary.ShiftReduceCount != 1 {
+				t.Errorf("`stmt` is involved in %d shift/reduce conflicts; want 1", summary.ShiftReduceCount)
+			}
+		case "file":
+			if summary.ShiftReduceCount != 0 {
+				t.Errorf("`file` is involved in %d shift/reduce conflicts; want 0", summary.ShiftReduceCount)
+			}
+		default:
+			t.Errorf("unexpected production `%s` in the summary", summary.Name)
+		}
+	}
+
+	buff := &bytes.Buffer{}
+	report.Write(buff)
+	if output := buff.String(); strings.Contains(output, "$") || !strings.Contains(output, "prefix: "+conflict.Prefix) {
+		t.Errorf("unexpected report:\n%s", output)
+	}
+}
+
+func TestGrammarString(t *testing.T) {
+	grammarPath := filepath.Join(t.TempDir(), "grammar.ebnf")
+	grammar := "file = 'IDENTIFIER' { ',' 'IDENTIFIER' } [ '=' ( 'INTLIT' | 'FLOATLIT' ) ] ? 'NEWLINE' '' ? ;"
+	if err := os.WriteFile(grammarPath, []byte(grammar), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := loadGrammar(grammarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "IDENTIFIER { ',' IDENTIFIER } [ '=' ( INTLIT | FLOATLIT ) ] ? NEWLINE '' ?"
+	if got := grammarString(g["file"]); got != want {
+		t.Errorf("got `%s`; want `%s`", got, want)
+	}
+}
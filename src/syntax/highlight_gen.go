@@ -0,0 +1,466 @@
+package syntax
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The highlighting grammars are generated from the language grammar and the
+// token tables so that editor highlighting never drifts from what the compiler
+// actually accepts: only the keywords and operators that are used in the
+// grammar are highlighted.
+
+// textMateGrammar is the root of a TextMate grammar (`.tmLanguage.json`)
+type textMateGrammar struct {
+	Name       string                      `json:"name"`
+	ScopeName  string                      `json:"scopeName"`
+	FileTypes  []string                    `json:"fileTypes"`
+	Patterns   []*textMatePattern          `json:"patterns"`
+	Repository map[string]*textMatePattern `json:"repository"`
+}
+
+// textMatePattern is a single rule of a TextMate grammar.  The captures of
+// `Match` are scoped by `Captures` and those of `Begin` and `End` by
+// `BeginCaptures` and `EndCaptures` respectively.
+type textMatePattern struct {
+	Name          string                      `json:"name,omitempty"`
+	Match         string                      `json:"match,omitempty"`
+	Begin         string                      `json:"begin,omitempty"`
+	End           string                      `json:"end,omitempty"`
+	Include       string                      `json:"include,omitempty"`
+	Captures      map[string]*textMatePattern `json:"captures,omitempty"`
+	BeginCaptures map[string]*textMatePattern `json:"beginCaptures,omitempty"`
+	EndCaptures   map[string]*textMatePattern `json:"endCaptures,omitempty"`
+	Patterns      []*textMatePattern          `json:"patterns,omitempty"`
+}
+
+// GenerateTextMateGrammar generates a TextMate grammar (as JSON) for Whirlwind
+// from the grammar at the given path and the token tables
+func GenerateTextMateGrammar(grammarPath string) ([]byte, error) {
+	g, err := loadGrammar(grammarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	terminals := grammarTerminals(g)
+
+	tmg := &textMateGrammar{
+		Name:      "Whirlwind",
+		ScopeName: "source.whirlwind",
+		FileTypes: []string{"wrl"},
+		Repository: map[string]*textMatePattern{
+			"comments": {Patterns: []*textMatePattern{
+				{
+					Name:          "comment.block.whirlwind",
+					Begin:         "(#!)",
+					End:           "(!#)",
+					BeginCaptures: captureScopes("punctuation.definition.comment.whirlwind"),
+					EndCaptures:   captureScopes("punctuation.definition.comment.whirlwind"),
+				},
+				{
+					Name:     "comment.line.number-sign.whirlwind",
+					Match:    "(#).*$",
+					Captures: captureScopes("punctuation.definition.comment.whirlwind"),
+				},
+			}},
+			"metadata": {
+				Name:     "meta.preprocessor.whirlwind",
+				Match:    `^\s*(!!)(.*)$`,
+				Captures: captureScopes("punctuation.definition.directive.whirlwind", "entity.other.attribute-name.whirlwind"),
+			},
+			"annotations": {
+				Name:     "meta.annotation.whirlwind",
+				Match:    `(@)\s*(` + identifierPattern() + `)`,
+				Captures: captureScopes("punctuation.definition.annotation.whirlwind", "storage.type.annotation.whirlwind"),
+			},
+			"strings": {Patterns: []*textMatePattern{
+				{
+					Name:     "string.quoted.double.whirlwind",
+					Begin:    `"`,
+					End:      `"`,
+					Patterns: []*textMatePattern{{Include: "#escapes"}},
+				},
+				{
+					Name:  "string.quoted.other.raw.whirlwind",
+					Begin: "`",
+					End:   "`",
+				},
+				{
+					Name:  "string.quoted.single.whirlwind",
+					Match: `'(?:\\(?:x[0-9A-Fa-f]{2}|u[0-9A-Fa-f]{4}|U[0-9A-Fa-f]{8}|.)|[^'\\])'`,
+				},
+			}},
+			"escapes": {
+				Name:  "constant.character.escape.whirlwind",
+				Match: `\\(?:[abnfrtv0s"'\\]|x[0-9A-Fa-f]{2}|u[0-9A-Fa-f]{4}|U[0-9A-Fa-f]{8})`,
+			},
+			"numbers": {Patterns: []*textMatePattern{
+				{Name: "constant.numeric.hex.whirlwind", Match: `\b0x[0-9A-Fa-f]+[ul]*\b`},
+				{Name: "constant.numeric.octal.whirlwind", Match: `\b0o[0-7]+[ul]*\b`},
+				{Name: "constant.numeric.binary.whirlwind", Match: `\b0b[01]+[ul]*\b`},
+				{Name: "constant.numeric.float.whirlwind", Match: `\b[0-9]+(?:\.[0-9]+(?:[eE]-?[0-9]+)?|[eE]-?[0-9]+)\b`},
+				{Name: "constant.numeric.integer.whirlwind", Match: `\b[0-9]+[ul]*\b`},
+			}},
+		},
+	}
+
+	// the order of the patterns matters: comments and strings have to be
+	// matched before anything that could occur inside of them
+	for _, name := range []string{"comments", "metadata", "strings", "annotations", "numbers", "keywords", "operators"} {
+		tmg.Patterns = append(tmg.Patterns, &textMatePattern{Include: "#" + name})
+	}
+
+	keywords := &textMatePattern{}
+	for _, group := range keywordGroups(terminals) {
+		keywords.Patterns = append(keywords.Patterns, &textMatePattern{
+			Name:  group.scope + ".whirlwind",
+			Match: `\b(?:` + strings.Join(group.keywords, "|") + `)\b`,
+		})
+	}
+
+	if _, ok := terminals[BOOLLIT]; ok {
+		keywords.Patterns = append(keywords.Patterns, &textMatePattern{
+			Name:  "constant.language.boolean.whirlwind",
+			Match: `\b(?:true|false)\b`,
+		})
+	}
+
+	tmg.Repository["keywords"] = keywords
+
+	// longer operators must be matched first
+	operators := highlightedOperators(terminals)
+	sort.SliceStable(operators, func(i, j int) bool {
+		return len(operators[i]) > len(operators[j])
+	})
+
+	for i, op := range operators {
+		operators[i] = regexp.QuoteMeta(op)
+	}
+
+	tmg.Repository["operators"] = &textMatePattern{
+		Name:  "keyword.operator.whirlwind",
+		Match: strings.Join(operators, "|"),
+	}
+
+	return json.MarshalIndent(tmg, "", "  ")
+}
+
+// captureScopes creates the capture map for a pattern from the scopes of each
+// of its capture groups (in order)
+func captureScopes(scopes ...string) map[string]*textMatePattern {
+	captures := make(map[string]*textMatePattern)
+	for i, scope := range scopes {
+		captures[fmt.Sprint(i+1)] = &textMatePattern{Name: scope}
+	}
+
+	return captures
+}
+
+// keywordGroup is a group of keywords that are highlighted the same way
+type keywordGroup struct {
+	scope    string
+	keywords []string
+}
+
+// keywordGroups groups the keywords used in the grammar by their highlighting
+// scopes.  The groups are based on the token kinds.
+func keywordGroups(terminals map[int]struct{}) []*keywordGroup {
+	groups := []*keywordGroup{
+		{scope: "keyword.control.import"},
+		{scope: "keyword.control"},
+		{scope: "storage.type"},
+		{scope: "storage.modifier"},
+		{scope: "support.type.primitive"},
+		{scope: "constant.language"},
+		{scope: "variable.language"},
+		{scope: "keyword.other"},
+	}
+
+	for keyword, kind := range keywordPatterns {
+		if _, ok := terminals[kind]; !ok {
+			continue
+		}
+
+		var group *keywordGroup
+		switch {
+		case kind >= IMPORT && kind <= FROM:
+			group = groups[0]
+		case kind >= IF && kind <= YIELD:
+			group = groups[1]
+		case kind == LET || kind == FUNC || kind == OPER || kind == TYPE || kind == INTERF || kind == CONSTRAINT:
+			group = groups[2]
+		case kind == CONST || kind == VOL || kind == ASYNC || kind == SPECIAL || kind == CLOSED:
+			group = groups[3]
+		case kind >= U8 && kind <= NOTHING:
+			group = groups[4]
+		case kind == NULL:
+			group = groups[5]
+		case kind == SUPER:
+			group = groups[6]
+		default:
+			group = groups[7]
+		}
+
+		group.keywords = append(group.keywords, keyword)
+	}
+
+	var usedGroups []*keywordGroup
+	for _, group := range groups {
+		if len(group.keywords) > 0 {
+			sort.Strings(group.keywords)
+			usedGroups = append(usedGroups, group)
+		}
+	}
+
+	return usedGroups
+}
+
+// highlightedOperators returns all of the operators used in the grammar sorted
+// alphabetically.  Brackets and separators are not considered operators and
+// `@` is highlighted as part of annotations.
+func highlightedOperators(terminals map[int]struct{}) []string {
+	var operators []string
+	for op, kind := range symbolPatterns {
+		if _, ok := terminals[kind]; !ok {
+			continue
+		}
+
+		switch kind {
+		case LPAREN, RPAREN, LBRACE, RBRACE, LBRACKET, RBRACKET, COMMA, SEMICOLON, ANNOTSTART:
+			continue
+		}
+
+		operators = append(operators, op)
+	}
+
+	sort.Strings(operators)
+	return operators
+}
+
+// grammarTerminals collects all of the terminals that are used in a grammar
+func grammarTerminals(g Grammar) map[int]struct{} {
+	terminals := make(map[int]struct{})
+
+	var collect func(elems []GrammaticalElement)
+	collect = func(elems []GrammaticalElement) {
+		for _, elem := range elems {
+			switch v := elem.(type) {
+			case Terminal:
+				terminals[int(v)] = struct{}{}
+			case *GroupingElement:
+				collect(v.elements)
+			case *AlternatorElement:
+				for _, group := range v.groups {
+					collect(group)
+				}
+			}
+		}
+	}
+
+	for _, prod := range g {
+		collect(prod)
+	}
+
+	return terminals
+}
+
+// treeSitterSpecialTerminals maps the special terminals to the tree-sitter
+// rules that match them.  The whitespace tokens are produced by an external
+// scanner (since they depend on indentation).
+var treeSitterSpecialTerminals = map[int]string{
+	IDENTIFIER: "$.identifier",
+	STRINGLIT:  "$.string_literal",
+	INTLIT:     "$.int_literal",
+	FLOATLIT:   "$.float_literal",
+	RUNELIT:    "$.rune_literal",
+	BOOLLIT:    "$.bool_literal",
+	NEWLINE:    "$._newline",
+	INDENT:     "$._indent",
+	DEDENT:     "$._dedent",
+}
+
+// treeSitterLexicalRules are the rules for the special terminals that can be
+// matched by tree-sitter directly (other than identifiers: see
+// `identifierPattern`)
+const treeSitterLexicalRules = `    string_literal: $ => token(choice(
+      seq('"', repeat(choice(/[^"\\\n]/, /\\./)), '"'),
+      seq('` + "`" + `', /[^` + "`" + `]*/, '` + "`" + `'),
+    )),
+
+    int_literal: $ => token(choice(
+      /0x[0-9A-Fa-f]+[ul]*/,
+      /0o[0-7]+[ul]*/,
+      /0b[01]+[ul]*/,
+      /[0-9]+[ul]*/,
+    )),
+
+    float_literal: $ => token(/[0-9]+(\.[0-9]+([eE]-?[0-9]+)?|[eE]-?[0-9]+)/),
+
+    rune_literal: $ => token(seq("'", choice(/[^'\\]/, /\\./, /\\x[0-9A-Fa-f]{2}/, /\\u[0-9A-Fa-f]{4}/, /\\U[0-9A-Fa-f]{8}/), "'")),
+
+    bool_literal: $ => choice('true', 'false'),
+
+    comment: $ => token(choice(
+      seq('#!', /[^!]*!+([^#!][^!]*!+)*/, '#'),
+      /#[^\n]*/,
+    )),
+`
+
+// GenerateTreeSitterGrammar generates a skeleton tree-sitter grammar
+// (`grammar.js`) from the grammar at the given path.  The rules are translated
+// directly from the grammar, but the indentation tokens have to be provided by
+// an external scanner which is not generated.
+func GenerateTreeSitterGrammar(grammarPath string) (string, error) {
+	g, err := loadGrammar(grammarPath)
+	if err != nil {
+		return "", err
+	}
+
+	// the goal symbol has to be the first rule
+	names := make([]string, 0, len(g))
+	for name := range g {
+		if name != _goalSymbol {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	names = append([]string{_goalSymbol}, names...)
+
+	sb := strings.Builder{}
+	sb.WriteString("// Generated from grammar.ebnf by `whirl dev highlight -format tree-sitter`.\n")
+	sb.WriteString("// This is only a skeleton: INDENT, DEDENT, and NEWLINE must be produced by an\n")
+	sb.WriteString("// external scanner, and conflicts may need to be declared.\n")
+	sb.WriteString("module.exports = grammar({\n")
+	sb.WriteString("  name: 'whirlwind',\n\n")
+	sb.WriteString("  externals: $ => [$._newline, $._indent, $._dedent],\n\n")
+	sb.WriteString("  extras: $ => [/[ \\t\\r\\f\\v]/, $.comment],\n\n")
+	sb.WriteString("  word: $ => $.identifier,\n\n")
+	sb.WriteString("  rules: {\n")
+
+	for _, name := range names {
+		prod, ok := g[name]
+		if !ok {
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("    %s: $ => %s,\n\n", name, treeSitterGroup(prod)))
+	}
+
+	sb.WriteString(fmt.Sprintf("    identifier: $ => /%s/,\n\n", identifierPattern()))
+	sb.WriteString(treeSitterLexicalRules)
+	sb.WriteString("  }\n});\n")
+
+	return sb.String(), nil
+}
+
+// treeSitterGroup converts a group of grammatical elements into a tree-sitter
+// rule expression
+func treeSitterGroup(elems []GrammaticalElement) string {
+	if len(elems) == 1 {
+		return treeSitterElement(elems[0])
+	}
+
+	parts := make([]string, len(elems))
+	for i, elem := range elems {
+		parts[i] = treeSitterElement(elem)
+	}
+
+	return "seq(" + strings.Join(parts, ", ") + ")"
+}
+
+// treeSitterElement converts a single grammatical element into a tree-sitter
+// rule expression
+func treeSitterElement(elem GrammaticalElement) string {
+	switch v := elem.(type) {
+	case Terminal:
+		if v == -1 {
+			return "blank()"
+		}
+
+		if rule, ok := treeSitterSpecialTerminals[int(v)]; ok {
+			return rule
+		}
+
+		// the patterns of keywords and symbols are quoted the same way in the
+		// grammar and in JavaScript
+		return strings.Replace(terminalName(int(v)), `\`, `\\`, -1)
+	case Nonterminal:
+		return "$." + string(v)
+	case *GroupingElement:
+		group := treeSitterGroup(v.elements)
+
+		switch v.kind {
+		case GKindOptional:
+			return "optional(" + group + ")"
+		case GKindRepeat:
+			return "repeat(" + group + ")"
+		case GKindSuite:
+			return fmt.Sprintf("choice(%s, seq($._indent, %s, $._dedent))", group, group)
+		}
+
+		return group
+	case *AlternatorElement:
+		alternatives := make([]string, len(v.groups))
+		for i, group := range v.groups {
+			alternatives[i] = treeSitterGroup(group)
+		}
+
+		return "choice(" + strings.Join(alternatives, ", ") + ")"
+	}
+
+	return "blank()"
+}
+
+// identifierPattern returns a regular expression that matches an identifier.
+// It is generated from `IsIdentStart` and `IsIdentContinue` so identifiers are
+// highlighted exactly as they are scanned.  The expression is understood by
+// both TextMate (Oniguruma) and tree-sitter.
+func identifierPattern() string {
+	return runeClass(IsIdentStart) + runeClass(IsIdentContinue) + "*"
+}
+
+// runeClass generates a regular expression character class that matches all
+// of the runes for which the given predicate is true.  Runes outside of ASCII
+// are written as `\x{...}` escapes so the class only contains ASCII.
+func runeClass(pred func(rune) bool) string {
+	sb := strings.Builder{}
+	sb.WriteRune('[')
+
+	writeRune := func(r rune) {
+		if r < utf8.RuneSelf && (IsLetter(r) || IsDigit(r) || r == '_') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteString(fmt.Sprintf("\\x{%X}", r))
+		}
+	}
+
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if !pred(r) {
+			continue
+		}
+
+		// find the end of the range of runes starting at `r`
+		lo := r
+		for r+1 <= unicode.MaxRune && pred(r+1) {
+			r++
+		}
+
+		writeRune(lo)
+		if r > lo {
+			if r > lo+1 {
+				sb.WriteRune('-')
+			}
+
+			writeRune(r)
+		}
+	}
+
+	sb.WriteRune(']')
+	return sb.String()
+}
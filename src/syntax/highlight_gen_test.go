@@ -0,0 +1,124 @@
+package syntax
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testGrammarPath = filepath.Join("..", "..", "config", "grammar.ebnf")
+
+// loadTextMateGrammar generates the TextMate grammar for the language grammar
+func loadTextMateGrammar(t *testing.T) *textMateGrammar {
+	t.Helper()
+
+	data, err := GenerateTextMateGrammar(testGrammarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmg := &textMateGrammar{}
+	if err := json.Unmarshal(data, tmg); err != nil {
+		t.Fatal(err)
+	}
+
+	return tmg
+}
+
+// checkCaptures checks that a pattern has as many capture groups as the
+// captures scoped for it
+func checkCaptures(t *testing.T, name, pattern string, captures map[string]*textMatePattern) {
+	t.Helper()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		t.Errorf("invalid pattern for `%s`: %s", name, err)
+		return
+	}
+
+	for group := range captures {
+		var n int
+		if _, err := fmt.Sscan(group, &n); err != nil || n > re.NumSubexp() {
+			t.Errorf("`%s` scopes capture %s but `%s` has %d groups", name, group, pattern, re.NumSubexp())
+		}
+	}
+}
+
+func TestTextMateGrammarCaptures(t *testing.T) {
+	tmg := loadTextMateGrammar(t)
+
+	var walk func(name string, patterns []*textMatePattern)
+	walk = func(name string, patterns []*textMatePattern) {
+		for _, p := range patterns {
+			if p.Match != "" {
+				checkCaptures(t, name, p.Match, p.Captures)
+			} else if len(p.Captures) > 0 {
+				t.Errorf("`%s` uses captures without a match", name)
+			}
+
+			if p.Begin != "" {
+				checkCaptures(t, name, p.Begin, p.BeginCaptures)
+				checkCaptures(t, name, p.End, p.EndCaptures)
+			}
+
+			walk(name, p.Patterns)
+		}
+	}
+
+	for name, p := range tmg.Repository {
+		walk(name, []*textMatePattern{p})
+	}
+
+	comment := tmg.Repository["comments"].Patterns[0]
+	if len(comment.BeginCaptures) != 1 || len(comment.EndCaptures) != 1 {
+		t.Error("the delimiters of block comments are not scoped")
+	}
+}
+
+func TestTextMateGrammarAnnotations(t *testing.T) {
+	re := regexp.MustCompile(loadTextMateGrammar(t).Repository["annotations"].Match)
+
+	for annot, name := range map[string]string{"@inline": "inline", "@ größe": "größe", "@π2": "π2", "@_x": "_x"} {
+		if match := re.FindStringSubmatch(annot); match == nil || match[2] != name {
+			t.Errorf("`%s` is not highlighted as the annotation `%s`", annot, name)
+		}
+	}
+
+	if re.MatchString("@2x") {
+		t.Error("`@2x` is highlighted as an annotation")
+	}
+}
+
+func TestTreeSitterGrammar(t *testing.T) {
+	grammarJS, err := GenerateTreeSitterGrammar(testGrammarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(grammarJS, "    file: $ => ") {
+		t.Error("missing the goal symbol")
+	}
+
+	prefix := "    identifier: $ => /"
+	start := strings.Index(grammarJS, prefix)
+	if start == -1 {
+		t.Fatal("missing the identifier rule")
+	}
+
+	pattern := grammarJS[start+len(prefix):]
+	pattern = pattern[:strings.Index(pattern, "/,\n")]
+
+	re := regexp.MustCompile("^" + pattern + "$")
+	for _, ident := range []string{"x", "größe", "π", "_a1"} {
+		if !re.MatchString(ident) {
+			t.Errorf("`%s` is not matched as an identifier", ident)
+		}
+	}
+
+	if re.MatchString("1x") {
+		t.Error("`1x` is matched as an identifier")
+	}
+}
@@ -0,0 +1,108 @@
+package syntax
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Identifiers follow UAX #31 (Unicode Identifier and Pattern Syntax): an
+// identifier begins with a character with the XID_Start property or an
+// underscore and is followed by any number of characters with the
+// XID_Continue property.  Identifiers are normalized to NFC when they are
+// scanned so that identifiers that are canonically equivalent (eg. `é` written
+// as one code point or as `e` followed by a combining accent) are the same
+// identifier.  The same rules apply to package and module names since packages
+// are referenced by name in source code.
+
+// IsIdentStart tests if a rune can begin an identifier
+func IsIdentStart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return IsLetter(r) || r == '_'
+	}
+
+	return isIDStart(r) && !unicode.Is(xidStartExclusions, r)
+}
+
+// IsIdentContinue tests if a rune can appear after the first rune of an
+// identifier
+func IsIdentContinue(r rune) bool {
+	if r < utf8.RuneSelf {
+		return IsLetter(r) || IsDigit(r) || r == '_'
+	}
+
+	if unicode.Is(xidContinueExclusions, r) {
+		return false
+	}
+
+	return isIDStart(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
+// IsValidIdentifier tests if a string is a valid identifier.  The string should
+// already be normalized (see `NormalizeIdentifier`).
+func IsValidIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i, r := range s {
+		if r == utf8.RuneError {
+			return false
+		}
+
+		if i == 0 {
+			if !IsIdentStart(r) {
+				return false
+			}
+		} else if !IsIdentContinue(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NormalizeIdentifier converts an identifier (or a path made up of identifiers)
+// into Normalization Form C
+func NormalizeIdentifier(s string) string {
+	return norm.NFC.String(s)
+}
+
+// isIDStart tests if a rune has the ID_Start property: letters, letter numbers
+// (eg. Roman numerals) and the other characters that are grandfathered in
+func isIDStart(r rune) bool {
+	if unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space) {
+		return false
+	}
+
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start)
+}
+
+// xidStartExclusions are the characters that have the ID_Start property but
+// not the XID_Start property: they are excluded so that identifiers stay
+// identifiers under NFKC normalization
+var xidStartExclusions = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x0e33, Hi: 0x0e33, Stride: 1},
+		{Lo: 0x0eb3, Hi: 0x0eb3, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+		{Lo: 0xff9e, Hi: 0xff9f, Stride: 1},
+	},
+}
+
+// xidContinueExclusions are the characters that have the ID_Continue property
+// but not the XID_Continue property
+var xidContinueExclusions = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+	},
+}
@@ -0,0 +1,175 @@
+package syntax
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"whirlwind/logging"
+)
+
+// scanTestTokens scans a source file and returns all of its tokens up to (but
+// not including) the EOF: the test fails if it can't be scanned
+func scanTestTokens(t *testing.T, src string) []*Token {
+	t.Helper()
+
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "file.wrl")
+	if err := os.WriteFile(fpath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logging.Initialize(dir, "error")
+	sc, ok := NewScanner(fpath, &logging.LogContext{FilePath: fpath})
+	if !ok {
+		t.Fatal("failed to open the file")
+	}
+
+	var toks []*Token
+	for {
+		tok, ok := sc.ReadToken()
+		if !ok {
+			logging.LogStageEnd()
+			t.Fatal("failed to scan the file")
+		}
+
+		if tok.Kind == EOF {
+			return toks
+		}
+
+		toks = append(toks, tok)
+	}
+}
+
+func TestIdentClasses(t *testing.T) {
+	for _, c := range []struct {
+		r           rune
+		start, cont bool
+	}{
+		{'a', true, true},
+		{'_', true, true},
+		{'7', false, true},
+		{'$', false, false},
+		{'ß', true, true},
+		{'π', true, true},
+		{'界', true, true},
+		// Roman numeral (letter number)
+		{'Ⅻ', true, true},
+		// middle dot: Other_ID_Continue
+		{'·', false, true},
+		// combining acute accent: Mn
+		{'\u0301', false, true},
+		// Arabic-Indic digit: Nd
+		{'٣', false, true},
+		// Greek ypogegrammeni: ID_Start but not XID_Start
+		{'ͺ', false, false},
+		// Thai sara am: XID_Continue but not XID_Start
+		{'ำ', false, true},
+		// pattern syntax and white space
+		{'→', false, false},
+		{' ', false, false},
+	} {
+		if got := IsIdentStart(c.r); got != c.start {
+			t.Errorf("IsIdentStart(%U) = %v, want %v", c.r, got, c.start)
+		}
+
+		if got := IsIdentContinue(c.r); got != c.cont {
+			t.Errorf("IsIdentContinue(%U) = %v, want %v", c.r, got, c.cont)
+		}
+	}
+}
+
+func TestIdentRuneClasses(t *testing.T) {
+	// the character classes used by the generated syntax highlighters must
+	// match exactly the same runes as the scanner
+	for name, pred := range map[string]func(rune) bool{
+		"IsIdentStart":    IsIdentStart,
+		"IsIdentContinue": IsIdentContinue,
+	} {
+		class := regexp.MustCompile("^" + runeClass(pred) + "$")
+
+		for r := rune(0); r <= unicode.MaxRune; r++ {
+			// surrogates can't be encoded in UTF-8
+			if !utf8.ValidRune(r) {
+				continue
+			}
+
+			if got, want := class.MatchString(string(r)), pred(r); got != want {
+				t.Errorf("the class of %s matches %U: %v, want %v", name, r, got, want)
+			}
+		}
+	}
+}
+
+func TestIsValidIdentifier(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		want bool
+	}{
+		{"größe", true},
+		{"π", true},
+		{"_x1", true},
+		{"x·y", true},
+		{"", false},
+		{"1x", false},
+		{"·x", false},
+		{"a-b", false},
+		{"a b", false},
+		{"\xff", false},
+	} {
+		if got := IsValidIdentifier(c.s); got != c.want {
+			t.Errorf("IsValidIdentifier(%q) = %v, want %v", c.s, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	// `é` written as `e` followed by a combining accent
+	if got := NormalizeIdentifier("cafe\u0301"); got != "café" {
+		t.Errorf("got %q, want %q", got, "café")
+	}
+
+	if got := NormalizeIdentifier("proj/größe"); got != "proj/größe" {
+		t.Errorf("got %q, want %q", got, "proj/größe")
+	}
+}
+
+func TestScanUnicodeIdentifiers(t *testing.T) {
+	// `café` is written with a combining accent
+	toks := scanTestTokens(t, "größe π cafe\u0301 x\n")
+
+	want := []struct {
+		value            string
+		startCol, endCol int
+	}{
+		{"größe", 0, 5},
+		{"π", 6, 7},
+		// identifiers are normalized but their columns are still counted in
+		// the characters of the source text
+		{"café", 8, 13},
+		{"x", 14, 15},
+	}
+
+	var idents []*Token
+	for _, tok := range toks {
+		if tok.Kind == IDENTIFIER {
+			idents = append(idents, tok)
+		}
+	}
+
+	if len(idents) != len(want) {
+		t.Fatalf("got %d identifiers, want %d", len(idents), len(want))
+	}
+
+	for i, w := range want {
+		tok := idents[i]
+		pos := TextPositionOfToken(tok)
+
+		if tok.Value != w.value || pos.StartCol != w.startCol || pos.EndCol != w.endCol {
+			t.Errorf("got `%s` at columns %d-%d, want `%s` at columns %d-%d", tok.Value, pos.StartCol, pos.EndCol, w.value, w.startCol, w.endCol)
+		}
+	}
+}
@@ -194,7 +194,7 @@ func (p *Parser) shift(state int) bool {
 
 		// no need to update lookahead if full level change hasn't been handled
 		if levelChange > 1 {
-			p.lookahead.Value = string(levelChange - 1)
+			p.lookahead.Value = string(rune(levelChange - 1))
 			return true
 		}
 	// handle indentation blind frame openers
@@ -304,6 +304,11 @@ func (p *Parser) reduce(ruleRef int) {
 			branch.Content = newBranchContent
 		}
 
+		// doc comments are attached as soon as the definition is reduced
+		if branch.Name == "definition" || branch.Name == "interf_member" {
+			p.attachDocComment(branch)
+		}
+
 		p.semanticStack = append(p.semanticStack, branch)
 		p.stateStack = p.stateStack[:len(p.stateStack)-rule.Count]
 	}
@@ -313,6 +318,31 @@ func (p *Parser) reduce(ruleRef int) {
 	p.stateStack = append(p.stateStack, currState.Gotos[rule.Name])
 }
 
+// attachDocComment attaches the doc comment preceding a definition to the
+// definition branch.  The comment is also attached to the definition that the
+// branch wraps (skipping over annotations) so that it is not lost when the
+// wrapping branches are discarded.
+func (p *Parser) attachDocComment(branch *ASTBranch) {
+	doc, ok := p.sc.DocCommentAt(branch.Position().StartLn)
+	if !ok {
+		return
+	}
+
+	for {
+		branch.DocComment = doc
+
+		switch branch.Name {
+		case "definition", "interf_member", "annotated_def", "annotated_method":
+			if inner, ok := branch.Last().(*ASTBranch); ok {
+				branch = inner
+				continue
+			}
+		}
+
+		return
+	}
+}
+
 // topIndentFrame gets the indent frame at the top of the frame stack (ie. the
 // current indent frame)
 func (p *Parser) topIndentFrame() *IndentFrame {
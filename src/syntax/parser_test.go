@@ -0,0 +1,125 @@
+package syntax
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+var (
+	testParsingTable     *ParsingTable
+	testParsingTableOnce sync.Once
+)
+
+// parseTestFile parses a source file: the test fails if it can't be parsed
+func parseTestFile(t *testing.T, src string) *ASTBranch {
+	t.Helper()
+
+	testParsingTableOnce.Do(func() {
+		ptable, err := NewParsingTable(testGrammarPath, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testParsingTable = ptable
+	})
+
+	if testParsingTable == nil {
+		t.Fatal("no parsing table")
+	}
+
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "file.wrl")
+	if err := os.WriteFile(fpath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logging.Initialize(dir, "error")
+	sc, ok := NewScanner(fpath, &logging.LogContext{FilePath: fpath})
+	if !ok {
+		t.Fatal("failed to open the file")
+	}
+
+	ast, ok := NewParser(testParsingTable, sc).Parse()
+	if !ok {
+		logging.LogStageEnd()
+		t.Fatal("failed to parse the file")
+	}
+
+	return ast.(*ASTBranch)
+}
+
+// docCommentsByName collects the doc comments of the branches with the given
+// name by the first identifier in them
+func docCommentsByName(root *ASTBranch, branchName string) map[string]string {
+	docs := make(map[string]string)
+
+	var walk func(branch *ASTBranch)
+	walk = func(branch *ASTBranch) {
+		if branch.Name == branchName {
+			for _, node := range branch.Content {
+				if leaf, ok := node.(*ASTLeaf); ok && leaf.Kind == IDENTIFIER {
+					docs[leaf.Value] = branch.DocComment
+					break
+				}
+			}
+		}
+
+		for _, node := range branch.Content {
+			if inner, ok := node.(*ASTBranch); ok {
+				walk(inner)
+			}
+		}
+	}
+
+	walk(root)
+	return docs
+}
+
+func TestDocComments(t *testing.T) {
+	ast := parseTestFile(t, `# not a doc comment: the file doesn't start with a definition
+import a
+
+# `+"`clamp`"+` constrains a value
+# to a range
+func clamp(x: int) int -> x
+
+func noDoc() int -> 0 # trailing comments are not doc comments
+
+# detached comments are not doc comments
+
+# `+"`Point`"+` is a point
+@packed
+type Point {
+    x: int
+}
+
+interf Shape of
+    # `+"`area`"+` gets the area
+    func area() int
+`)
+
+	funcs := docCommentsByName(ast, "func_def")
+	for name, want := range map[string]string{
+		"clamp": "`clamp` constrains a value\nto a range",
+		"noDoc": "",
+		"area":  "`area` gets the area",
+	} {
+		if got, ok := funcs[name]; !ok {
+			t.Errorf("missing function `%s`", name)
+		} else if got != want {
+			t.Errorf("`%s` has the doc comment %q; want %q", name, got, want)
+		}
+	}
+
+	if got := docCommentsByName(ast, "type_def")["Point"]; got != "`Point` is a point" {
+		t.Errorf("`Point` has the doc comment %q", got)
+	}
+
+	if got := docCommentsByName(ast, "interf_def")["Shape"]; got != "" {
+		t.Errorf("`Shape` has the doc comment %q", got)
+	}
+}
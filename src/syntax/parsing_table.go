@@ -1,17 +1,28 @@
 package syntax
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/gob"
+	"encoding/hex"
 	"errors"
+	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 )
 
+//go:generate go run gen_ptable.go
+
 // ParsingTable represents our adapted LALR(1) parser's Action-Goto table as
 // well as all of the rules it can reduce by
 type ParsingTable struct {
 	Rows  []*PTableRow
 	Rules []*PTableRule
+
+	// GrammarHash is the hash of the grammar file the table was generated
+	// from.  It is used to detect tables that are out of date.
+	GrammarHash string
 }
 
 // PTableRow is a particular row in the parsing table.  Any terminal for which
@@ -50,14 +61,72 @@ type PTableRule struct {
 }
 
 // NewParsingTable creates a new parsing table struct based on the given
-// grammar. This table will either be newly generated or loaded from disk
+// grammar. This table will either be loaded from disk, loaded from the table
+// embedded in the compiler (if there is one), or newly generated.  A table is
+// only loaded if it was generated from the current grammar: if it is out of
+// date, then a new table is generated and saved in its place.  If the grammar
+// can't be read (eg. because only the compiler was installed), then the
+// embedded table is preferred since the table on disk can't be checked: it is
+// only used if it was generated from the same grammar as the embedded table or
+// if there is no embedded table.
 func NewParsingTable(grammarPath string, forcegrebuild bool) (*ParsingTable, error) {
-	var parsingTable *ParsingTable
+	grammarHash, err := hashGrammar(grammarPath)
+	if err != nil {
+		if forcegrebuild || !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if embeddedParsingTable != nil {
+			embedded, err := decodeParsingTable(bytes.NewReader(embeddedParsingTable))
+			if err != nil {
+				return nil, err
+			}
+
+			if ptable, err := loadParsingTable(grammarPath); err == nil && ptable.GrammarHash == embedded.GrammarHash {
+				return ptable, nil
+			}
+
+			return embedded, nil
+		}
+
+		if ptable, err := loadParsingTable(grammarPath); err == nil {
+			return ptable, nil
+		}
+
+		return nil, errors.New("Parser Error: Unable to find a parsing table or a grammar to build one from")
+	}
 
 	if !forcegrebuild {
-		return loadParsingTable(grammarPath)
+		if ptable, err := loadParsingTable(grammarPath); err == nil && ptable.GrammarHash == grammarHash {
+			return ptable, nil
+		}
+
+		if embeddedParsingTable != nil {
+			ptable, err := decodeParsingTable(bytes.NewReader(embeddedParsingTable))
+			if err == nil && ptable.GrammarHash == grammarHash {
+				return ptable, nil
+			}
+		}
+	}
+
+	ptable, err := buildParsingTable(grammarPath, grammarHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// save the newly generated parsing table so it can be loaded the next time
+	// the compiler is run.  If the rebuild was forced, then failing to save is
+	// an error.  Otherwise, the table can still be used: it just will have to
+	// be rebuilt again next time (eg. if the config directory isn't writable)
+	if err = saveParsingTable(ptable, grammarPath); err != nil && forcegrebuild {
+		return nil, err
 	}
 
+	return ptable, nil
+}
+
+// buildParsingTable generates a new parsing table from the grammar
+func buildParsingTable(grammarPath, grammarHash string) (*ParsingTable, error) {
 	g, err := loadGrammar(grammarPath)
 
 	if err != nil {
@@ -78,13 +147,19 @@ func NewParsingTable(grammarPath string, forcegrebuild bool) (*ParsingTable, err
 		return nil, errors.New("Parser Error: Failed to build the parsing table")
 	}
 
-	// save the newly generate parsing table.  If it didn't generate, we want to
-	// return an error (even if the parsing table generated correctly)
-	if err = saveParsingTable(parsingTable, grammarPath); err != nil {
-		return nil, err
+	parsingTable.GrammarHash = grammarHash
+	return parsingTable, nil
+}
+
+// hashGrammar calculates the hash of the grammar file at the given path
+func hashGrammar(grammarPath string) (string, error) {
+	grammar, err := ioutil.ReadFile(grammarPath)
+	if err != nil {
+		return "", err
 	}
 
-	return parsingTable, nil
+	h := sha256.Sum256(grammar)
+	return hex.EncodeToString(h[:]), nil
 }
 
 // loadParsingTable allows us to load a parsing table from a saved file which should
@@ -98,7 +173,14 @@ func loadParsingTable(grammarPath string) (*ParsingTable, error) {
 		return nil, err
 	}
 
-	decoder := gob.NewDecoder(f)
+	defer f.Close()
+
+	return decodeParsingTable(f)
+}
+
+// decodeParsingTable decodes a gob-encoded parsing table
+func decodeParsingTable(r io.Reader) (*ParsingTable, error) {
+	decoder := gob.NewDecoder(r)
 
 	ptable := &ParsingTable{}
 	if err := decoder.Decode(ptable); err != nil {
@@ -0,0 +1,149 @@
+package syntax
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encodeTestTable gob-encodes a parsing table with the given grammar hash and
+// a single rule with the given name (used to tell the tables apart)
+func encodeTestTable(t *testing.T, hash, rule string) []byte {
+	t.Helper()
+
+	buff := &bytes.Buffer{}
+	ptable := &ParsingTable{Rules: []*PTableRule{{Name: rule}}, GrammarHash: hash}
+	if err := gob.NewEncoder(buff).Encode(ptable); err != nil {
+		t.Fatal(err)
+	}
+
+	return buff.Bytes()
+}
+
+// setEmbeddedTable replaces the embedded parsing table for the duration of a
+// test
+func setEmbeddedTable(t *testing.T, table []byte) {
+	prev := embeddedParsingTable
+	embeddedParsingTable = table
+	t.Cleanup(func() { embeddedParsingTable = prev })
+}
+
+func TestNewParsingTableWithoutGrammar(t *testing.T) {
+	dir := t.TempDir()
+	grammarPath := filepath.Join(dir, "grammar.ebnf")
+	writeDiskTable := func(hash string) {
+		if err := os.WriteFile(filepath.Join(dir, "grammar.ptable"), encodeTestTable(t, hash, "disk"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		embedded []byte
+		diskHash string
+		want     string
+	}{
+		// the table on disk can't be checked so the embedded table is used
+		{"stale disk table", encodeTestTable(t, "new", "embedded"), "old", "embedded"},
+		{"matching disk table", encodeTestTable(t, "new", "embedded"), "new", "disk"},
+		{"no embedded table", nil, "old", "disk"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setEmbeddedTable(t, tc.embedded)
+			writeDiskTable(tc.diskHash)
+
+			ptable, err := NewParsingTable(grammarPath, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ptable.Rules[0].Name; got != tc.want {
+				t.Errorf("loaded the %s table; want the %s table", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewParsingTableWithGrammar(t *testing.T) {
+	grammarPath, err := filepath.Abs(filepath.Join("..", "..", "config", "grammar.ebnf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := hashGrammar(grammarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// copy the grammar so that the table on disk can be replaced
+	dir := t.TempDir()
+	grammar, err := os.ReadFile(grammarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grammarPath = filepath.Join(dir, "grammar.ebnf")
+	if err := os.WriteFile(grammarPath, grammar, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// an up to date table on disk is preferred over the embedded table
+	setEmbeddedTable(t, encodeTestTable(t, hash, "embedded"))
+	if err := os.WriteFile(filepath.Join(dir, "grammar.ptable"), encodeTestTable(t, hash, "disk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if ptable, err := NewParsingTable(grammarPath, false); err != nil || ptable.Rules[0].Name != "disk" {
+		t.Fatal("did not load the up to date table from disk")
+	}
+
+	// a stale embedded table is never used: the table is rebuilt and saved
+	setEmbeddedTable(t, encodeTestTable(t, "stale", "embedded"))
+	if err := os.WriteFile(filepath.Join(dir, "grammar.ptable"), encodeTestTable(t, "stale", "disk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ptable, err := NewParsingTable(grammarPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ptable.GrammarHash != hash || len(ptable.Rows) == 0 {
+		t.Fatal("did not rebuild the stale table")
+	}
+
+	if saved, err := loadParsingTable(grammarPath); err != nil || saved.GrammarHash != hash {
+		t.Error("did not save the rebuilt table")
+	}
+}
+
+// TestGeneratedParsingTableUpToDate checks that the table generated to be
+// embedded in the compiler was generated from the current grammar
+func TestGeneratedParsingTableUpToDate(t *testing.T) {
+	f, err := os.Open("grammar.ptable")
+	if os.IsNotExist(err) {
+		t.Skip("no parsing table has been generated for embedding")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	ptable, err := decodeParsingTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := hashGrammar(filepath.Join("..", "..", "config", "grammar.ebnf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ptable.GrammarHash != hash {
+		t.Error("the generated parsing table is out of date: run `go generate ./syntax`")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build embedptable
+// +build embedptable
+
+package syntax
+
+import (
+	// embed is only imported for the `go:embed` directive
+	_ "embed"
+)
+
+// embeddedParsingTable is the gob-encoded parsing table compiled into the
+// compiler.  To embed a table, run `go generate ./syntax` to generate
+// `grammar.ptable` in this directory and build with the `embedptable` tag.
+//
+//go:embed grammar.ptable
+var embeddedParsingTable []byte
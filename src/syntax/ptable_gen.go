@@ -52,6 +52,19 @@ type PTableBuilder struct {
 
 	// allows us to memoize first sets by nonterminals
 	firstSets map[string][]int
+
+	// conflicts stores all of the conflicts encountered while building the
+	// table (used to generate grammar reports)
+	conflicts []tableConflict
+}
+
+// tableConflict records a conflict between actions in a state of the table
+type tableConflict struct {
+	state, lookahead int
+
+	// reduceReduce indicates whether this is a reduce/reduce conflict as
+	// opposed to a shift/reduce conflict (which is resolved as a shift)
+	reduceReduce bool
 }
 
 // build uses the current builds a full parsing table for a given rule table
@@ -507,6 +520,7 @@ func (ptb *PTableBuilder) buildTableFromSets() bool {
 							// }
 
 							// Shift-Reduce Conflict -- all accounted for
+							ptb.conflicts = append(ptb.conflicts, tableConflict{state: i, lookahead: lookahead})
 						} else if action.Kind == AKReduce {
 							oldRule, newRule := ptb.Table.Rules[action.Operand], ptb.Table.Rules[reduceRule]
 
@@ -543,6 +557,8 @@ func (ptb *PTableBuilder) buildTableFromSets() bool {
 								continue
 							}
 
+							ptb.conflicts = append(ptb.conflicts, tableConflict{state: i, lookahead: lookahead, reduceReduce: true})
+
 							fmt.Printf("Reduce/Reduce Conflict Between `%s` and `%s`\n", oldRule.Name, newRule.Name)
 							tableConstructionSucceeded = false
 						}
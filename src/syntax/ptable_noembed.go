@@ -0,0 +1,8 @@
+//go:build !embedptable
+// +build !embedptable
+
+package syntax
+
+// embeddedParsingTable is `nil` since no parsing table was embedded in the
+// compiler (see `ptable_embed.go`)
+var embeddedParsingTable []byte
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"whirlwind/logging"
 )
@@ -19,7 +20,13 @@ func NewScanner(fpath string, lctx *logging.LogContext) (*Scanner, bool) {
 		return nil, false
 	}
 
-	s := &Scanner{file: bufio.NewReader(f), fpath: fpath, line: 1, lctx: lctx}
+	s := &Scanner{
+		file:        bufio.NewReader(f),
+		fpath:       fpath,
+		line:        1,
+		lctx:        lctx,
+		docComments: make(map[int]string),
+	}
 	return s, true
 }
 
@@ -66,11 +73,60 @@ type Scanner struct {
 	// DEDENT and some other token need to be emitted b/c the next line was
 	// determined to hold content but its first token was already consumed
 	auxLookahead *Token
+
+	// docLines stores the lines of the block of line comments that was most
+	// recently scanned.  docEndLine is the line the last of them occurred on.
+	docLines   []string
+	docEndLine int
+
+	// lastTokenLine is the line of the last meaningful token read (used to
+	// distinguish comments trailing code from comments on their own lines)
+	lastTokenLine int
+
+	// docComments maps the line of the first token after a block of line
+	// comments to the text of that block: the comments are doc comments for
+	// whatever begins on that line
+	docComments map[int]string
 }
 
 // ReadToken reads a single token from the stream.  True indicates that there is
 // a token to be read/processed
 func (s *Scanner) ReadToken() (*Token, bool) {
+	tok, ok := s.readToken()
+
+	if ok && tok != nil {
+		s.trackDocComment(tok)
+	}
+
+	return tok, ok
+}
+
+// DocCommentAt gets the doc comment immediately preceding the given line (if
+// there is one)
+func (s *Scanner) DocCommentAt(line int) (string, bool) {
+	doc, ok := s.docComments[line]
+	return doc, ok
+}
+
+// trackDocComment attaches the current block of doc comments to the line of
+// the token if the token immediately follows it.  Whitespace tokens are ignored
+// since they can occur between a comment and the code it documents.
+func (s *Scanner) trackDocComment(tok *Token) {
+	switch tok.Kind {
+	case NEWLINE, INDENT, DEDENT:
+		return
+	}
+
+	if len(s.docLines) > 0 && tok.Line == s.docEndLine+1 {
+		s.docComments[tok.Line] = strings.Join(s.docLines, "\n")
+	}
+
+	s.docLines = nil
+	s.lastTokenLine = tok.Line
+}
+
+// readToken reads the next token from the stream (see ReadToken)
+func (s *Scanner) readToken() (*Token, bool) {
 	// check the lookahead before yielding a token
 	if next := s.readLookahead(); next != nil {
 		return next, true
@@ -142,7 +198,7 @@ func (s *Scanner) ReadToken() (*Token, bool) {
 					// one indent on the first measured indentation)
 					s.indentLevel = 1
 
-					tok = s.makeToken(INDENT, string(1))
+					tok = s.makeToken(INDENT, string(rune(1)))
 				} else {
 					// otherwise, calculate the equivalent indentation based on
 					// the known space-based indentation mode
@@ -156,10 +212,10 @@ func (s *Scanner) ReadToken() (*Token, bool) {
 
 					// the change is negative, the indent level decreased
 					if levelDiff < 0 {
-						tok = s.makeToken(DEDENT, string(-levelDiff))
+						tok = s.makeToken(DEDENT, string(rune(-levelDiff)))
 					} else if levelDiff > 0 {
 						// if it is positive, indent level increased
-						tok = s.makeToken(INDENT, string(levelDiff))
+						tok = s.makeToken(INDENT, string(rune(levelDiff)))
 					} else if next := s.readLookahead(); next != nil {
 						// if there was no change, we need to check to see if
 						// the lookahead is populated, if it is, we should
@@ -221,9 +277,9 @@ func (s *Scanner) ReadToken() (*Token, bool) {
 				s.indentLevel = level // update level now that we don't need it
 
 				if levelDiff < 0 {
-					tok = s.makeToken(DEDENT, string(-levelDiff))
+					tok = s.makeToken(DEDENT, string(rune(-levelDiff)))
 				} else if levelDiff > 0 {
-					tok = s.makeToken(INDENT, string(levelDiff))
+					tok = s.makeToken(INDENT, string(rune(levelDiff)))
 				} else if next := s.readLookahead(); next != nil {
 					// if there was no change, we need to check to see if the
 					// lookahead is populated, if it is, we should return it
@@ -313,7 +369,7 @@ func (s *Scanner) ReadToken() (*Token, bool) {
 			continue
 		default:
 			// check for identifiers
-			if IsLetter(s.curr) || s.curr == '_' {
+			if IsIdentStart(s.curr) {
 				tok = s.readWord()
 			} else if IsDigit(s.curr) {
 				// check numeric literals
@@ -341,20 +397,20 @@ func (s *Scanner) ReadToken() (*Token, bool) {
 			}
 		}
 
-		// discard the built contents for the current scanned token
-		s.tokBuilder.Reset()
-
 		// error out on any malformed tokens (using contents of token builder)
 		if malformed {
 			logging.LogCompileError(
 				s.lctx,
 				fmt.Sprintf("Malformed Token: `%s`", s.tokBuilder.String()),
 				logging.LMKToken,
-				&logging.TextPosition{StartLn: s.line, StartCol: s.col, EndLn: s.line, EndCol: s.col + s.tokBuilder.Len()},
+				&logging.TextPosition{StartLn: s.line, StartCol: s.col - sourceWidth(s.tokBuilder.String()), EndLn: s.line, EndCol: s.col},
 			)
 			return nil, false
 		}
 
+		// discard the built contents for the current scanned token
+		s.tokBuilder.Reset()
+
 		// if we reach here, we do not need to update the indentation (another
 		// meaningful token was encountered => no more indentation counting)
 		s.updateIndentLevel = false
@@ -367,7 +423,7 @@ func (s *Scanner) ReadToken() (*Token, bool) {
 	// token in the lookahead (this section should only run once)
 	s.lookahead = &Token{Kind: EOF}
 
-	return s.makeToken(DEDENT, string(s.indentLevel)), true
+	return s.makeToken(DEDENT, string(rune(s.indentLevel))), true
 }
 
 // UnreadToken is used to undo the preprocessor read the occurs at the start of
@@ -427,18 +483,20 @@ func (s *Scanner) readNext() bool {
 	s.tokBuilder.WriteRune(r)
 	s.curr = r
 
-	if r == '\t' {
-		// Whirlwind makes the executive decision to count tabs as four spaces
-		// (this is only really meaningful for display purposes -- Whirlwind
-		// highlights the error)
-		s.col += 4
-	} else {
-		s.col++
-	}
+	// columns are counted in characters: how wide a character is displayed
+	// (tabs, wide characters, etc.) is handled when the error is displayed
+	s.col++
 
 	return true
 }
 
+// sourceWidth calculates the number of columns a piece of source text spans.
+// Columns are counted in characters (not bytes) in the same way `readNext`
+// counts them.
+func sourceWidth(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
 // same behavior as readNext but doesn't populate the token builder used for
 // comments where it makes sense
 func (s *Scanner) skipNext() bool {
@@ -460,6 +518,7 @@ func (s *Scanner) skipNext() bool {
 	}
 
 	s.curr = r
+	s.col++
 	return true
 }
 
@@ -486,25 +545,27 @@ func (s *Scanner) readWord() *Token {
 	// the token builder (guaranteed by caller or previous loop cycle). we then
 	// use a look-ahead to check if the next token will be valid. If it is, we
 	// continue looping (and the logic outlined above holds). If not, we exit.
-	// Additionally, if at any point in the middle of the word, we encounter a
-	// digit or an underscore, we know we are not reading a keyword and set the
-	// corresponding flag.  This function is never called on words that begin
-	// with numbers so no need to check for first-character rules in it.
+	// Additionally, if at any point in the middle of the word, we encounter
+	// anything other than an ASCII letter (eg. a digit or an underscore), we
+	// know we are not reading a keyword and set the corresponding flag.  This
+	// function is never called on words that begin with numbers so no need to
+	// check for first-character rules in it.
 	for {
 		c, more := s.peek()
 
-		if !more {
+		if !more || !IsIdentContinue(c) {
 			break
-		} else if IsDigit(c) || c == '_' {
-			keywordValid = false
 		} else if !IsLetter(c) {
-			break
+			keywordValid = false
 		}
 
 		s.readNext()
 	}
 
-	tokValue := s.tokBuilder.String()
+	// the width is calculated before normalization since the token should span
+	// the identifier as it is written in the source text
+	width := sourceWidth(s.tokBuilder.String())
+	tokValue := NormalizeIdentifier(s.tokBuilder.String())
 
 	// if a keyword is possible and our current token value matches a keyword
 	// pattern, create a new keyword token from the token builder
@@ -515,7 +576,9 @@ func (s *Scanner) readWord() *Token {
 	}
 
 	// otherwise, assume that it is just an identifier and act accordingly
-	return s.makeToken(IDENTIFIER, tokValue)
+	tok := s.makeToken(IDENTIFIER, tokValue)
+	tok.width = width
+	return tok
 }
 
 // read in a floating point or integral number
@@ -856,7 +919,22 @@ func (s *Scanner) readRawStringLiteral() (*Token, bool) {
 }
 
 func (s *Scanner) skipLineComment() bool {
+	commentLine := s.line
+	commentBuilder := strings.Builder{}
 	for s.skipNext() && s.curr != '\n' {
+		commentBuilder.WriteRune(s.curr)
+	}
+
+	// comments that trail code are never doc comments
+	if commentLine != s.lastTokenLine {
+		// a gap between comments starts a new block
+		if commentLine != s.docEndLine+1 {
+			s.docLines = nil
+		}
+
+		comment := strings.TrimSuffix(commentBuilder.String(), "\r")
+		s.docLines = append(s.docLines, strings.TrimPrefix(comment, " "))
+		s.docEndLine = commentLine
 	}
 
 	// make sure the scanner properly handles the newline
@@ -896,7 +974,7 @@ func (s *Scanner) processNewline() bool {
 			// make sure that the token following the non-blank line is not
 			// discarded/lost by using the auxilliary lookahead
 			s.auxLookahead = s.lookahead
-			s.lookahead = s.makeToken(DEDENT, string(s.indentLevel))
+			s.lookahead = s.makeToken(DEDENT, string(rune(s.indentLevel)))
 			s.indentLevel = 0
 		}
 
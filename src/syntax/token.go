@@ -6,6 +6,11 @@ type Token struct {
 	Value string
 	Line  int
 	Col   int
+
+	// width is the number of columns the token spans in the source text if it
+	// differs from the number of characters in its value (eg. for normalized
+	// identifiers).  Zero means the value spans the token.
+	width int
 }
 
 // The various kinds of a tokens supported by the scanner
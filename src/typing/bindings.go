@@ -47,6 +47,10 @@ func (b *Binding) ClearWildcards() {
 // no conflict is found, an empty string and the return flag `false` are
 // returned
 func (br *BindingRegistry) CheckBindingConflicts(nb *Binding) (string, bool) {
+	// the wildcards of the bindings are shared
+	sharedStateMutex.Lock()
+	defer sharedStateMutex.Unlock()
+
 	getInterfType := func(b *Binding) *InterfType {
 		switch v := b.TypeInterf.(type) {
 		case *InterfType:
@@ -82,6 +86,10 @@ func (br *BindingRegistry) CheckBindingConflicts(nb *Binding) (string, bool) {
 
 // GetBindings fetches all applicable interface bindings for a given type
 func (s *Solver) GetBindings(br *BindingRegistry, dt DataType) []*InterfType {
+	// the wildcards of the bindings are shared
+	s.lockSharedState()
+	defer s.unlockSharedState()
+
 	var matches []*InterfType
 
 	for _, binding := range br.Bindings {
@@ -123,6 +131,10 @@ func (s *Solver) ImplementsInterf(dt DataType, it *InterfType) bool {
 		return false
 	}
 
+	// the instances of the interface are shared
+	s.lockSharedState()
+	defer s.unlockSharedState()
+
 	if ContainsType(dt, it.Instances) {
 		return true
 	}
@@ -163,6 +175,11 @@ outerloop:
 // This should be called after `ImplementsInterf` is called to check the
 // derivation.
 func (s *Solver) Derive(it, deriving *InterfType) {
+	s.lockSharedState()
+	defer s.unlockSharedState()
+
+	it.Implements = append(it.Implements, deriving)
+
 	for name, method := range deriving.Methods {
 		if imethod, ok := it.Methods[name]; ok {
 			// we can assume that if the `ImplementsInterf` check passed, then
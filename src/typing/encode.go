@@ -0,0 +1,795 @@
+package typing
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Type Encoding
+// -------------
+// Data types are encoded into a flat table of type nodes that refer to each
+// other by index (a `TypeRef`).  Every data type that is stored by reference
+// is only encoded once so any shared references (eg. the type parameters of a
+// generic) are preserved when the table is decoded.  This also makes the
+// encoding safe for cyclic types (eg. self-referential structs and algebraic
+// types whose variants refer back to their parent).  The table is designed to
+// be serialized as JSON.
+//
+// Several fields of the data types are not encoded because they are just
+// caches that will be rebuilt as they are needed: the instances of interfaces
+// and generics and the parametric instances of method specializations.  Opaque
+// and unknown types are encoded as the types they evaluated to: an unevaluated
+// opaque or unknown type cannot be encoded.
+//
+// The package IDs of named types are encoded as is.  Since package IDs depend
+// on where a package is located, a decoder can be given a mapping of package
+// IDs to update them when the table is decoded somewhere else.
+
+// TypeRef is a reference to a type node in a type table.  It is the index of
+// the node plus one so that the zero value can be used to indicate no type.
+type TypeRef int
+
+// TypeTable is the encoded form of a set of data types
+type TypeTable struct {
+	Nodes []*TypeNode `json:"nodes"`
+}
+
+// TypeNode is a single, encoded data type.  Only the fields that are relevant
+// to its kind are populated.
+type TypeNode struct {
+	Kind string `json:"kind"`
+
+	// Name and PackageID are used by all named types (structs, interfaces, etc.)
+	Name      string `json:"name,omitempty"`
+	PackageID uint   `json:"package_id,omitempty"`
+
+	PrimKind uint8 `json:"prim_kind,omitempty"`
+	PrimSpec uint8 `json:"prim_spec,omitempty"`
+
+	// Elem is the single inner type of a type: eg. the element type of a
+	// reference, the return type of a function, the template of a generic, the
+	// parent of an algebraic variant or the bound type of a type interface
+	Elem TypeRef `json:"elem,omitempty"`
+
+	// Elems is the list of inner types of a type: eg. the types of a tuple, the
+	// type parameters of a generic or the values of an algebraic variant
+	Elems []TypeRef `json:"elems,omitempty"`
+
+	// Generate is the memoized generate of a generic instance
+	Generate TypeRef `json:"generate,omitempty"`
+
+	// Size is the size of a vector or the variant position of a generic
+	// algebraic variant
+	Size uint `json:"size,omitempty"`
+
+	// Values are the fields of a struct or the arguments of a function
+	Values []*ValueNode `json:"values,omitempty"`
+
+	// Methods are the methods of an interface (sorted by name)
+	Methods []*MethodNode `json:"methods,omitempty"`
+
+	// Flags stores all of the boolean properties of a type by name
+	Flags []string `json:"flags,omitempty"`
+}
+
+// ValueNode is an encoded struct field or function argument
+type ValueNode struct {
+	Name  string   `json:"name,omitempty"`
+	Type  TypeRef  `json:"type"`
+	Flags []string `json:"flags,omitempty"`
+}
+
+// MethodNode is an encoded interface method
+type MethodNode struct {
+	Name            string                `json:"name"`
+	Signature       TypeRef               `json:"signature"`
+	Kind            int                   `json:"kind"`
+	Specializations []*SpecializationNode `json:"specializations,omitempty"`
+	DocComment      string                `json:"doc_comment,omitempty"`
+}
+
+// SpecializationNode is an encoded generic method specialization
+type SpecializationNode struct {
+	MatchingTypes []TypeRef `json:"matching_types"`
+	Parametric    bool      `json:"parametric,omitempty"`
+}
+
+// The names of the type node kinds
+const (
+	tnkPrimitive      = "prim"
+	tnkTuple          = "tuple"
+	tnkVector         = "vector"
+	tnkRef            = "ref"
+	tnkFunc           = "func"
+	tnkStruct         = "struct"
+	tnkInterf         = "interf"
+	tnkAlgebraic      = "algebraic"
+	tnkVariant        = "variant"
+	tnkAlias          = "alias"
+	tnkGeneric        = "generic"
+	tnkWildcard       = "wildcard"
+	tnkGenericInst    = "generic_instance"
+	tnkGenericVariant = "generic_variant"
+	tnkConstraint     = "constraint"
+)
+
+// hasFlag checks if a list of flags contains a given flag
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// makeFlags creates a list of flags from a map of flag names and values
+func makeFlags(flags map[string]bool) []string {
+	var names []string
+	for name, set := range flags {
+		if set {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// -----------------------------------------------------------------------------
+
+// TypeEncoder encodes data types into a type table
+type TypeEncoder struct {
+	Table *TypeTable
+
+	// refs stores the type refs of all the data types that have already been
+	// encoded by their pointer.  Tuples are not stored here since they are not
+	// stored by reference (and can't be used as map keys).
+	refs map[DataType]TypeRef
+}
+
+// NewTypeEncoder creates a new type encoder with an empty type table
+func NewTypeEncoder() *TypeEncoder {
+	return &TypeEncoder{Table: &TypeTable{}, refs: make(map[DataType]TypeRef)}
+}
+
+// Encode encodes a data type into the type table and returns a reference to
+// it.  If the type (or any of its inner types) can't be encoded, an error is
+// returned.
+func (te *TypeEncoder) Encode(dt DataType) (TypeRef, error) {
+	if dt == nil {
+		return 0, nil
+	}
+
+	// encode the types that stand in for other types as the types they stand
+	// in for: they aren't meaningful on their own
+	switch v := dt.(type) {
+	case *OpaqueType:
+		if v.EvalType == nil {
+			return 0, fmt.Errorf("unable to encode unevaluated opaque type `%s`", v.Name)
+		}
+
+		return te.Encode(v.EvalType)
+	case *OpaqueGenericType:
+		if v.EvalType == nil {
+			return 0, fmt.Errorf("unable to encode unevaluated opaque generic")
+		}
+
+		return te.Encode(v.EvalType)
+	case *OpaqueGenericInstanceType:
+		if v.OpaqueGeneric.EvalType == nil || v.MemoizedGenerate == nil {
+			return 0, fmt.Errorf("unable to encode unevaluated opaque generic instance")
+		}
+
+		return te.encodeGenericInstance(dt, v.OpaqueGeneric.EvalType, v.TypeParams, v.MemoizedGenerate)
+	case *UnknownType:
+		if v.EvalType == nil {
+			return 0, fmt.Errorf("unable to encode undetermined type")
+		}
+
+		return te.Encode(v.EvalType)
+	case TupleType:
+		ref, node := te.newNode(nil, tnkTuple)
+		elems, err := te.encodeSlice(v)
+		node.Elems = elems
+		return ref, err
+	}
+
+	if ref, ok := te.refs[dt]; ok {
+		return ref, nil
+	}
+
+	switch v := dt.(type) {
+	case *PrimitiveType:
+		ref, node := te.newNode(dt, tnkPrimitive)
+		node.PrimKind = v.PrimKind
+		node.PrimSpec = v.PrimSpec
+		return ref, nil
+	case *VectorType:
+		ref, node := te.newNode(dt, tnkVector)
+		node.Size = v.Size
+
+		var err error
+		node.Elem, err = te.Encode(v.ElemType)
+		return ref, err
+	case *RefType:
+		ref, node := te.newNode(dt, tnkRef)
+		node.Flags = makeFlags(map[string]bool{"constant": v.Constant})
+
+		var err error
+		node.Elem, err = te.Encode(v.ElemType)
+		return ref, err
+	case *FuncType:
+		ref, node := te.newNode(dt, tnkFunc)
+		node.Flags = makeFlags(map[string]bool{"boxed": v.Boxed, "boxable": v.Boxable, "async": v.Async})
+
+		for _, arg := range v.Args {
+			argNode, err := te.encodeValue(arg.Name, arg.Val, map[string]bool{"optional": arg.Optional, "indefinite": arg.Indefinite})
+			if err != nil {
+				return 0, err
+			}
+
+			node.Values = append(node.Values, argNode)
+		}
+
+		var err error
+		node.Elem, err = te.Encode(v.ReturnType)
+		return ref, err
+	case *StructType:
+		ref, node := te.newNode(dt, tnkStruct)
+		node.Name, node.PackageID = v.Name, v.SrcPackageID
+		node.Flags = makeFlags(map[string]bool{"packed": v.Packed})
+
+		// the fields are encoded in the order they were declared so that the
+		// layout of the struct is preserved
+		for _, name := range v.FieldOrder {
+			fieldNode, err := te.encodeValue(name, v.Fields[name], nil)
+			if err != nil {
+				return 0, err
+			}
+
+			node.Values = append(node.Values, fieldNode)
+		}
+
+		if v.Inherit != nil {
+			var err error
+			node.Elem, err = te.Encode(v.Inherit)
+			return ref, err
+		}
+
+		return ref, nil
+	case *InterfType:
+		ref, node := te.newNode(dt, tnkInterf)
+		node.Name, node.PackageID = v.Name, v.SrcPackageID
+
+		methodNames := make([]string, 0, len(v.Methods))
+		for name := range v.Methods {
+			methodNames = append(methodNames, name)
+		}
+
+		sort.Strings(methodNames)
+		for _, name := range methodNames {
+			method := v.Methods[name]
+
+			sigRef, err := te.Encode(method.Signature)
+			if err != nil {
+				return 0, err
+			}
+
+			methodNode := &MethodNode{Name: name, Signature: sigRef, Kind: method.Kind, DocComment: method.DocComment}
+			for _, spec := range method.Specializations {
+				matchingRefs, err := te.encodeSlice(spec.MatchingTypes)
+				if err != nil {
+					return 0, err
+				}
+
+				methodNode.Specializations = append(methodNode.Specializations, &SpecializationNode{
+					MatchingTypes: matchingRefs,
+					Parametric:    spec.ParametricInstances != nil,
+				})
+			}
+
+			node.Methods = append(node.Methods, methodNode)
+		}
+
+		for _, implement := range v.Implements {
+			implRef, err := te.Encode(implement)
+			if err != nil {
+				return 0, err
+			}
+
+			node.Elems = append(node.Elems, implRef)
+		}
+
+		if v.BoundType != nil {
+			var err error
+			node.Elem, err = te.Encode(v.BoundType)
+			return ref, err
+		}
+
+		return ref, nil
+	case *AlgebraicType:
+		ref, node := te.newNode(dt, tnkAlgebraic)
+		node.Name, node.PackageID = v.Name, v.SrcPackageID
+		node.Flags = makeFlags(map[string]bool{"closed": v.Closed})
+
+		// the variants are encoded as their own nodes so that the variants
+		// stored in symbols share their references with the parent type
+		for _, vari := range v.Variants {
+			variRef, err := te.Encode(vari)
+			if err != nil {
+				return 0, err
+			}
+
+			node.Elems = append(node.Elems, variRef)
+		}
+
+		return ref, nil
+	case *AlgebraicVariant:
+		ref, node := te.newNode(dt, tnkVariant)
+		node.Name = v.Name
+
+		elems, err := te.encodeSlice(v.Values)
+		if err != nil {
+			return 0, err
+		}
+
+		node.Elems = elems
+		node.Elem, err = te.Encode(v.Parent)
+		return ref, err
+	case *AliasType:
+		ref, node := te.newNode(dt, tnkAlias)
+		node.Name, node.PackageID = v.Name, v.SrcPackageID
+
+		var err error
+		node.Elem, err = te.Encode(v.TrueType)
+		return ref, err
+	case *GenericType:
+		ref, node := te.newNode(dt, tnkGeneric)
+
+		for _, tp := range v.TypeParams {
+			tpRef, err := te.Encode(tp)
+			if err != nil {
+				return 0, err
+			}
+
+			node.Elems = append(node.Elems, tpRef)
+		}
+
+		var err error
+		node.Elem, err = te.Encode(v.Template)
+		return ref, err
+	case *WildcardType:
+		ref, node := te.newNode(dt, tnkWildcard)
+		node.Name = v.Name
+		node.Flags = makeFlags(map[string]bool{"immediate_bind": v.ImmediateBind})
+
+		elems, err := te.encodeSlice(v.Constraints)
+		if err != nil {
+			return 0, err
+		}
+
+		node.Elems = elems
+		node.Elem, err = te.Encode(v.Value)
+		return ref, err
+	case *GenericInstanceType:
+		return te.encodeGenericInstance(dt, v.Generic, v.TypeParams, v.MemoizedGenerate)
+	case *GenericAlgebraicVariantType:
+		ref, node := te.newNode(dt, tnkGenericVariant)
+		node.Size = uint(v.VariantPos)
+
+		var err error
+		node.Elem, err = te.Encode(v.GenericParent)
+		return ref, err
+	case *ConstraintType:
+		ref, node := te.newNode(dt, tnkConstraint)
+		node.Name = v.Name
+		node.Flags = makeFlags(map[string]bool{"intrinsic": v.Intrinsic})
+
+		elems, err := te.encodeSlice(v.Types)
+		node.Elems = elems
+		return ref, err
+	}
+
+	return 0, fmt.Errorf("unable to encode type `%s`", dt.Repr())
+}
+
+// newNode adds a new node of the given kind to the type table.  If `dt` is not
+// `nil`, the reference to the new node is stored as the reference for `dt`.
+// This must be done before any of the inner types are encoded so that cyclic
+// references resolve to the new node.
+func (te *TypeEncoder) newNode(dt DataType, kind string) (TypeRef, *TypeNode) {
+	node := &TypeNode{Kind: kind}
+	te.Table.Nodes = append(te.Table.Nodes, node)
+
+	ref := TypeRef(len(te.Table.Nodes))
+	if dt != nil {
+		te.refs[dt] = ref
+	}
+
+	return ref, node
+}
+
+// encodeSlice encodes a slice of data types
+func (te *TypeEncoder) encodeSlice(dts []DataType) ([]TypeRef, error) {
+	var refs []TypeRef
+	for _, dt := range dts {
+		ref, err := te.Encode(dt)
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// encodeValue encodes a typed value as a value node with the given name and
+// additional flags
+func (te *TypeEncoder) encodeValue(name string, tv *TypedValue, flags map[string]bool) (*ValueNode, error) {
+	ref, err := te.Encode(tv.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags == nil {
+		flags = make(map[string]bool)
+	}
+
+	flags["constant"] = tv.Constant
+	flags["volatile"] = tv.Volatile
+
+	return &ValueNode{Name: name, Type: ref, Flags: makeFlags(flags)}, nil
+}
+
+// encodeGenericInstance encodes a generic instance (or an evaluated opaque
+// generic instance)
+func (te *TypeEncoder) encodeGenericInstance(dt DataType, generic *GenericType, typeParams []DataType, generate DataType) (TypeRef, error) {
+	ref, node := te.newNode(dt, tnkGenericInst)
+
+	var err error
+	if node.Elem, err = te.Encode(generic); err != nil {
+		return 0, err
+	}
+
+	if node.Elems, err = te.encodeSlice(typeParams); err != nil {
+		return 0, err
+	}
+
+	node.Generate, err = te.Encode(generate)
+	return ref, err
+}
+
+// -----------------------------------------------------------------------------
+
+// TypeDecoder decodes data types from a type table
+type TypeDecoder struct {
+	table *TypeTable
+
+	// types stores the data types that have already been decoded (or are being
+	// decoded) by their reference
+	types map[TypeRef]DataType
+
+	// PackageIDs maps the package IDs stored in the type table to the package
+	// IDs that should be given to the decoded types.  Package IDs that are not
+	// in this map are left unchanged.
+	PackageIDs map[uint]uint
+}
+
+// NewTypeDecoder creates a new type decoder for the given type table
+func NewTypeDecoder(table *TypeTable) *TypeDecoder {
+	return &TypeDecoder{table: table, types: make(map[TypeRef]DataType), PackageIDs: make(map[uint]uint)}
+}
+
+// Decode decodes the data type referenced by the given type ref.  An error is
+// returned if the type table is malformed.
+func (td *TypeDecoder) Decode(ref TypeRef) (DataType, error) {
+	if ref == 0 {
+		return nil, nil
+	}
+
+	if dt, ok := td.types[ref]; ok {
+		return dt, nil
+	}
+
+	if int(ref) < 0 || int(ref) > len(td.table.Nodes) {
+		return nil, fmt.Errorf("invalid type reference: %d", ref)
+	}
+
+	node := td.table.Nodes[ref-1]
+	if pkgID, ok := td.PackageIDs[node.PackageID]; ok {
+		node = &TypeNode{}
+		*node = *td.table.Nodes[ref-1]
+		node.PackageID = pkgID
+	}
+
+	// every type that is stored by reference is created and stored before its
+	// inner types are decoded so that cyclic references resolve to it
+	switch node.Kind {
+	case tnkPrimitive:
+		pt := &PrimitiveType{PrimKind: node.PrimKind, PrimSpec: node.PrimSpec}
+		td.types[ref] = pt
+		return pt, nil
+	case tnkTuple:
+		elems, err := td.decodeSlice(node.Elems)
+		if err != nil {
+			return nil, err
+		}
+
+		// tuples are never referenced more than once so we don't store them
+		return TupleType(elems), nil
+	case tnkVector:
+		vt := &VectorType{Size: node.Size}
+		td.types[ref] = vt
+
+		var err error
+		vt.ElemType, err = td.decodeRequired(node.Elem)
+		return vt, err
+	case tnkRef:
+		rt := &RefType{Constant: hasFlag(node.Flags, "constant")}
+		td.types[ref] = rt
+
+		var err error
+		rt.ElemType, err = td.decodeRequired(node.Elem)
+		return rt, err
+	case tnkFunc:
+		ft := &FuncType{
+			Boxed:   hasFlag(node.Flags, "boxed"),
+			Boxable: hasFlag(node.Flags, "boxable"),
+			Async:   hasFlag(node.Flags, "async"),
+		}
+		td.types[ref] = ft
+
+		for _, argNode := range node.Values {
+			val, err := td.decodeValue(argNode)
+			if err != nil {
+				return nil, err
+			}
+
+			ft.Args = append(ft.Args, &FuncArg{
+				Name:       argNode.Name,
+				Val:        val,
+				Optional:   hasFlag(argNode.Flags, "optional"),
+				Indefinite: hasFlag(argNode.Flags, "indefinite"),
+			})
+		}
+
+		var err error
+		ft.ReturnType, err = td.decodeRequired(node.Elem)
+		return ft, err
+	case tnkStruct:
+		st := &StructType{
+			Name:         node.Name,
+			SrcPackageID: node.PackageID,
+			Fields:       make(map[string]*TypedValue),
+			Packed:       hasFlag(node.Flags, "packed"),
+		}
+		td.types[ref] = st
+
+		for _, fieldNode := range node.Values {
+			val, err := td.decodeValue(fieldNode)
+			if err != nil {
+				return nil, err
+			}
+
+			st.Fields[fieldNode.Name] = val
+			st.FieldOrder = append(st.FieldOrder, fieldNode.Name)
+		}
+
+		if node.Elem != 0 {
+			inherit, err := td.Decode(node.Elem)
+			if err != nil {
+				return nil, err
+			}
+
+			var ok bool
+			if st.Inherit, ok = inherit.(*StructType); !ok {
+				return nil, fmt.Errorf("struct `%s` inherits from a non-struct type", st.Name)
+			}
+		}
+
+		return st, nil
+	case tnkInterf:
+		it := &InterfType{
+			Name:         node.Name,
+			SrcPackageID: node.PackageID,
+			Methods:      make(map[string]*InterfMethod),
+		}
+		td.types[ref] = it
+
+		for _, methodNode := range node.Methods {
+			sig, err := td.decodeRequired(methodNode.Signature)
+			if err != nil {
+				return nil, err
+			}
+
+			method := &InterfMethod{Signature: sig, Kind: methodNode.Kind, DocComment: methodNode.DocComment}
+			for _, specNode := range methodNode.Specializations {
+				matchingTypes, err := td.decodeSlice(specNode.MatchingTypes)
+				if err != nil {
+					return nil, err
+				}
+
+				spec := &GenericSpecialization{MatchingTypes: matchingTypes}
+				if specNode.Parametric {
+					spec.ParametricInstances = &[][]DataType{}
+				}
+
+				method.Specializations = append(method.Specializations, spec)
+			}
+
+			it.Methods[methodNode.Name] = method
+		}
+
+		for _, implRef := range node.Elems {
+			impl, err := td.Decode(implRef)
+			if err != nil {
+				return nil, err
+			}
+
+			implInterf, ok := impl.(*InterfType)
+			if !ok {
+				return nil, fmt.Errorf("interface `%s` implements a non-interface type", it.Name)
+			}
+
+			it.Implements = append(it.Implements, implInterf)
+		}
+
+		boundType, err := td.Decode(node.Elem)
+		if err != nil {
+			return nil, err
+		}
+
+		it.BoundType = boundType
+		return it, nil
+	case tnkAlgebraic:
+		at := &AlgebraicType{Name: node.Name, SrcPackageID: node.PackageID, Closed: hasFlag(node.Flags, "closed")}
+		td.types[ref] = at
+
+		for _, variRef := range node.Elems {
+			vari, err := td.Decode(variRef)
+			if err != nil {
+				return nil, err
+			}
+
+			algVari, ok := vari.(*AlgebraicVariant)
+			if !ok {
+				return nil, fmt.Errorf("algebraic type `%s` has a non-variant variant", at.Name)
+			}
+
+			at.Variants = append(at.Variants, algVari)
+		}
+
+		return at, nil
+	case tnkVariant:
+		av := &AlgebraicVariant{Name: node.Name}
+		td.types[ref] = av
+
+		var err error
+		if av.Values, err = td.decodeSlice(node.Elems); err != nil {
+			return nil, err
+		}
+
+		av.Parent, err = td.decodeRequired(node.Elem)
+		return av, err
+	case tnkAlias:
+		at := &AliasType{Name: node.Name, SrcPackageID: node.PackageID}
+		td.types[ref] = at
+
+		var err error
+		at.TrueType, err = td.decodeRequired(node.Elem)
+		return at, err
+	case tnkGeneric:
+		gt := &GenericType{}
+		td.types[ref] = gt
+
+		for _, tpRef := range node.Elems {
+			tp, err := td.Decode(tpRef)
+			if err != nil {
+				return nil, err
+			}
+
+			wt, ok := tp.(*WildcardType)
+			if !ok {
+				return nil, fmt.Errorf("generic has a type parameter that is not a wildcard type")
+			}
+
+			gt.TypeParams = append(gt.TypeParams, wt)
+		}
+
+		var err error
+		gt.Template, err = td.decodeRequired(node.Elem)
+		return gt, err
+	case tnkWildcard:
+		wt := &WildcardType{Name: node.Name, ImmediateBind: hasFlag(node.Flags, "immediate_bind")}
+		td.types[ref] = wt
+
+		var err error
+		if wt.Constraints, err = td.decodeSlice(node.Elems); err != nil {
+			return nil, err
+		}
+
+		wt.Value, err = td.Decode(node.Elem)
+		return wt, err
+	case tnkGenericInst:
+		gi := &GenericInstanceType{}
+		td.types[ref] = gi
+
+		generic, err := td.decodeRequired(node.Elem)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		if gi.Generic, ok = generic.(*GenericType); !ok {
+			return nil, fmt.Errorf("generic instance of a non-generic type")
+		}
+
+		if gi.TypeParams, err = td.decodeSlice(node.Elems); err != nil {
+			return nil, err
+		}
+
+		gi.MemoizedGenerate, err = td.decodeRequired(node.Generate)
+		return gi, err
+	case tnkGenericVariant:
+		gavt := &GenericAlgebraicVariantType{VariantPos: int(node.Size)}
+		td.types[ref] = gavt
+
+		var err error
+		gavt.GenericParent, err = td.decodeRequired(node.Elem)
+		return gavt, err
+	case tnkConstraint:
+		ct := &ConstraintType{Name: node.Name, Intrinsic: hasFlag(node.Flags, "intrinsic")}
+		td.types[ref] = ct
+
+		var err error
+		ct.Types, err = td.decodeSlice(node.Elems)
+		return ct, err
+	}
+
+	return nil, fmt.Errorf("unknown type kind: `%s`", node.Kind)
+}
+
+// decodeRequired decodes a type reference that must not be empty
+func (td *TypeDecoder) decodeRequired(ref TypeRef) (DataType, error) {
+	if ref == 0 {
+		return nil, fmt.Errorf("missing required type")
+	}
+
+	return td.Decode(ref)
+}
+
+// decodeSlice decodes a slice of type references
+func (td *TypeDecoder) decodeSlice(refs []TypeRef) ([]DataType, error) {
+	if refs == nil {
+		return nil, nil
+	}
+
+	dts := make([]DataType, len(refs))
+	for i, ref := range refs {
+		dt, err := td.decodeRequired(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		dts[i] = dt
+	}
+
+	return dts, nil
+}
+
+// decodeValue decodes a value node into a typed value
+func (td *TypeDecoder) decodeValue(vn *ValueNode) (*TypedValue, error) {
+	dt, err := td.decodeRequired(vn.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedValue{
+		Type:     dt,
+		Constant: hasFlag(vn.Flags, "constant"),
+		Volatile: hasFlag(vn.Flags, "volatile"),
+	}, nil
+}
@@ -21,8 +21,8 @@ type GenericType struct {
 	TypeParams []*WildcardType
 
 	// Template is the data type that is used for generating the various
-	// instances of this generic type.  It contains empty WildcardTypes that are
-	// temporarily filled in and then copied.
+	// instances of this generic type.  Its WildcardTypes are never filled in:
+	// they are replaced by filled-in WildcardTypes when it is copied.
 	Template DataType
 
 	// Instances stores a list of the generic instance of this generic type so
@@ -76,33 +76,28 @@ func (gt *GenericType) equals(other DataType) bool {
 	return false
 }
 
-// overrideWildcardTemplateCopy is a map that should be nil by default that used
-// to store new WildcardType shared references in the event of a called to
-// copyTemplate on a GenericType (so that way the references can stay synced).
-// If this field is nil, then no override is necessary
-var overrideWildcardTemplateCopy map[string]*WildcardType
-
 // Since GenericTypes can be contained inside templates in the form of methods,
-// an actual duplication function is required
-func (gt *GenericType) copyTemplate() DataType {
-	overrideWildcardTemplateCopy = make(map[string]*WildcardType)
-	defer (func() {
-		overrideWildcardTemplateCopy = nil
-	})()
+// an actual duplication function is required.  The type parameters of the copy
+// are new wildcard types that replace the type parameters of the original in
+// the copy of its template (so that way the references can stay synced).
+func (gt *GenericType) copyTemplate(wcm wildcardMap) DataType {
+	innerWcm := make(wildcardMap, len(wcm)+len(gt.TypeParams))
+	for wt, replacement := range wcm {
+		innerWcm[wt] = replacement
+	}
 
 	newTypeParams := make([]*WildcardType, len(gt.TypeParams))
 	for i, tp := range gt.TypeParams {
-		// Value should always be `nil` if we are copying from a GenericType and
-		// since this should only occur in the cotnext of a method,
-		// ImmediateBind can be ignored and thus the default implementation of
+		// ImmediateBind can be ignored since this should only occur in the
+		// context of a method and thus the default implementation of
 		// copyTemplate for WildcardTypes is acceptable
-		newTypeParams[i] = tp.copyTemplate().(*WildcardType)
-		overrideWildcardTemplateCopy[tp.Name] = newTypeParams[i]
+		newTypeParams[i] = tp.copyTemplate(wcm).(*WildcardType)
+		innerWcm[tp] = newTypeParams[i]
 	}
 
 	return &GenericType{
 		TypeParams: newTypeParams,
-		Template:   gt.Template.copyTemplate(),
+		Template:   gt.Template.copyTemplate(innerWcm),
 		// Since a direct copy template on a generic can only occur inside of an
 		// interface, there is no need to copy a new set of instances since all
 		// old instances will recreated when the new generic bodies are
@@ -120,10 +115,14 @@ func (gt *GenericType) copyTemplate() DataType {
 // corresponding parameters. This function will log an appropriate error should
 // a generic be unable to be generated.
 func (s *Solver) CreateGenericInstance(gt *GenericType, typeParams []DataType, typeParamsBranch *syntax.ASTBranch) (DataType, bool) {
+	// the instances of the generic are shared
+	s.lockSharedState()
+	defer s.unlockSharedState()
+
 	if len(gt.TypeParams) != len(typeParams) {
 		logging.LogCompileError(
 			s.Context,
-			fmt.Sprintf("Generic `%s` expects `%d` type parameters; received `%d`", gt.Repr(), len(gt.TypeParams), len(typeParams)),
+			fmt.Sprintf("Generic `%s` expects `%d` type parameters; received `%d`", s.PackagePaths.ReprType(gt), len(gt.TypeParams), len(typeParams)),
 			logging.LMKTyping,
 			typeParamsBranch.Position(),
 		)
@@ -148,6 +147,10 @@ outerloop:
 		return instance, true
 	}
 
+	// the type parameters of the generic are replaced in the copy of its
+	// template by wildcard types holding the type values.  The template itself
+	// is never changed since it is shared by every package that uses it.
+	wcm := make(wildcardMap, len(gt.TypeParams))
 	for i, wt := range gt.TypeParams {
 		if len(wt.Constraints) > 0 {
 			matchedRestrictor := false
@@ -160,9 +163,10 @@ outerloop:
 			}
 
 			if !matchedRestrictor {
+				reprs := s.PackagePaths.ReprTypes(typeParams[i], gt)
 				logging.LogCompileError(
 					s.Context,
-					fmt.Sprintf("Type `%s` does not satisfy restrictor of type parameter `%s` of generic `%s`", typeParams[i].Repr(), wt.Name, gt.Repr()),
+					fmt.Sprintf("Type `%s` does not satisfy restrictor of type parameter `%s` of generic `%s`", reprs[0], wt.Name, reprs[1]),
 					logging.LMKTyping,
 					typeParamsBranch.Content[i*2].Position(),
 				)
@@ -170,15 +174,10 @@ outerloop:
 			}
 		}
 
-		wt.Value = typeParams[i]
+		wcm[wt] = &WildcardType{Name: wt.Name, Constraints: wt.Constraints, Value: typeParams[i]}
 	}
 
-	copy := gt.Template.copyTemplate()
-
-	// clear WildcardTypes after duplicate has been created
-	for _, wt := range gt.TypeParams {
-		wt.Value = nil
-	}
+	copy := gt.Template.copyTemplate(wcm)
 
 	// now, create and memoize our instance, then return
 	gi := &GenericInstanceType{
@@ -230,6 +229,8 @@ func (wt *WildcardType) equals(other DataType) bool {
 			return false
 		}
 
+		// only the wildcards of bindings bind immediately and they are only
+		// matched while the shared state lock is held
 		if wt.ImmediateBind {
 			wt.Value = other
 		}
@@ -240,10 +241,10 @@ func (wt *WildcardType) equals(other DataType) bool {
 	return Equals(wt.Value, other)
 }
 
-func (wt *WildcardType) copyTemplate() DataType {
-	// handle the override case
-	if overrideWildcardTemplateCopy != nil {
-		return overrideWildcardTemplateCopy[wt.Name]
+func (wt *WildcardType) copyTemplate(wcm wildcardMap) DataType {
+	// handle the replacement case
+	if replacement, ok := wcm[wt]; ok {
+		return replacement
 	}
 
 	return &WildcardType{
@@ -304,11 +305,11 @@ func (gi *GenericInstanceType) Repr() string {
 // copyTemplate will copy both the memoized generate and the type parameters as
 // both can contain wildcard types.  However, the root generic should stay the
 // same (as it unaffected in this context).
-func (gi *GenericInstanceType) copyTemplate() DataType {
+func (gi *GenericInstanceType) copyTemplate(wcm wildcardMap) DataType {
 	return &GenericInstanceType{
 		Generic:          gi.Generic,
-		MemoizedGenerate: gi.MemoizedGenerate.copyTemplate(),
-		TypeParams:       copyTemplateSlice(gi.TypeParams),
+		MemoizedGenerate: gi.MemoizedGenerate.copyTemplate(wcm),
+		TypeParams:       copyTemplateSlice(gi.TypeParams, wcm),
 	}
 }
 
@@ -361,9 +362,9 @@ func (gavt *GenericAlgebraicVariantType) Repr() string {
 	return "<opaque generic algebraic variant>"
 }
 
-func (gavt *GenericAlgebraicVariantType) copyTemplate() DataType {
+func (gavt *GenericAlgebraicVariantType) copyTemplate(wcm wildcardMap) DataType {
 	return &GenericAlgebraicVariantType{
-		GenericParent: gavt.GenericParent.copyTemplate().(*GenericType),
+		GenericParent: gavt.GenericParent.copyTemplate(wcm).(*GenericType),
 		VariantPos:    gavt.VariantPos,
 	}
 }
@@ -406,6 +407,17 @@ type ConstraintType struct {
 }
 
 func (ts *ConstraintType) Repr() string {
+	// constraints created during inference (eg. for numeric literals) have no
+	// name so their types are spelled out as a union
+	if ts.Name == "" {
+		reprs := make([]string, len(ts.Types))
+		for i, dt := range ts.Types {
+			reprs[i] = dt.Repr()
+		}
+
+		return strings.Join(reprs, " | ")
+	}
+
 	return ts.Name
 }
 
@@ -414,9 +426,9 @@ func (ts *ConstraintType) equals(other DataType) bool {
 	return false
 }
 
-func (ts *ConstraintType) copyTemplate() DataType {
+func (ts *ConstraintType) copyTemplate(wcm wildcardMap) DataType {
 	return &ConstraintType{
-		Types:     copyTemplateSlice(ts.Types),
+		Types:     copyTemplateSlice(ts.Types, wcm),
 		Intrinsic: ts.Intrinsic,
 	}
 }
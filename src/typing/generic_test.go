@@ -0,0 +1,127 @@
+package typing
+
+import (
+	"sync"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+// newBoxGeneric creates the generic `Box<T> { value: T }`
+func newBoxGeneric() *GenericType {
+	tp := &WildcardType{Name: "T"}
+
+	return &GenericType{
+		TypeParams: []*WildcardType{tp},
+		Template: &StructType{
+			Name:       "Box",
+			Fields:     map[string]*TypedValue{"value": {Type: tp}},
+			FieldOrder: []string{"value"},
+		},
+	}
+}
+
+// newPairGeneric creates the generic `Pair<T> { first, second: Box<T> }` along
+// with the `Box` generic it contains
+func newPairGeneric() (*GenericType, *GenericType) {
+	box := newBoxGeneric()
+	tp := &WildcardType{Name: "T"}
+
+	// the instance of `Box` in the template is that created for its field
+	boxOfT := &GenericInstanceType{
+		Generic:          box,
+		TypeParams:       []DataType{tp},
+		MemoizedGenerate: box.Template.copyTemplate(wildcardMap{box.TypeParams[0]: tp}),
+	}
+
+	return &GenericType{
+		TypeParams: []*WildcardType{tp},
+		Template: &StructType{
+			Name: "Pair",
+			Fields: map[string]*TypedValue{
+				"first":  {Type: boxOfT},
+				"second": {Type: boxOfT},
+			},
+			FieldOrder: []string{"first", "second"},
+		},
+	}, box
+}
+
+func newTestSolver() *Solver {
+	return NewSolver(&logging.LogContext{}, nil, nil, nil)
+}
+
+func TestCreateGenericInstance(t *testing.T) {
+	pair, box := newPairGeneric()
+	s := newTestSolver()
+
+	i32 := &PrimitiveType{PrimKind: PrimKindIntegral, PrimSpec: PrimIntI32}
+	gi, ok := s.CreateGenericInstance(pair, []DataType{i32}, nil)
+	if !ok {
+		t.Fatal("failed to create `Pair<i32>`")
+	}
+
+	// both fields share the copy of the type parameter of `Pair`
+	st := gi.(*GenericInstanceType).MemoizedGenerate.(*StructType)
+	first := st.Fields["first"].Type.(*GenericInstanceType)
+	second := st.Fields["second"].Type.(*GenericInstanceType)
+	if first.TypeParams[0] != second.TypeParams[0] {
+		t.Error("the fields of `Pair<i32>` do not share a type parameter")
+	}
+
+	if got := first.MemoizedGenerate.(*StructType).Fields["value"].Type.Repr(); got != "i32" {
+		t.Errorf("`value` of `first` is `%s`; want `i32`", got)
+	}
+
+	// the templates are never changed
+	for _, gt := range []*GenericType{pair, box} {
+		if gt.TypeParams[0].Value != nil {
+			t.Errorf("type parameter of `%s` was left bound to `%s`", gt.Template.Repr(), gt.TypeParams[0].Value.Repr())
+		}
+	}
+
+	// instances are memoized
+	if again, _ := s.CreateGenericInstance(pair, []DataType{i32}, nil); again != gi {
+		t.Error("`Pair<i32>` was created twice")
+	}
+}
+
+// TestCreateGenericInstanceConcurrently should be run with `-race`: the
+// generic is instantiated and printed by several solvers at once as it is when
+// packages are resolved and validated concurrently.
+func TestCreateGenericInstanceConcurrently(t *testing.T) {
+	pair, _ := newPairGeneric()
+
+	var wg sync.WaitGroup
+	for spec := uint8(PrimIntU8); spec <= PrimIntI64; spec++ {
+		wg.Add(1)
+
+		go func(spec uint8) {
+			defer wg.Done()
+
+			s := newTestSolver()
+			prim := &PrimitiveType{PrimKind: PrimKindIntegral, PrimSpec: spec}
+			for i := 0; i < 10; i++ {
+				gi, ok := s.CreateGenericInstance(pair, []DataType{prim}, nil)
+				if !ok {
+					t.Errorf("failed to create `Pair<%s>`", prim.Repr())
+					return
+				}
+
+				// reading the template must be safe while instances are created
+				_ = pair.Repr()
+
+				first := gi.(*GenericInstanceType).MemoizedGenerate.(*StructType).Fields["first"].Type
+				if got := first.(*GenericInstanceType).TypeParams[0].Repr(); got != prim.Repr() {
+					t.Errorf("`first` of `Pair<%s>` is `Box<%s>`", prim.Repr(), got)
+				}
+			}
+		}(spec)
+	}
+
+	wg.Wait()
+
+	if len(pair.Instances) != PrimIntI64+1 {
+		t.Errorf("`Pair` has %d instances; want %d", len(pair.Instances), PrimIntI64+1)
+	}
+}
@@ -0,0 +1,460 @@
+package typing
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Type Layouts
+// ------------
+// The layout of a data type is how its values are stored in memory on a given
+// target: their size, their alignment and the offsets of their fields.
+// Layouts follow the C ABI of the target (as LLVM does) so that they can be
+// used for FFI:
+//
+// - Structs and tuples store their fields in order with each field aligned to
+//   its own alignment.  They are aligned to their most aligned field and padded
+//   to a multiple of their alignment.  Packed structs have no padding and are
+//   aligned to a single byte.
+// - A struct that inherits from another struct stores that struct as its first
+//   field so that references to it can be used as references to its parent.
+// - References and functions are pointers.  Strings are a pointer to their
+//   bytes and their length.  Interfaces and `any` are a pointer to their value
+//   and a pointer to the method table of its type.
+// - Vectors are laid out like LLVM vectors: their size is rounded up to a power
+//   of two and they are aligned to their size (up to 16 bytes).
+// - Algebraic types store a tag (the index of their variant) followed by a
+//   payload large enough to store the values of any of their variants.  An
+//   algebraic type that contains itself (directly or through other types) is
+//   recursive: it is always stored by reference within other types (including
+//   itself) so that the layouts of types don't depend on the order in which
+//   they are computed.
+
+// Layout is the memory layout of a data type.  All sizes and offsets are in
+// bytes.
+type Layout struct {
+	Size  int
+	Align int
+
+	// Fields are the fields of a struct or the elements of a tuple in the order
+	// they are stored in memory.  The fields of the struct a struct inherits
+	// from come first.
+	Fields []*FieldLayout
+
+	// TagSize is the size of the tag of an algebraic type (which is always
+	// stored at the start of the type).  It is 0 for all other types.
+	TagSize int
+
+	// Variants are the layouts of the variants of an algebraic type in order
+	Variants []*VariantLayout
+}
+
+// FieldLayout is the layout of a single field within a type
+type FieldLayout struct {
+	// Name is the name of the field or its index if the field is unnamed (eg.
+	// the elements of tuples)
+	Name string
+
+	Type   DataType
+	Offset int
+	Size   int
+	Align  int
+}
+
+// VariantLayout is the layout of the values of a variant of an algebraic type.
+// The offsets of the values are from the start of the algebraic type.
+type VariantLayout struct {
+	Name   string
+	Tag    int
+	Fields []*FieldLayout
+}
+
+// LayoutService computes the layouts of data types for a target.  It memoizes
+// the layouts of named types so it should be reused for a given target.  It is
+// not safe for concurrent use.
+type LayoutService struct {
+	// Arch is the target architecture (eg. `amd64`)
+	Arch string
+
+	// PointerSize is the size (and alignment) of a pointer on the target
+	PointerSize int
+
+	// PackagePaths is the table of the import paths of packages used to
+	// qualify the named types in errors
+	PackagePaths PackagePaths
+
+	// layouts stores the layouts of the named types that have already been
+	// computed
+	layouts map[DataType]*Layout
+
+	// inProgress stores the named types whose layouts are currently being
+	// computed so that types that contain themselves can be detected
+	inProgress map[DataType]struct{}
+
+	// recursive stores whether each algebraic type that has been checked
+	// contains itself
+	recursive map[*AlgebraicType]bool
+}
+
+// NewLayoutService creates a new layout service for a target
+func NewLayoutService(arch string, pointerSize int, pp PackagePaths) *LayoutService {
+	return &LayoutService{
+		Arch:         arch,
+		PointerSize:  pointerSize,
+		PackagePaths: pp,
+		layouts:      make(map[DataType]*Layout),
+		inProgress:   make(map[DataType]struct{}),
+		recursive:    make(map[*AlgebraicType]bool),
+	}
+}
+
+// Layout computes the layout of a data type.  It returns an error if the type
+// does not have a layout: eg. it is generic or has not been fully evaluated.
+func (ls *LayoutService) Layout(dt DataType) (*Layout, error) {
+	return ls.layout(dt, false)
+}
+
+// layout computes the layout of a data type.  `nested` indicates whether the
+// type is stored within another type in which case recursive algebraic types
+// are stored by reference.
+func (ls *LayoutService) layout(dt DataType, nested bool) (*Layout, error) {
+	switch v := dt.(type) {
+	case *PrimitiveType:
+		return ls.primitiveLayout(v), nil
+	case TupleType:
+		fields := make([]*FieldLayout, len(v))
+		for i, elem := range v {
+			fields[i] = &FieldLayout{Name: strconv.Itoa(i), Type: elem}
+		}
+
+		return ls.aggregateLayout(fields, 0, 1, false)
+	case *VectorType:
+		return ls.vectorLayout(v)
+	case *RefType, *FuncType:
+		return ls.pointerLayout(1), nil
+	case *InterfType:
+		return ls.pointerLayout(2), nil
+	case *StructType:
+		return ls.namedLayout(v, func() (*Layout, error) {
+			return ls.structLayout(v)
+		})
+	case *AlgebraicType:
+		// algebraic types can contain themselves (eg. a linked list) in which
+		// case they must be stored by reference
+		if nested && ls.isRecursive(v) {
+			return ls.pointerLayout(1), nil
+		}
+
+		return ls.namedLayout(v, func() (*Layout, error) {
+			return ls.algebraicLayout(v)
+		})
+	case *AlgebraicVariant:
+		return ls.layout(v.Parent, nested)
+	case *WildcardType:
+		if v.Value != nil {
+			return ls.layout(v.Value, nested)
+		}
+	case *GenericType, *ConstraintType:
+		// these never have layouts
+	default:
+		// all of the other types are laid out as the types they stand for
+		if inner := InnerType(dt); inner != dt {
+			return ls.layout(inner, nested)
+		}
+	}
+
+	return nil, fmt.Errorf("type `%s` has no layout since it is not a concrete type", ls.PackagePaths.ReprType(dt))
+}
+
+// namedLayout memoizes the layout of a named type and detects named types
+// that contain themselves
+func (ls *LayoutService) namedLayout(dt DataType, compute func() (*Layout, error)) (*Layout, error) {
+	if layout, ok := ls.layouts[dt]; ok {
+		return layout, nil
+	}
+
+	if _, ok := ls.inProgress[dt]; ok {
+		return nil, fmt.Errorf("type `%s` has infinite size since it contains itself", ls.PackagePaths.ReprType(dt))
+	}
+
+	ls.inProgress[dt] = struct{}{}
+	defer delete(ls.inProgress, dt)
+
+	layout, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	ls.layouts[dt] = layout
+	return layout, nil
+}
+
+// isRecursive checks whether an algebraic type contains itself
+func (ls *LayoutService) isRecursive(at *AlgebraicType) bool {
+	if recursive, ok := ls.recursive[at]; ok {
+		return recursive
+	}
+
+	visited := make(map[DataType]struct{})
+	recursive := false
+	for _, variant := range at.Variants {
+		for _, value := range variant.Values {
+			if containsByValue(value, at, visited) {
+				recursive = true
+			}
+		}
+	}
+
+	ls.recursive[at] = recursive
+	return recursive
+}
+
+// containsByValue checks whether a type stores a named type within itself (ie.
+// not by reference).  `visited` stores the named types that have already been
+// checked so that types that contain themselves are only checked once.
+func containsByValue(dt, named DataType, visited map[DataType]struct{}) bool {
+	if dt == named {
+		return true
+	}
+
+	switch v := dt.(type) {
+	case TupleType:
+		for _, elem := range v {
+			if containsByValue(elem, named, visited) {
+				return true
+			}
+		}
+	case *VectorType:
+		return containsByValue(v.ElemType, named, visited)
+	case *StructType:
+		if _, ok := visited[v]; ok {
+			return false
+		}
+
+		visited[v] = struct{}{}
+
+		if v.Inherit != nil && containsByValue(v.Inherit, named, visited) {
+			return true
+		}
+
+		for _, field := range v.Fields {
+			if containsByValue(field.Type, named, visited) {
+				return true
+			}
+		}
+	case *AlgebraicType:
+		if _, ok := visited[v]; ok {
+			return false
+		}
+
+		visited[v] = struct{}{}
+
+		for _, variant := range v.Variants {
+			for _, value := range variant.Values {
+				if containsByValue(value, named, visited) {
+					return true
+				}
+			}
+		}
+	case *AlgebraicVariant:
+		return containsByValue(v.Parent, named, visited)
+	case *WildcardType:
+		if v.Value != nil {
+			return containsByValue(v.Value, named, visited)
+		}
+	case *PrimitiveType, *RefType, *FuncType, *InterfType:
+		// these never store other types by value
+	default:
+		if inner := InnerType(dt); inner != dt {
+			return containsByValue(inner, named, visited)
+		}
+	}
+
+	return false
+}
+
+// primitiveLayout computes the layout of a primitive type
+func (ls *LayoutService) primitiveLayout(pt *PrimitiveType) *Layout {
+	switch pt.PrimKind {
+	case PrimKindIntegral:
+		// the integral types are ordered in pairs of increasing size
+		return ls.scalarLayout(1 << (pt.PrimSpec / 2))
+	case PrimKindFloating:
+		if pt.PrimSpec == 0 {
+			return ls.scalarLayout(4)
+		}
+
+		return ls.scalarLayout(8)
+	case PrimKindText:
+		// runes are stored as 32 bit code points
+		if pt.PrimSpec == 0 {
+			return ls.scalarLayout(4)
+		}
+
+		return ls.pointerLayout(2)
+	case PrimKindUnit:
+		// `nothing` takes up no space and `any` is stored like an interface
+		if pt.PrimSpec == 0 {
+			return &Layout{Size: 0, Align: 1}
+		}
+
+		return ls.pointerLayout(2)
+	}
+
+	// bool
+	return ls.scalarLayout(1)
+}
+
+// scalarLayout creates the layout of a scalar value of the given size.  Scalars
+// are aligned to their size except on 32 bit x86 where 64 bit values are only
+// aligned to 4 bytes.
+func (ls *LayoutService) scalarLayout(size int) *Layout {
+	if ls.Arch == "386" && size > 4 {
+		return &Layout{Size: size, Align: 4}
+	}
+
+	return &Layout{Size: size, Align: size}
+}
+
+// pointerLayout creates the layout of a sequence of `n` pointers
+func (ls *LayoutService) pointerLayout(n int) *Layout {
+	return &Layout{Size: n * ls.PointerSize, Align: ls.PointerSize}
+}
+
+// vectorLayout computes the layout of a vector type
+func (ls *LayoutService) vectorLayout(vt *VectorType) (*Layout, error) {
+	elemLayout, err := ls.layout(vt.ElemType, true)
+	if err != nil {
+		return nil, err
+	}
+
+	size := 1
+	for size < int(vt.Size)*elemLayout.Size {
+		size *= 2
+	}
+
+	align := size
+	if align > 16 {
+		align = 16
+	}
+
+	if align < elemLayout.Align {
+		align = elemLayout.Align
+	}
+
+	return &Layout{Size: size, Align: align}, nil
+}
+
+// structLayout computes the layout of a struct type
+func (ls *LayoutService) structLayout(st *StructType) (*Layout, error) {
+	fields := make([]*FieldLayout, len(st.FieldOrder))
+	for i, name := range st.FieldOrder {
+		fields[i] = &FieldLayout{Name: name, Type: st.Fields[name].Type}
+	}
+
+	if st.Inherit == nil {
+		return ls.aggregateLayout(fields, 0, 1, st.Packed)
+	}
+
+	parentLayout, err := ls.Layout(st.Inherit)
+	if err != nil {
+		return nil, err
+	}
+
+	align := parentLayout.Align
+	if st.Packed {
+		align = 1
+	}
+
+	layout, err := ls.aggregateLayout(fields, parentLayout.Size, align, st.Packed)
+	if err != nil {
+		return nil, err
+	}
+
+	// the parent is stored at the start of the struct so its fields are at
+	// the same offsets as they are in the parent
+	layout.Fields = append(append([]*FieldLayout{}, parentLayout.Fields...), layout.Fields...)
+	return layout, nil
+}
+
+// aggregateLayout lays out a sequence of fields starting at the given offset
+// and computes the offset of each field.  `align` is the minimum alignment of
+// the aggregate.  If the aggregate is packed, its fields are not aligned.
+func (ls *LayoutService) aggregateLayout(fields []*FieldLayout, offset, align int, packed bool) (*Layout, error) {
+	for _, field := range fields {
+		fieldLayout, err := ls.layout(field.Type, true)
+		if err != nil {
+			return nil, err
+		}
+
+		field.Size, field.Align = fieldLayout.Size, fieldLayout.Align
+		if packed {
+			field.Align = 1
+		}
+
+		field.Offset = alignOffset(offset, field.Align)
+		offset = field.Offset + field.Size
+
+		if field.Align > align {
+			align = field.Align
+		}
+	}
+
+	return &Layout{Size: alignOffset(offset, align), Align: align, Fields: fields}, nil
+}
+
+// algebraicLayout computes the layout of an algebraic type
+func (ls *LayoutService) algebraicLayout(at *AlgebraicType) (*Layout, error) {
+	// the tag is the smallest integer that can store the index of every variant
+	tagSize := 1
+	for len(at.Variants) > 1<<(8*tagSize) {
+		tagSize *= 2
+	}
+
+	layout := &Layout{TagSize: tagSize}
+
+	// the values of each variant are laid out as if they were a tuple: the
+	// payload must be aligned to the most aligned variant
+	payloadSize, payloadAlign := 0, 1
+	for i, variant := range at.Variants {
+		fields := make([]*FieldLayout, len(variant.Values))
+		for j, value := range variant.Values {
+			fields[j] = &FieldLayout{Name: strconv.Itoa(j), Type: value}
+		}
+
+		variantLayout, err := ls.aggregateLayout(fields, 0, 1, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if variantLayout.Size > payloadSize {
+			payloadSize = variantLayout.Size
+		}
+
+		if variantLayout.Align > payloadAlign {
+			payloadAlign = variantLayout.Align
+		}
+
+		layout.Variants = append(layout.Variants, &VariantLayout{Name: variant.Name, Tag: i, Fields: fields})
+	}
+
+	payloadOffset := alignOffset(tagSize, payloadAlign)
+	for _, variant := range layout.Variants {
+		for _, field := range variant.Fields {
+			field.Offset += payloadOffset
+		}
+	}
+
+	layout.Align = payloadAlign
+	if tagSize > layout.Align {
+		layout.Align = tagSize
+	}
+
+	layout.Size = alignOffset(payloadOffset+payloadSize, layout.Align)
+	return layout, nil
+}
+
+// alignOffset rounds an offset up to the next multiple of an alignment
+func alignOffset(offset, align int) int {
+	return (offset + align - 1) / align * align
+}
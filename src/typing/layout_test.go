@@ -0,0 +1,184 @@
+package typing
+
+import (
+	"strings"
+	"testing"
+)
+
+var (
+	i8Type  = &PrimitiveType{PrimKind: PrimKindIntegral, PrimSpec: PrimIntI8}
+	i64Type = &PrimitiveType{PrimKind: PrimKindIntegral, PrimSpec: PrimIntI64}
+)
+
+// newTestAlgebraic creates an algebraic type whose variants store the given
+// values.  The values of the variants can be filled in once it is created.
+func newTestAlgebraic(name string, variants map[string][]DataType, order ...string) *AlgebraicType {
+	at := &AlgebraicType{Name: name}
+	for _, vname := range order {
+		at.Variants = append(at.Variants, &AlgebraicVariant{Parent: at, Name: vname, Values: variants[vname]})
+	}
+
+	return at
+}
+
+// newTestStruct creates a struct type with the given fields in order
+func newTestStruct(name string, fields ...interface{}) *StructType {
+	st := &StructType{Name: name, Fields: make(map[string]*TypedValue)}
+	for i := 0; i < len(fields); i += 2 {
+		fname := fields[i].(string)
+		st.Fields[fname] = &TypedValue{Type: fields[i+1].(DataType)}
+		st.FieldOrder = append(st.FieldOrder, fname)
+	}
+
+	return st
+}
+
+// checkFields checks the offsets and sizes of a list of field layouts
+func checkFields(t *testing.T, name string, fields []*FieldLayout, want [][2]int) {
+	t.Helper()
+
+	if len(fields) != len(want) {
+		t.Fatalf("%s: got %d fields, want %d", name, len(fields), len(want))
+	}
+
+	for i, w := range want {
+		if fields[i].Offset != w[0] || fields[i].Size != w[1] {
+			t.Errorf("%s: field `%s` is at offset %d with size %d, want offset %d with size %d",
+				name, fields[i].Name, fields[i].Offset, fields[i].Size, w[0], w[1])
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	ls := NewLayoutService("amd64", 8, nil)
+
+	point := newTestStruct("Point", "a", i8Type, "b", i64Type, "c", i32Type)
+	packed := newTestStruct("Packed", "a", i8Type, "b", i64Type)
+	packed.Packed = true
+	point3 := newTestStruct("Point3", "d", i8Type)
+	point3.Inherit = point
+	opt := newTestAlgebraic("Opt", map[string][]DataType{"Some": {i32Type}}, "None", "Some")
+
+	for _, c := range []struct {
+		name        string
+		dt          DataType
+		size, align int
+		fields      [][2]int
+	}{
+		{"i32", i32Type, 4, 4, nil},
+		{"ref", &RefType{ElemType: i32Type}, 8, 8, nil},
+		{"tuple", TupleType{i8Type, i32Type}, 8, 4, [][2]int{{0, 1}, {4, 4}}},
+		{"vector", &VectorType{ElemType: i32Type, Size: 3}, 16, 16, nil},
+		{"struct", point, 24, 8, [][2]int{{0, 1}, {8, 8}, {16, 4}}},
+		{"packed struct", packed, 9, 1, [][2]int{{0, 1}, {1, 8}}},
+		{"inheriting struct", point3, 32, 8, [][2]int{{0, 1}, {8, 8}, {16, 4}, {24, 1}}},
+		{"algebraic", opt, 8, 4, nil},
+	} {
+		layout, err := ls.Layout(c.dt)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+
+		if layout.Size != c.size || layout.Align != c.align {
+			t.Errorf("%s: got size %d and align %d, want size %d and align %d", c.name, layout.Size, layout.Align, c.size, c.align)
+		}
+
+		if c.fields != nil {
+			checkFields(t, c.name, layout.Fields, c.fields)
+		}
+	}
+
+	// 32 bit x86 only aligns 64 bit integers to 4 bytes
+	if layout, err := NewLayoutService("386", 4, nil).Layout(point); err != nil {
+		t.Error(err)
+	} else {
+		checkFields(t, "386 struct", layout.Fields, [][2]int{{0, 1}, {4, 8}, {12, 4}})
+	}
+}
+
+func TestLayoutInfiniteStruct(t *testing.T) {
+	a := newTestStruct("A")
+	b := newTestStruct("B", "a", a)
+	a.Fields["b"] = &TypedValue{Type: b}
+	a.FieldOrder = []string{"b"}
+
+	_, err := NewLayoutService("amd64", 8, nil).Layout(a)
+	if err == nil || !strings.Contains(err.Error(), "infinite size") {
+		t.Errorf("got error %v, want an infinite size error", err)
+	}
+}
+
+func TestLayoutRecursiveAlgebraic(t *testing.T) {
+	// `List` is stored by reference within itself
+	list := newTestAlgebraic("List", map[string][]DataType{}, "Nil", "Cons")
+	list.Variants[1].Values = []DataType{i32Type, list}
+
+	// `Opt` doesn't contain itself so it is stored inline
+	opt := newTestAlgebraic("Opt", map[string][]DataType{"Some": {i32Type}}, "None", "Some")
+	holder := newTestStruct("Holder", "l", list, "o", opt)
+
+	ls := NewLayoutService("amd64", 8, nil)
+
+	layout, err := ls.Layout(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if layout.Size != 24 || layout.Align != 8 || layout.TagSize != 1 {
+		t.Errorf("got size %d, align %d and tag size %d, want 24, 8 and 1", layout.Size, layout.Align, layout.TagSize)
+	}
+
+	checkFields(t, "Cons", layout.Variants[1].Fields, [][2]int{{8, 4}, {16, 8}})
+
+	layout, err = ls.Layout(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFields(t, "Holder", layout.Fields, [][2]int{{0, 8}, {8, 8}})
+}
+
+func TestLayoutQueryOrder(t *testing.T) {
+	// `A` and `B` contain each other so they are both stored by reference
+	// within each other regardless of which is laid out first
+	newTypes := func() (*AlgebraicType, *AlgebraicType) {
+		a := newTestAlgebraic("A", map[string][]DataType{}, "A0", "A1")
+		b := newTestAlgebraic("B", map[string][]DataType{}, "B0", "B1")
+		a.Variants[1].Values = []DataType{i64Type, b}
+		b.Variants[1].Values = []DataType{i8Type, a}
+		return a, b
+	}
+
+	for _, aFirst := range []bool{true, false} {
+		a, b := newTypes()
+		ls := NewLayoutService("amd64", 8, nil)
+
+		order := []*AlgebraicType{b, a}
+		if aFirst {
+			order = []*AlgebraicType{a, b}
+		}
+
+		layouts := make(map[*AlgebraicType]*Layout)
+		for _, at := range order {
+			layout, err := ls.Layout(at)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			layouts[at] = layout
+		}
+
+		for at, want := range map[*AlgebraicType][][2]int{
+			a: {{8, 8}, {16, 8}},
+			b: {{8, 1}, {16, 8}},
+		} {
+			layout := layouts[at]
+			if layout.Size != 24 || layout.Align != 8 {
+				t.Errorf("`%s` (A first: %v): got size %d and align %d, want size 24 and align 8", at.Name, aFirst, layout.Size, layout.Align)
+			}
+
+			checkFields(t, at.Name, layout.Variants[1].Fields, want)
+		}
+	}
+}
@@ -40,9 +40,9 @@ func (op *OpaqueType) Repr() string {
 
 // copyTemplate duplicates the EvalType if it can and acts as an identity
 // function if it doesn't (returns the OpaqueType reference)
-func (op *OpaqueType) copyTemplate() DataType {
+func (op *OpaqueType) copyTemplate(wcm wildcardMap) DataType {
 	if op.EvalType != nil {
-		return op.EvalType.copyTemplate()
+		return op.EvalType.copyTemplate(wcm)
 	}
 
 	// don't copy so that we can avoid losing the shared data type reference
@@ -86,7 +86,7 @@ func (og *OpaqueGenericType) Repr() string {
 // This method should never be used since the only time generics are copied
 // directly is in interfaces (as method) which do not experience opaque symbol
 // resolution.  If this method is called, a fatal error will occur.
-func (og *OpaqueGenericType) copyTemplate() DataType {
+func (og *OpaqueGenericType) copyTemplate(wcm wildcardMap) DataType {
 	// COMMENT FOR PREVIOUS IMPL:
 	// copyTemplate attempts to copy the inner generic if it exists; otherwise,
 	// it simply inserts a nil.  All the instances are also copied *and* their
@@ -110,10 +110,35 @@ func (og *OpaqueGenericType) copyTemplate() DataType {
 	return nil
 }
 
+// CreateOpaqueGenericInstance creates a new instance of an opaque generic.  If
+// the generic has not been evaluated yet, the instance is a placeholder that
+// is created properly once the generic is evaluated.
+func (s *Solver) CreateOpaqueGenericInstance(ogt *OpaqueGenericType, typeParams []DataType, typeParamsBranch *syntax.ASTBranch) (DataType, bool) {
+	// the instances of the opaque generic are shared
+	s.lockSharedState()
+	defer s.unlockSharedState()
+
+	if ogt.EvalType != nil {
+		return s.CreateGenericInstance(ogt.EvalType, typeParams, typeParamsBranch)
+	}
+
+	ogi := &OpaqueGenericInstanceType{
+		OpaqueGeneric:    ogt,
+		TypeParams:       typeParams,
+		TypeParamsBranch: typeParamsBranch,
+	}
+
+	ogt.Instances = append(ogt.Instances, ogi)
+	return ogi, true
+}
+
 // Evaluate takes in a type to act as the evaluated generic, updates the opaque
 // type, checks all of the instances, and logs an appropriate error if something
 // goes wrong
 func (ogt *OpaqueGenericType) Evaluate(gt *GenericType, s *Solver) bool {
+	s.lockSharedState()
+	defer s.unlockSharedState()
+
 	ogt.EvalType = gt
 
 	instancesMatched := true
@@ -185,10 +210,10 @@ func (ogi *OpaqueGenericInstanceType) Repr() string {
 // copyTemplate preserve the internal opaque generic reference while copying the
 // type parameters and memoized generic.  In addition, it will add itself to the
 // original opaque generic instance
-func (ogi *OpaqueGenericInstanceType) copyTemplate() DataType {
+func (ogi *OpaqueGenericInstanceType) copyTemplate(wcm wildcardMap) DataType {
 	copy := &OpaqueGenericInstanceType{
 		OpaqueGeneric:    ogi.OpaqueGeneric,
-		TypeParams:       copyTemplateSlice(ogi.TypeParams),
+		TypeParams:       copyTemplateSlice(ogi.TypeParams, wcm),
 		MemoizedGenerate: ogi.MemoizedGenerate, // this field may be `nil`
 		TypeParamsBranch: ogi.TypeParamsBranch,
 	}
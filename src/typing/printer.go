@@ -0,0 +1,263 @@
+package typing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type Printing
+// -------------
+// `Repr` gives the canonical, unqualified representation of a data type which
+// is not always helpful in diagnostics: eg. two `List` types from different
+// packages have the same representation.  The type printer produces the
+// representations of types used in diagnostics.  It works like `Repr` except
+// that:
+//
+// 1. Named types are qualified by the import paths of their packages (eg.
+//    `std::io::File`) if the types being printed together contain types of the
+//    same name from different packages.
+// 2. Type interfaces are represented by the types they are bound to.
+// 3. Constraint unions that have no name are spelled out (eg. `i32 | i64`).
+// 4. Unknown types that (erroneously) contain themselves are printed safely.
+//
+// The printer needs to know the import paths of packages to qualify types by
+// so they are passed to it explicitly as a `PackagePaths` table.  Types printed
+// without one (see `ReprType`) are never qualified.
+
+// PackagePaths maps the IDs of packages to the import paths that the types they
+// define are qualified by.  The table is filled in by the compiler as packages
+// are added to the dependency graph and is only read once they have all been
+// added.
+type PackagePaths map[uint]string
+
+// ReprType gets the representation of a data type without qualifying any of
+// the named types it contains
+func ReprType(dt DataType) string {
+	return PackagePaths(nil).ReprType(dt)
+}
+
+// ReprTypes gets the representations of several data types without qualifying
+// any of the named types they contain
+func ReprTypes(dts ...DataType) []string {
+	return PackagePaths(nil).ReprTypes(dts...)
+}
+
+// ReprType gets the representation of a data type for use in diagnostics
+func (pp PackagePaths) ReprType(dt DataType) string {
+	return pp.ReprTypes(dt)[0]
+}
+
+// ReprTypes gets the representations of several data types that are displayed
+// together (eg. in the same message) for use in diagnostics.  Named types are
+// only qualified by the import paths of their packages if they are ambiguous
+// between all of the given types.
+func (pp PackagePaths) ReprTypes(dts ...DataType) []string {
+	tp := &typePrinter{
+		packagePaths: pp,
+		namePackages: make(map[string]map[uint]struct{}),
+		unknowns:     make(map[*UnknownType]struct{}),
+	}
+
+	// the types are printed once to find all of the names they use before
+	// they are actually printed
+	tp.collecting = true
+	for _, dt := range dts {
+		tp.repr(dt)
+	}
+
+	tp.collecting = false
+	reprs := make([]string, len(dts))
+	for i, dt := range dts {
+		reprs[i] = tp.repr(dt)
+	}
+
+	return reprs
+}
+
+// typePrinter is the state used to print a set of data types
+type typePrinter struct {
+	// packagePaths is the table of import paths named types are qualified by
+	packagePaths PackagePaths
+
+	// namePackages maps the names of the named types in the types being printed
+	// to the set of the IDs of the packages that define types with that name
+	namePackages map[string]map[uint]struct{}
+
+	// collecting indicates whether the printer is only collecting the names of
+	// the named types in the types being printed
+	collecting bool
+
+	// unknowns stores the unknown types that are currently being printed so
+	// that unknowns that contain themselves can be detected
+	unknowns map[*UnknownType]struct{}
+}
+
+// repr gets the representation of a single data type
+func (tp *typePrinter) repr(dt DataType) string {
+	switch v := dt.(type) {
+	case nil:
+		return ""
+	case TupleType:
+		return "(" + tp.reprList(v, ", ") + ")"
+	case *VectorType:
+		return fmt.Sprintf("<%d>%s", v.Size, tp.repr(v.ElemType))
+	case *RefType:
+		if v.Constant {
+			return "&const " + tp.repr(v.ElemType)
+		}
+
+		return "&" + tp.repr(v.ElemType)
+	case *FuncType:
+		return tp.reprFunc(v)
+	case *StructType:
+		return tp.reprName(v.Name, v.SrcPackageID)
+	case *InterfType:
+		if v.Name == "" {
+			if v.BoundType != nil {
+				return tp.repr(v.BoundType)
+			}
+
+			return v.Repr()
+		}
+
+		return tp.reprName(v.Name, v.SrcPackageID)
+	case *AlgebraicType:
+		return tp.reprName(v.Name, v.SrcPackageID)
+	case *AliasType:
+		return tp.reprName(v.Name, v.SrcPackageID)
+	case *AlgebraicVariant:
+		baseName := tp.repr(v.Parent) + "::" + v.Name
+		if len(v.Values) == 0 {
+			return baseName
+		}
+
+		return baseName + "(" + tp.reprList(v.Values, ", ") + ")"
+	case *GenericType:
+		params := make([]string, len(v.TypeParams))
+		for i, param := range v.TypeParams {
+			params[i] = param.Name
+		}
+
+		return tp.repr(v.Template) + "<" + strings.Join(params, ", ") + ">"
+	case *GenericInstanceType:
+		return tp.repr(v.Generic.Template) + "<" + tp.reprList(v.TypeParams, ", ") + ">"
+	case *GenericAlgebraicVariantType:
+		gt, ok := v.GenericParent.(*GenericType)
+		if ogt, isOpaque := v.GenericParent.(*OpaqueGenericType); isOpaque && ogt.EvalType != nil {
+			gt, ok = ogt.EvalType, true
+		}
+
+		if !ok {
+			return v.Repr()
+		}
+
+		return tp.repr(gt) + "::" + gt.Template.(*AlgebraicType).Variants[v.VariantPos].Name
+	case *WildcardType:
+		if v.Value != nil {
+			return tp.repr(v.Value)
+		}
+
+		return v.Name
+	case *ConstraintType:
+		if v.Name == "" {
+			return tp.reprList(v.Types, " | ")
+		}
+
+		return v.Name
+	case *OpaqueType:
+		if v.EvalType != nil {
+			return tp.repr(v.EvalType)
+		}
+
+		return v.Name
+	case *OpaqueGenericType:
+		if v.EvalType != nil {
+			return tp.repr(v.EvalType)
+		}
+	case *OpaqueGenericInstanceType:
+		if v.MemoizedGenerate != nil {
+			return tp.repr(v.OpaqueGeneric.EvalType.Template) + "<" + tp.reprList(v.TypeParams, ", ") + ">"
+		}
+	case *UnknownType:
+		if v.EvalType == nil {
+			return "_"
+		}
+
+		if _, ok := tp.unknowns[v]; ok {
+			return "..."
+		}
+
+		tp.unknowns[v] = struct{}{}
+		defer delete(tp.unknowns, v)
+
+		return tp.repr(v.EvalType)
+	}
+
+	return dt.Repr()
+}
+
+// reprList gets the representations of a list of data types joined by a
+// separator
+func (tp *typePrinter) reprList(dts []DataType, sep string) string {
+	reprs := make([]string, len(dts))
+	for i, dt := range dts {
+		reprs[i] = tp.repr(dt)
+	}
+
+	return strings.Join(reprs, sep)
+}
+
+// reprFunc gets the representation of a function type (see `FuncType.Repr`)
+func (tp *typePrinter) reprFunc(ft *FuncType) string {
+	sb := strings.Builder{}
+
+	if ft.Async {
+		sb.WriteString("async(")
+	} else {
+		sb.WriteString("func(")
+	}
+
+	for i, param := range ft.Args {
+		if param.Indefinite {
+			sb.WriteString("...")
+		} else if param.Optional {
+			sb.WriteRune('~')
+		}
+
+		sb.WriteString(tp.repr(param.Val.Type))
+
+		if i < len(ft.Args)-1 {
+			sb.WriteString(", ")
+		}
+	}
+
+	sb.WriteString(")(")
+	sb.WriteString(tp.repr(ft.ReturnType))
+	sb.WriteRune(')')
+
+	return sb.String()
+}
+
+// reprName gets the representation of the name of a named type: it is qualified
+// by the import path of its package if the types being printed contain another
+// type with the same name from a different package
+func (tp *typePrinter) reprName(name string, pkgID uint) string {
+	if tp.collecting {
+		if _, ok := tp.namePackages[name]; !ok {
+			tp.namePackages[name] = make(map[uint]struct{})
+		}
+
+		tp.namePackages[name][pkgID] = struct{}{}
+		return name
+	}
+
+	if len(tp.namePackages[name]) < 2 {
+		return name
+	}
+
+	if importPath, ok := tp.packagePaths[pkgID]; ok {
+		return importPath + "::" + name
+	}
+
+	return name
+}
@@ -0,0 +1,63 @@
+package typing
+
+import "testing"
+
+func TestReprTypesQualification(t *testing.T) {
+	pp := PackagePaths{1: "proj::a", 2: "proj::b"}
+
+	aList := &StructType{Name: "List", SrcPackageID: 1}
+	bList := &StructType{Name: "List", SrcPackageID: 2}
+	aPoint := &StructType{Name: "Point", SrcPackageID: 1}
+
+	for _, c := range []struct {
+		name string
+		dts  []DataType
+		want []string
+	}{
+		{"ambiguous", []DataType{aList, bList}, []string{"proj::a::List", "proj::b::List"}},
+		{"nested", []DataType{TupleType{aList, bList}}, []string{"(proj::a::List, proj::b::List)"}},
+		{"unambiguous", []DataType{aList, aPoint}, []string{"List", "Point"}},
+		{"same type", []DataType{aList, aList}, []string{"List", "List"}},
+	} {
+		reprs := pp.ReprTypes(c.dts...)
+
+		for i, want := range c.want {
+			if reprs[i] != want {
+				t.Errorf("%s: got `%s`, want `%s`", c.name, reprs[i], want)
+			}
+		}
+	}
+
+	// types are never qualified without a table of import paths
+	if reprs := ReprTypes(aList, bList); reprs[0] != "List" || reprs[1] != "List" {
+		t.Errorf("got `%s` and `%s` without a table, want `List` and `List`", reprs[0], reprs[1])
+	}
+}
+
+func TestReprType(t *testing.T) {
+	pp := PackagePaths{1: "proj::a"}
+
+	list := &StructType{Name: "List", SrcPackageID: 1}
+	gt := &GenericType{
+		Template:   list,
+		TypeParams: []*WildcardType{{Name: "T"}},
+	}
+
+	for _, c := range []struct {
+		dt   DataType
+		want string
+	}{
+		{gt, "List<T>"},
+		{&GenericInstanceType{Generic: gt, TypeParams: []DataType{i32Type}}, "List<i32>"},
+		{&InterfType{BoundType: list}, "List"},
+		{&ConstraintType{Types: []DataType{i32Type, boolType}}, "i32 | bool"},
+		{&ConstraintType{Name: "Numeric", Types: []DataType{i32Type}}, "Numeric"},
+		{&WildcardType{Name: "T", Value: boolType}, "bool"},
+		{&OpaqueType{Name: "Node", EvalType: list}, "List"},
+		{&UnknownType{}, "_"},
+	} {
+		if got := pp.ReprType(c.dt); got != c.want {
+			t.Errorf("got `%s`, want `%s`", got, c.want)
+		}
+	}
+}
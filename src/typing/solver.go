@@ -2,6 +2,9 @@ package typing
 
 import (
 	"fmt"
+	"strings"
+	"sync"
+
 	"whirlwind/logging"
 )
 
@@ -29,6 +32,10 @@ type Solver struct {
 	// from other packages are only visible in the current file
 	LocalBindings *BindingRegistry
 
+	// PackagePaths is the table of the import paths of packages used to
+	// qualify the named types in diagnostics
+	PackagePaths PackagePaths
+
 	// Variables is the list of active type variables to be unified
 	Variables map[int]*TypeVariable
 
@@ -39,14 +46,71 @@ type Solver struct {
 	// using to "solve" the current type context.  These are essentially its
 	// "guesses" as to what an unknown type should be.
 	Substitutions map[int]*TypeSubstitution
+
+	// sharedStateDepth is the number of nested calls that currently hold the
+	// shared state lock through this solver (see `lockSharedState`)
+	sharedStateDepth int
+
+	// currConstraint is the constraint currently being unified.  It is used to
+	// point to the sides of the constraint when a type mismatch is logged.
+	currConstraint *TypeConstraint
+
+	// substTrail is the stack of substitutions that are currently being unified
+	// against.  It is used to explain why the solver believed a type variable
+	// had the type it did when a type mismatch is logged.
+	substTrail []substTrailEntry
+
+	// Trace is the trace the solver records its work in.  If it is `nil`, no
+	// trace is recorded.  It is cleared along with the rest of the solving
+	// context once `Solve` is complete.
+	Trace *SolverTrace
+}
+
+// substTrailEntry is an entry in the substitution trail of the solver
+type substTrailEntry struct {
+	tvar *TypeVariable
+	sub  *TypeSubstitution
+}
+
+// sharedStateMutex guards the state of data types that is shared between all
+// of the packages that use them: the instances of generics, opaque generics
+// and interfaces, and the values of the wildcard types of bindings (which are
+// set as bindings are matched).  This state is updated as types are checked so
+// packages that are resolved or validated concurrently must only access it
+// while holding this lock.  The type parameters of generics are never updated:
+// instances are created from copies of their templates.
+var sharedStateMutex sync.Mutex
+
+// lockSharedState acquires exclusive access to the shared state of data types.
+// Since the solver's methods call each other, the lock is only acquired by the
+// outermost call: this is safe because a solver is only ever used by a single
+// goroutine.  Every call must be paired with a call to `unlockSharedState`.
+func (s *Solver) lockSharedState() {
+	if s.sharedStateDepth == 0 {
+		sharedStateMutex.Lock()
+	}
+
+	s.sharedStateDepth++
 }
 
-// NewSolver creates a new solver with the given context and binding registries.
-func NewSolver(ctx *logging.LogContext, lb, gb *BindingRegistry) *Solver {
+// unlockSharedState releases the shared state lock acquired by
+// `lockSharedState`
+func (s *Solver) unlockSharedState() {
+	s.sharedStateDepth--
+
+	if s.sharedStateDepth == 0 {
+		sharedStateMutex.Unlock()
+	}
+}
+
+// NewSolver creates a new solver with the given context, binding registries
+// and table of package import paths.
+func NewSolver(ctx *logging.LogContext, lb, gb *BindingRegistry, pp PackagePaths) *Solver {
 	return &Solver{
 		Context:        ctx,
 		GlobalBindings: gb,
 		LocalBindings:  lb,
+		PackagePaths:   pp,
 		Variables:      make(map[int]*TypeVariable),
 		Substitutions:  make(map[int]*TypeSubstitution),
 	}
@@ -75,6 +139,10 @@ type TypeVariable struct {
 	// Unknown contains a reference to this type variable's corresponding
 	// unknown type
 	Unknown *UnknownType
+
+	// Position is the position of the expression whose type this type variable
+	// represents (if it is known)
+	Position *logging.TextPosition
 }
 
 // TypeConstraint is a data type representing a constraint equation
@@ -89,6 +157,12 @@ type TypeConstraint struct {
 	Kind int
 
 	Position *logging.TextPosition
+
+	// LhsPosition and RhsPosition are the positions of whatever produced the
+	// left and right hand sides of the constraint (eg. a function argument and
+	// the function it is passed to).  They are used to point to both sides of
+	// the constraint if it can't be satisfied and can be `nil`.
+	LhsPosition, RhsPosition *logging.TextPosition
 }
 
 // TypeSubstitution is a structure representing a type substitution made by the
@@ -104,6 +178,25 @@ type TypeSubstitution struct {
 	// substitution"; that is a substitution where the constraint would apply as
 	// if this were the lhs type (since these constraints are directional)
 	ConsKind int
+
+	// Provenance records every type this substitution has held along with the
+	// constraint that caused it in the order they were applied: the first step
+	// introduced the substitution and each subsequent step replaced the type
+	// with a more dominant one.  This is used to explain type errors.
+	Provenance []*SubstitutionStep
+}
+
+// SubstitutionStep is a single step in the provenance of a type substitution
+type SubstitutionStep struct {
+	// SubbedType is the type that was substituted by this step
+	SubbedType DataType
+
+	// Constraint is the constraint being unified when this step was made.  It
+	// can be `nil` if the step was not made while solving a constraint.
+	Constraint *TypeConstraint
+
+	// Position is the position at which the step was made
+	Position *logging.TextPosition
 }
 
 // Type constraint kinds
@@ -126,6 +219,11 @@ type UnknownType struct {
 	// constraints). It is `nil` until the corresponding type variable is
 	// unified.  This should be an `InnerType`!
 	EvalType DataType
+
+	// inRepr indicates that the unknown type is currently being represented.
+	// It is used to detect evaluated types that (erroneously) contain their own
+	// unknown so that they can still be printed when debugging.
+	inRepr bool
 }
 
 func (ut *UnknownType) Repr() string {
@@ -133,6 +231,17 @@ func (ut *UnknownType) Repr() string {
 		return "_"
 	}
 
+	// the occurs check should prevent an unknown from being evaluated to a
+	// type that contains it, but if one is, the repeated type is elided
+	if ut.inRepr {
+		return "..."
+	}
+
+	ut.inRepr = true
+	defer func() {
+		ut.inRepr = false
+	}()
+
 	return ut.EvalType.Repr()
 }
 
@@ -141,7 +250,7 @@ func (ut *UnknownType) equals(other DataType) bool {
 	return true
 }
 
-func (ut *UnknownType) copyTemplate() DataType {
+func (ut *UnknownType) copyTemplate(wcm wildcardMap) DataType {
 	// This method need not be anything more than an identity since unknowns can
 	// ever occur inside generics as something for which a "copyTemplate" would
 	// be required.
@@ -162,11 +271,13 @@ func (s *Solver) NewTypeVar(defaultType DataType, pos *logging.TextPosition, han
 		ID:            len(s.Variables),
 		DefaultType:   defaultType,
 		LogUnsolvable: handler,
+		Position:      pos,
 	}
 
 	s.Variables[tv.ID] = tv
 
 	ut := &UnknownType{TypeVarID: tv.ID}
+	tv.Unknown = ut
 	if initialConstraint != nil {
 		s.AddConstraint(ut, initialConstraint, initialConsKind, pos)
 	}
@@ -181,6 +292,15 @@ func (s *Solver) AddConstraint(lhs, rhs DataType, consKind int, pos *logging.Tex
 	})
 }
 
+// AddConstraintWithSides adds a new constraint to the given context along with
+// the positions of whatever produced its left and right hand sides
+func (s *Solver) AddConstraintWithSides(lhs, rhs DataType, consKind int, pos, lhsPos, rhsPos *logging.TextPosition) {
+	s.Constraints = append(s.Constraints, &TypeConstraint{
+		Lhs: lhs, Rhs: rhs, Kind: consKind, Position: pos,
+		LhsPosition: lhsPos, RhsPosition: rhsPos,
+	})
+}
+
 // Solve performs all unification, erroring, and substituting for the current
 // type context.  This should be called only at the end of the context once all
 // constraints have been built. It returns a flag indicating whether or not
@@ -188,15 +308,26 @@ func (s *Solver) AddConstraint(lhs, rhs DataType, consKind int, pos *logging.Tex
 func (s *Solver) Solve() bool {
 	succeeded := true
 
+	if s.Trace != nil {
+		s.traceStart()
+	}
+
 	// unify all constraints
 	for _, cons := range s.Constraints {
+		s.currConstraint = cons
 		_, ok := s.unify(cons.Lhs, cons.Rhs, cons.Kind, cons.Position)
 		succeeded = succeeded && ok
 	}
 
+	s.currConstraint = nil
+
 	// test to see if all variables resolved
 	for _, tvar := range s.Variables {
-		if sub, ok := s.Substitutions[tvar.ID]; ok {
+		// a type variable that was unified with other type variables is
+		// evaluated as the type substituted for the last of them
+		last := s.lastUnknownInChain(tvar.Unknown).(*UnknownType)
+
+		if sub, ok := s.Substitutions[last.TypeVarID]; ok {
 			// if the best substituted type is a type constraint, then we
 			// attempt to find a default type.  If one can't be found, then this
 			// type is still unsolvable (something being `Numeric` doesn't
@@ -216,12 +347,22 @@ func (s *Solver) Solve() bool {
 		succeeded = false
 	}
 
-	// reset solver
+	if s.Trace != nil {
+		s.traceEnd(succeeded)
+	}
+
+	s.Reset()
+	return succeeded
+}
+
+// Reset clears the current solving context without solving it.  This should be
+// called when the context is abandoned (eg. because the expression it was built
+// for is invalid) so that its constraints don't leak into the next context.
+func (s *Solver) Reset() {
 	s.Constraints = nil
 	s.Variables = make(map[int]*TypeVariable)
 	s.Substitutions = make(map[int]*TypeSubstitution)
-
-	return succeeded
+	s.Trace = nil
 }
 
 // -----------------------------------------------------------------------------
@@ -242,6 +383,32 @@ const (
 // -- it should be one of the enumerated constraint kinds.  This function does
 // log errors and should not be called as a "testing" function.
 func (s *Solver) unify(lhType, rhType DataType, consKind int, pos *logging.TextPosition) (int, bool) {
+	if s.Trace == nil {
+		return s.unifyTypes(lhType, rhType, consKind, pos)
+	}
+
+	traceResult := s.traceUnify(lhType, rhType, consKind, pos)
+	tr, ok := s.unifyTypes(lhType, rhType, consKind, pos)
+	traceResult(tr, ok)
+	return tr, ok
+}
+
+// unifyTypes performs the actual unification of two types for `unify`
+func (s *Solver) unifyTypes(lhType, rhType DataType, consKind int, pos *logging.TextPosition) (int, bool) {
+	// type variables that have been unified with each other are unified as the
+	// last type variable in their chain of substitutions so that a variable is
+	// never substituted for a variable that is already substituted for it (eg.
+	// `t0 = t1` after `t1 = t0`)
+	lhType, rhType = s.lastUnknownInChain(lhType), s.lastUnknownInChain(rhType)
+
+	// an unknown type is always equal to itself: it must not be substituted
+	// for itself (which the occurs check would reject)
+	if lut, ok := lhType.(*UnknownType); ok {
+		if rut, ok := rhType.(*UnknownType); ok && lut.TypeVarID == rut.TypeVarID {
+			return UEqual, true
+		}
+	}
+
 	// we start by testing the `rhType` to see if it is unknown before
 	// proceeding with unification -- the main unify switch tests based on the
 	// `lhType`.
@@ -253,31 +420,32 @@ func (s *Solver) unify(lhType, rhType DataType, consKind int, pos *logging.TextP
 		// since any substitutions after this one must still be a subset of
 		// `Numeric`.
 		if sub, ok := s.Substitutions[rut.TypeVarID]; ok {
-			if tr, ok := s.unify(lhType, sub.SubbedType, sub.ConsKind, pos); ok {
+			if tr, ok := s.unifyWithSubstitution(rut.TypeVarID, lhType, sub.SubbedType, sub.ConsKind, pos); ok {
 				// only if the left type was dominant, do we need to update the
 				// substitution. `unify` already checks that the conditions of
 				// this left substitution were met so we don't need to check
 				// them here
 				if tr == ULeft {
-					sub.SubbedType = lhType
+					if !s.occursCheck(rut.TypeVarID, lhType, pos) {
+						return -1, false
+					}
 
 					// since we are performing a substitution against a
 					// different constraint, we need to update the substitution
 					// to indicate which constraint we are abiding by now (for
 					// the substitution)
-					sub.ConsKind = consKind
+					s.updateSubstitution(sub, lhType, consKind, pos)
 				}
 
 				return tr, true
 			} else {
 				return -1, false
 			}
-		} else {
-			s.Substitutions[rut.TypeVarID] = &TypeSubstitution{
-				SubbedType: lhType,
-				ConsKind:   consKind,
-			}
+		} else if s.occursCheck(rut.TypeVarID, lhType, pos) {
+			s.Substitutions[rut.TypeVarID] = s.newSubstitution(lhType, consKind, pos)
 			return UEqual, true
+		} else {
+			return -1, false
 		}
 	}
 
@@ -302,26 +470,28 @@ func (s *Solver) unify(lhType, rhType DataType, consKind int, pos *logging.TextP
 				consKind = TCLeftCoerce
 			}
 
-			if tr, ok := s.unify(sub.SubbedType, rhType, consKind, pos); ok {
+			if tr, ok := s.unifyWithSubstitution(v.TypeVarID, sub.SubbedType, rhType, consKind, pos); ok {
 				// only if the right type was dominant, do we need to update the
 				// substitution. `unify` already checks that the conditions of
 				// this left substitution were met so we don't need to check
 				// them here
 				if tr == URight {
-					sub.SubbedType = rhType
-					sub.ConsKind = consKind
+					if !s.occursCheck(v.TypeVarID, rhType, pos) {
+						return -1, false
+					}
+
+					s.updateSubstitution(sub, rhType, consKind, pos)
 				}
 
 				return tr, true
 			} else {
 				return -1, false
 			}
-		} else {
-			s.Substitutions[v.TypeVarID] = &TypeSubstitution{
-				SubbedType: rhType,
-				ConsKind:   consKind,
-			}
+		} else if s.occursCheck(v.TypeVarID, rhType, pos) {
+			s.Substitutions[v.TypeVarID] = s.newSubstitution(rhType, consKind, pos)
 			return UEqual, true
+		} else {
+			return -1, false
 		}
 	case TupleType:
 		if rtt, ok := rhType.(TupleType); ok {
@@ -417,19 +587,165 @@ func (s *Solver) unify(lhType, rhType DataType, consKind int, pos *logging.TextP
 	return -1, false
 }
 
+// lastUnknownInChain follows the substitutions of an unknown type for as long
+// as they are other unknown types and returns the last unknown type in that
+// chain.  All other types are returned as is.
+func (s *Solver) lastUnknownInChain(dt DataType) DataType {
+	visited := make(map[int]bool)
+
+	for {
+		ut, ok := dt.(*UnknownType)
+		if !ok || visited[ut.TypeVarID] {
+			return dt
+		}
+
+		visited[ut.TypeVarID] = true
+
+		sub, ok := s.Substitutions[ut.TypeVarID]
+		if !ok {
+			return dt
+		}
+
+		next, ok := sub.SubbedType.(*UnknownType)
+		if !ok {
+			return dt
+		}
+
+		dt = next
+	}
+}
+
+// occursCheck checks that a type can be substituted for a type variable: a type
+// that contains the type variable can't be substituted for it since that would
+// make the type variable infinite (eg. `t0 = [t0]`).  If the type does contain
+// the type variable, an error is logged.  It returns a flag indicating whether
+// or not the substitution is valid.
+func (s *Solver) occursCheck(tvarID int, dt DataType, pos *logging.TextPosition) bool {
+	if !s.occursIn(tvarID, dt, make(map[int]bool)) {
+		return true
+	}
+
+	var annotations []logging.Annotation
+	if tvar, ok := s.Variables[tvarID]; ok && tvar.Position != nil && (pos == nil || *tvar.Position != *pos) {
+		annotations = append(annotations,
+			logging.WithLabel("this requires the inferred type to contain itself"),
+			logging.WithSpan(tvar.Position, "the type of this had to be inferred"),
+		)
+	} else {
+		annotations = append(annotations, logging.WithLabel("the type of this would have to contain itself"))
+	}
+
+	annotations = append(annotations,
+		logging.WithNote(fmt.Sprintf("the inferred type would have to be `%s` which contains it", s.PackagePaths.ReprType(dt))),
+	)
+
+	logging.LogCompileError(
+		s.Context,
+		"Infinite Type: an inferred type cannot contain itself",
+		logging.LMKTyping,
+		pos,
+		append(annotations, s.substitutionTrail(pos)...)...,
+	)
+
+	return false
+}
+
+// occursIn checks if a type variable occurs in a type: either directly or
+// through the substitutions of the unknown types the type contains.  `visited`
+// stores the type variables whose substitutions have already been checked so
+// that cycles (which should never occur) can't cause infinite recursion.
+func (s *Solver) occursIn(tvarID int, dt DataType, visited map[int]bool) bool {
+	switch v := dt.(type) {
+	case *UnknownType:
+		if v.TypeVarID == tvarID {
+			return true
+		} else if visited[v.TypeVarID] {
+			return false
+		}
+
+		visited[v.TypeVarID] = true
+		if sub, ok := s.Substitutions[v.TypeVarID]; ok {
+			return s.occursIn(tvarID, sub.SubbedType, visited)
+		}
+	case TupleType:
+		for _, item := range v {
+			if s.occursIn(tvarID, item, visited) {
+				return true
+			}
+		}
+	case *VectorType:
+		return s.occursIn(tvarID, v.ElemType, visited)
+	case *RefType:
+		return s.occursIn(tvarID, v.ElemType, visited)
+	case *FuncType:
+		for _, arg := range v.Args {
+			if s.occursIn(tvarID, arg.Val.Type, visited) {
+				return true
+			}
+		}
+
+		return s.occursIn(tvarID, v.ReturnType, visited)
+	case *GenericInstanceType:
+		for _, tparam := range v.TypeParams {
+			if s.occursIn(tvarID, tparam, visited) {
+				return true
+			}
+		}
+	}
+
+	// all other types can't contain unknown types (see `unify`)
+	return false
+}
+
+// unifyWithSubstitution unifies two types one of which is the type currently
+// substituted for the given type variable.  The substitution is added to the
+// substitution trail while the types are unified so that any type mismatch can
+// explain where the substituted type came from.
+func (s *Solver) unifyWithSubstitution(tvarID int, lhType, rhType DataType, consKind int, pos *logging.TextPosition) (int, bool) {
+	s.substTrail = append(s.substTrail, substTrailEntry{tvar: s.Variables[tvarID], sub: s.Substitutions[tvarID]})
+	defer func() {
+		s.substTrail = s.substTrail[:len(s.substTrail)-1]
+	}()
+
+	return s.unify(lhType, rhType, consKind, pos)
+}
+
+// newSubstitution creates a new substitution recording the constraint and
+// position that introduced it
+func (s *Solver) newSubstitution(subbedType DataType, consKind int, pos *logging.TextPosition) *TypeSubstitution {
+	sub := &TypeSubstitution{ConsKind: consKind}
+	s.updateSubstitution(sub, subbedType, consKind, pos)
+	return sub
+}
+
+// updateSubstitution updates the type of a substitution and records the
+// constraint and position that caused the update
+func (s *Solver) updateSubstitution(sub *TypeSubstitution, subbedType DataType, consKind int, pos *logging.TextPosition) {
+	sub.SubbedType = subbedType
+	sub.ConsKind = consKind
+	sub.Provenance = append(sub.Provenance, &SubstitutionStep{
+		SubbedType: subbedType,
+		Constraint: s.currConstraint,
+		Position:   pos,
+	})
+}
+
 // logTypeMismatch logs a type mismatch error between two types.  It takes a
 // constraint kind to indicate what error it should log
 func (s *Solver) logTypeMismatch(lhType, rhType DataType, consKind int, pos *logging.TextPosition) {
+	reprs := s.PackagePaths.ReprTypes(lhType, rhType)
+	lhs, rhs := reprs[0], reprs[1]
+
 	var message string
 	switch consKind {
 	case TCEquality:
-		message = fmt.Sprintf("Type Mismatch: `%s` v `%s`", lhType.Repr(), rhType.Repr())
+		message = fmt.Sprintf("Type Mismatch: `%s` v `%s`", lhs, rhs)
 	case TCLeftCoerce:
-		message = fmt.Sprintf("Invalid Coercion: `%s` to `%s`", rhType.Repr(), lhType.Repr())
+		message = fmt.Sprintf("Invalid Coercion: `%s` to `%s`", rhs, lhs)
 	case TCRightCoerce:
-		message = fmt.Sprintf("Invalid Coercion: `%s` to `%s`", lhType.Repr(), rhType.Repr())
+		message = fmt.Sprintf("Invalid Coercion: `%s` to `%s`", lhs, rhs)
 	case TCCast:
-		message = fmt.Sprintf("Invalid Cast: `%s` to `%s`", rhType.Repr(), lhType.Repr())
+		message = fmt.Sprintf("Invalid Cast: `%s` to `%s`", rhs, lhs)
 	}
 
 	logging.LogCompileError(
@@ -437,5 +753,147 @@ func (s *Solver) logTypeMismatch(lhType, rhType DataType, consKind int, pos *log
 		message,
 		logging.LMKTyping,
 		pos,
+		append(s.constraintSides(pos), s.substitutionTrail(pos)...)...,
 	)
 }
+
+// substitutionTrail creates the annotations explaining how the solver arrived
+// at the substituted types involved in a type mismatch: each step of the
+// provenance of every substitution being unified against is pointed to along
+// with the type it inferred, and a note summarizes the chain of inferences.
+func (s *Solver) substitutionTrail(pos *logging.TextPosition) []logging.Annotation {
+	var annotations []logging.Annotation
+
+	for _, entry := range s.substTrail {
+		var chain []string
+
+		if entry.tvar != nil && entry.tvar.Position != nil && !samePosition(entry.tvar.Position, pos) {
+			annotations = append(annotations, logging.WithSpan(entry.tvar.Position, "the type of this had to be inferred"))
+		}
+
+		for i, step := range entry.sub.Provenance {
+			reason := "inferred as"
+			if i > 0 {
+				reason = "then as"
+			}
+
+			if step.Position == nil {
+				chain = append(chain, fmt.Sprintf("%s `%s`", reason, s.PackagePaths.ReprType(step.SubbedType)))
+				continue
+			}
+
+			chain = append(chain, fmt.Sprintf("%s `%s` at %s", reason, s.PackagePaths.ReprType(step.SubbedType),
+				logging.FormatPosition(s.Context.FilePath, step.Position)))
+
+			// the primary position is already labeled by the constraint
+			if samePosition(step.Position, pos) {
+				continue
+			}
+
+			label := fmt.Sprintf("%s `%s` here", reason, s.PackagePaths.ReprType(step.SubbedType))
+			if step.Constraint != nil {
+				label += ", " + s.constraintReason(step.Constraint)
+			}
+
+			annotations = append(annotations, logging.WithSpan(step.Position, label))
+		}
+
+		if len(chain) > 0 {
+			annotations = append(annotations, logging.WithNote("the type was "+strings.Join(chain, ", ")))
+		}
+	}
+
+	return annotations
+}
+
+// samePosition checks if two text positions (either of which may be `nil`)
+// refer to the same text
+func samePosition(a, b *logging.TextPosition) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+// substitutedType gets the type currently substituted for a type if it is an
+// unknown type that hasn't been evaluated yet.  Otherwise, the type is returned
+// as is.
+func (s *Solver) substitutedType(dt DataType) DataType {
+	if ut, ok := dt.(*UnknownType); ok && ut.EvalType == nil {
+		if sub, ok := s.Substitutions[ut.TypeVarID]; ok {
+			return sub.SubbedType
+		}
+	}
+
+	return dt
+}
+
+// constraintReason describes why a constraint was made in terms of its types
+func (s *Solver) constraintReason(cons *TypeConstraint) string {
+	lhs, rhs := s.constraintSideRepr(cons.Lhs), s.constraintSideRepr(cons.Rhs)
+
+	switch cons.Kind {
+	case TCLeftCoerce:
+		return fmt.Sprintf("because %s must coerce to %s", rhs, lhs)
+	case TCRightCoerce:
+		return fmt.Sprintf("because %s must coerce to %s", lhs, rhs)
+	case TCCast:
+		return fmt.Sprintf("because %s must be castable to %s", rhs, lhs)
+	default:
+		return fmt.Sprintf("because %s must equal %s", lhs, rhs)
+	}
+}
+
+// constraintSideRepr gets the representation of one side of a constraint for
+// an explanation: unknown types are described rather than shown as `_`
+func (s *Solver) constraintSideRepr(dt DataType) string {
+	if ut, ok := dt.(*UnknownType); ok && ut.EvalType == nil {
+		return "the inferred type"
+	}
+
+	return "`" + s.PackagePaths.ReprType(dt) + "`"
+}
+
+// constraintSides creates the annotations pointing to the sides of the
+// constraint currently being unified (if their positions are known)
+func (s *Solver) constraintSides(pos *logging.TextPosition) []logging.Annotation {
+	cons := s.currConstraint
+	if cons == nil {
+		return nil
+	}
+
+	reprs := s.PackagePaths.ReprTypes(s.substitutedType(cons.Lhs), s.substitutedType(cons.Rhs))
+	lhs, rhs := reprs[0], reprs[1]
+
+	var lhsLabel, rhsLabel string
+	switch cons.Kind {
+	case TCLeftCoerce:
+		lhsLabel = fmt.Sprintf("expected `%s` because of this", lhs)
+		rhsLabel = fmt.Sprintf("this has type `%s`", rhs)
+	case TCRightCoerce:
+		lhsLabel = fmt.Sprintf("this has type `%s`", lhs)
+		rhsLabel = fmt.Sprintf("expected `%s` because of this", rhs)
+	case TCCast:
+		lhsLabel = fmt.Sprintf("cast to `%s` here", lhs)
+		rhsLabel = fmt.Sprintf("this has type `%s`", rhs)
+	default:
+		lhsLabel = fmt.Sprintf("this has type `%s`", lhs)
+		rhsLabel = fmt.Sprintf("this has type `%s`", rhs)
+	}
+
+	var annotations []logging.Annotation
+	for _, side := range []struct {
+		pos   *logging.TextPosition
+		label string
+	}{{cons.LhsPosition, lhsLabel}, {cons.RhsPosition, rhsLabel}} {
+		if side.pos == nil {
+			continue
+		}
+
+		// a side at the position of the error labels the error itself
+		if samePosition(side.pos, pos) {
+			annotations = append(annotations, logging.WithLabel(side.label))
+		} else {
+			annotations = append(annotations, logging.WithSpan(side.pos, side.label))
+		}
+	}
+
+	return annotations
+}
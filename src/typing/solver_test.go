@@ -0,0 +1,222 @@
+package typing
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+// captureStdout captures everything written to stdout while running `f`
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	f()
+
+	w.Close()
+	return <-output
+}
+
+// newLoggedSolver creates a solver whose errors are logged in a source file
+// with the given contents
+func newLoggedSolver(t *testing.T, src string) *Solver {
+	t.Helper()
+
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "file.wrl")
+	if err := os.WriteFile(fpath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logging.Initialize(dir, "error")
+	if err := logging.SetColorMode("never"); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewSolver(&logging.LogContext{FilePath: fpath}, nil, nil, nil)
+}
+
+// solveLogged solves the constraints of a solver and returns whether solving
+// succeeded along with the messages that were displayed
+func solveLogged(t *testing.T, s *Solver) (bool, string) {
+	t.Helper()
+
+	var ok bool
+	output := captureStdout(t, func() {
+		ok = s.Solve()
+		logging.LogStageEnd()
+	})
+
+	return ok, output
+}
+
+var (
+	i32Type  = &PrimitiveType{PrimKind: PrimKindIntegral, PrimSpec: PrimIntI32}
+	boolType = &PrimitiveType{PrimKind: PrimKindBoolean}
+)
+
+func TestTypeMismatchSides(t *testing.T) {
+	s := newLoggedSolver(t, "f(x)\n")
+
+	call := &logging.TextPosition{StartLn: 1, StartCol: 0, EndLn: 1, EndCol: 4}
+	arg := &logging.TextPosition{StartLn: 1, StartCol: 2, EndLn: 1, EndCol: 3}
+	s.AddConstraintWithSides(i32Type, boolType, TCLeftCoerce, arg, call, arg)
+
+	ok, output := solveLogged(t, s)
+	if ok {
+		t.Fatal("solving succeeded with mismatched types")
+	}
+
+	// the argument is the primary span and the call is a secondary span
+	for _, want := range []string{
+		"  ^ this has type `bool`",
+		"---- expected `i32` because of this",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing `%s` in:\n%s", want, output)
+		}
+	}
+}
+
+func TestSubstitutionTrail(t *testing.T) {
+	s := newLoggedSolver(t, "let x = 1\nlet y = x + true\n")
+
+	xPos := &logging.TextPosition{StartLn: 1, StartCol: 4, EndLn: 1, EndCol: 5}
+	onePos := &logging.TextPosition{StartLn: 1, StartCol: 8, EndLn: 1, EndCol: 9}
+	addPos := &logging.TextPosition{StartLn: 2, StartCol: 8, EndLn: 2, EndCol: 16}
+
+	// `x` is inferred as `i32` from its initializer and then used as a `bool`
+	tv := s.NewTypeVar(nil, xPos, nil, nil, -1)
+	s.AddConstraint(tv, i32Type, TCEquality, onePos)
+	s.AddConstraint(tv, boolType, TCEquality, addPos)
+
+	ok, output := solveLogged(t, s)
+	if ok {
+		t.Fatal("solving succeeded with mismatched types")
+	}
+
+	for _, want := range []string{
+		"Type Mismatch: `i32` v `bool`",
+		"- the type of this had to be inferred",
+		"- inferred as `i32` here, because the inferred type must equal `i32`",
+		"note: the type was inferred as `i32` at file.wrl:1:9",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing `%s` in:\n%s", want, output)
+		}
+	}
+}
+
+func TestSubstitutionTrailWithoutPositions(t *testing.T) {
+	s := newLoggedSolver(t, "let x = 1\n")
+
+	// the constraint that fails has no position
+	tv := s.NewTypeVar(nil, &logging.TextPosition{StartLn: 1, StartCol: 4, EndLn: 1, EndCol: 5}, nil, nil, -1)
+	s.AddConstraint(tv, i32Type, TCEquality, &logging.TextPosition{StartLn: 1, StartCol: 8, EndLn: 1, EndCol: 9})
+	s.AddConstraint(tv, boolType, TCEquality, nil)
+
+	if ok, output := solveLogged(t, s); ok {
+		t.Fatal("solving succeeded with mismatched types")
+	} else if !strings.Contains(output, "note: the type was inferred as `i32` at file.wrl:1:9") {
+		t.Errorf("missing the inference note in:\n%s", output)
+	}
+
+	// nor does the substitution
+	tv = s.NewTypeVar(nil, nil, nil, nil, -1)
+	s.AddConstraint(tv, i32Type, TCEquality, nil)
+	s.AddConstraint(tv, boolType, TCEquality, nil)
+
+	if ok, output := solveLogged(t, s); ok {
+		t.Fatal("solving succeeded with mismatched types")
+	} else if !strings.Contains(output, "note: the type was inferred as `i32`\n") {
+		t.Errorf("missing the inference note in:\n%s", output)
+	}
+}
+
+func TestSolverReset(t *testing.T) {
+	s := newLoggedSolver(t, "")
+
+	// the constraints of an abandoned context must not leak into the next
+	s.AddConstraint(i32Type, boolType, TCEquality, nil)
+	s.NewTypeVar(nil, nil, nil, nil, -1)
+	s.Reset()
+
+	if len(s.Constraints) != 0 || len(s.Variables) != 0 || len(s.Substitutions) != 0 {
+		t.Fatal("resetting the solver didn't clear its context")
+	}
+
+	tv := s.NewTypeVar(nil, nil, nil, nil, -1)
+	s.AddConstraint(tv, i32Type, TCEquality, nil)
+
+	if ok, output := solveLogged(t, s); !ok {
+		t.Fatalf("solving failed:\n%s", output)
+	}
+
+	if !Equals(tv.EvalType, i32Type) {
+		t.Errorf("the type variable was evaluated as `%s`, want `i32`", ReprType(tv.EvalType))
+	}
+
+	// solving also clears the context
+	if len(s.Constraints) != 0 || len(s.Variables) != 0 || len(s.Substitutions) != 0 {
+		t.Error("solving the solver didn't clear its context")
+	}
+}
+
+func TestUnifyTypeVariableChains(t *testing.T) {
+	s := newLoggedSolver(t, "")
+
+	// `t0 = t1` after `t1 = t0` must not be reported as an infinite type
+	t0 := s.NewTypeVar(nil, nil, nil, nil, -1)
+	t1 := s.NewTypeVar(nil, nil, nil, nil, -1)
+	s.AddConstraint(t1, t0, TCEquality, nil)
+	s.AddConstraint(t0, t1, TCEquality, nil)
+	s.AddConstraint(t0, i32Type, TCEquality, nil)
+
+	if ok, output := solveLogged(t, s); !ok {
+		t.Fatalf("solving failed:\n%s", output)
+	}
+
+	for i, tv := range []*UnknownType{t0, t1} {
+		if !Equals(tv.EvalType, i32Type) {
+			t.Errorf("t%d was evaluated as `%s`, want `i32`", i, ReprType(tv.EvalType))
+		}
+	}
+}
+
+func TestInfiniteType(t *testing.T) {
+	s := newLoggedSolver(t, "let x = [x]\n")
+
+	xPos := &logging.TextPosition{StartLn: 1, StartCol: 4, EndLn: 1, EndCol: 5}
+	vecPos := &logging.TextPosition{StartLn: 1, StartCol: 8, EndLn: 1, EndCol: 11}
+
+	// `t0 = [t0]`
+	tv := s.NewTypeVar(nil, xPos, func() {}, nil, -1)
+	s.AddConstraint(tv, &VectorType{ElemType: tv}, TCEquality, vecPos)
+
+	ok, output := solveLogged(t, s)
+	if ok {
+		t.Fatal("solving succeeded with an infinite type")
+	}
+
+	if !strings.Contains(output, "Infinite Type: an inferred type cannot contain itself") {
+		t.Errorf("missing the infinite type error in:\n%s", output)
+	}
+}
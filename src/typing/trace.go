@@ -0,0 +1,346 @@
+package typing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"whirlwind/logging"
+)
+
+// SolverTrace is a record of everything the solver did while solving a single
+// type context: the type variables and constraints it was given, every
+// unification step it performed and the substitutions it arrived at.  It is
+// used to debug type inference (see `whirl check --trace-types`).  A trace is
+// recorded by setting the `Trace` field of the solver before calling `Solve`.
+type SolverTrace struct {
+	// Context describes what was being solved (eg. "body of `f`")
+	Context  string `json:"context"`
+	FilePath string `json:"file"`
+
+	Variables     []*TraceVariable     `json:"variables"`
+	Constraints   []*TraceConstraint   `json:"constraints"`
+	Steps         []*TraceStep         `json:"steps"`
+	Substitutions []*TraceSubstitution `json:"substitutions"`
+
+	// Succeeded indicates whether solving succeeded
+	Succeeded bool `json:"succeeded"`
+
+	// depth is the current depth of the unification being traced
+	depth int
+}
+
+// TraceVariable is a type variable in a solver trace
+type TraceVariable struct {
+	ID          int            `json:"id"`
+	DefaultType string         `json:"default_type,omitempty"`
+	Position    *TracePosition `json:"position,omitempty"`
+
+	// EvalType is the type the variable was evaluated to (empty if it was not
+	// solved)
+	EvalType string `json:"eval_type,omitempty"`
+}
+
+// TraceConstraint is a type constraint in a solver trace
+type TraceConstraint struct {
+	Kind     string         `json:"kind"`
+	Lhs      string         `json:"lhs"`
+	Rhs      string         `json:"rhs"`
+	Position *TracePosition `json:"position,omitempty"`
+}
+
+// TraceStep is a single unification step in a solver trace.  Every constraint
+// is unified in a step of depth 0 and the unifications performed to satisfy a
+// step are traced as the steps following it with a greater depth.
+type TraceStep struct {
+	// Constraint is the index of the constraint being unified (-1 if the
+	// step was not performed for a constraint)
+	Constraint int `json:"constraint"`
+
+	Depth    int            `json:"depth"`
+	Kind     string         `json:"kind"`
+	Lhs      string         `json:"lhs"`
+	Rhs      string         `json:"rhs"`
+	Position *TracePosition `json:"position,omitempty"`
+
+	// Result is the type relation determined by the step (`equal`, `left`,
+	// or `right`) or `mismatch` if unification failed
+	Result string `json:"result"`
+}
+
+// TraceSubstitution is a final substitution in a solver trace
+type TraceSubstitution struct {
+	TypeVarID int    `json:"type_var"`
+	Type      string `json:"type"`
+	Kind      string `json:"kind"`
+
+	// Provenance lists the types the substitution held in order (see
+	// `TypeSubstitution`)
+	Provenance []*TraceProvenanceStep `json:"provenance"`
+}
+
+// TraceProvenanceStep is a step in the provenance of a traced substitution
+type TraceProvenanceStep struct {
+	Type     string         `json:"type"`
+	Position *TracePosition `json:"position,omitempty"`
+}
+
+// TracePosition is a text position in a solver trace.  Unlike text positions,
+// its lines and columns are both 1-indexed (as they are displayed).
+type TracePosition struct {
+	StartLn  int `json:"start_line"`
+	StartCol int `json:"start_col"`
+	EndLn    int `json:"end_line"`
+	EndCol   int `json:"end_col"`
+}
+
+// NewSolverTrace creates a new, empty solver trace
+func NewSolverTrace(context, fpath string) *SolverTrace {
+	return &SolverTrace{Context: context, FilePath: fpath}
+}
+
+// tracePosition converts a text position into a trace position
+func tracePosition(pos *logging.TextPosition) *TracePosition {
+	if pos == nil {
+		return nil
+	}
+
+	return &TracePosition{
+		StartLn:  pos.StartLn,
+		StartCol: pos.StartCol + 1,
+		EndLn:    pos.EndLn,
+		EndCol:   pos.EndCol + 1,
+	}
+}
+
+// String converts a trace position into a string of the form `ln:col`
+func (tp *TracePosition) String() string {
+	if tp == nil {
+		return "?"
+	}
+
+	return fmt.Sprintf("%d:%d", tp.StartLn, tp.StartCol)
+}
+
+// traceRepr gets the representation of a type in a trace: unknown types are
+// represented by their type variable (eg. `t0`) so they can be told apart
+func (s *Solver) traceRepr(dt DataType) string {
+	if dt == nil {
+		return ""
+	}
+
+	if ut, ok := dt.(*UnknownType); ok {
+		return fmt.Sprintf("t%d", ut.TypeVarID)
+	}
+
+	return s.PackagePaths.ReprType(dt)
+}
+
+// constraintKindNames are the names of the kinds of constraints in traces
+var constraintKindNames = map[int]string{
+	TCEquality:    "equality",
+	TCLeftCoerce:  "left-coerce",
+	TCRightCoerce: "right-coerce",
+	TCCast:        "cast",
+}
+
+// constraintKindOperators are the operators used to display constraints of
+// each kind in text traces: the arrows point in the direction of coercion
+var constraintKindOperators = map[int]string{
+	TCEquality:    "==",
+	TCLeftCoerce:  "<-",
+	TCRightCoerce: "->",
+	TCCast:        "<- (cast)",
+}
+
+// typeRelationNames are the names of the type relations in traces
+var typeRelationNames = map[int]string{
+	UEqual: "equal",
+	ULeft:  "left",
+	URight: "right",
+}
+
+// -----------------------------------------------------------------------------
+
+// traceStart records the type variables and constraints the solver was given
+func (s *Solver) traceStart() {
+	// the variables are identified by their index so we can just count up
+	for id := 0; id < len(s.Variables); id++ {
+		tv := s.Variables[id]
+		s.Trace.Variables = append(s.Trace.Variables, &TraceVariable{
+			ID:          tv.ID,
+			DefaultType: s.traceRepr(tv.DefaultType),
+			Position:    tracePosition(tv.Position),
+		})
+	}
+
+	for _, cons := range s.Constraints {
+		s.Trace.Constraints = append(s.Trace.Constraints, &TraceConstraint{
+			Kind:     constraintKindNames[cons.Kind],
+			Lhs:      s.traceRepr(cons.Lhs),
+			Rhs:      s.traceRepr(cons.Rhs),
+			Position: tracePosition(cons.Position),
+		})
+	}
+}
+
+// traceUnify records a unification step and returns the function that should
+// be called with the result of the step once it is complete
+func (s *Solver) traceUnify(lhType, rhType DataType, consKind int, pos *logging.TextPosition) func(int, bool) {
+	step := &TraceStep{
+		Constraint: -1,
+		Depth:      s.Trace.depth,
+		Kind:       constraintKindNames[consKind],
+		Lhs:        s.traceRepr(lhType),
+		Rhs:        s.traceRepr(rhType),
+		Position:   tracePosition(pos),
+	}
+
+	for i, cons := range s.Constraints {
+		if cons == s.currConstraint {
+			step.Constraint = i
+			break
+		}
+	}
+
+	s.Trace.Steps = append(s.Trace.Steps, step)
+	s.Trace.depth++
+
+	return func(tr int, ok bool) {
+		s.Trace.depth--
+
+		if ok {
+			step.Result = typeRelationNames[tr]
+		} else {
+			step.Result = "mismatch"
+		}
+	}
+}
+
+// traceEnd records the final substitutions of the solver and the values of its
+// type variables
+func (s *Solver) traceEnd(succeeded bool) {
+	for _, tv := range s.Trace.Variables {
+		if tvar, ok := s.Variables[tv.ID]; ok && tvar.Unknown != nil {
+			tv.EvalType = s.traceRepr(tvar.Unknown.EvalType)
+		}
+	}
+
+	for id := 0; id < len(s.Variables); id++ {
+		sub, ok := s.Substitutions[id]
+		if !ok {
+			continue
+		}
+
+		tsub := &TraceSubstitution{
+			TypeVarID: id,
+			Type:      s.traceRepr(sub.SubbedType),
+			Kind:      constraintKindNames[sub.ConsKind],
+		}
+
+		for _, step := range sub.Provenance {
+			tsub.Provenance = append(tsub.Provenance, &TraceProvenanceStep{
+				Type:     s.traceRepr(step.SubbedType),
+				Position: tracePosition(step.Position),
+			})
+		}
+
+		s.Trace.Substitutions = append(s.Trace.Substitutions, tsub)
+	}
+
+	s.Trace.Succeeded = succeeded
+}
+
+// -----------------------------------------------------------------------------
+
+// WriteSolverTraces writes solver traces in the given format (`text` or
+// `json`)
+func WriteSolverTraces(w io.Writer, traces []*SolverTrace, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		enc.SetEscapeHTML(false)
+
+		// an empty list should still be written as a list
+		if traces == nil {
+			traces = []*SolverTrace{}
+		}
+
+		return enc.Encode(traces)
+	case "text":
+		for _, trace := range traces {
+			trace.writeText(w)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("invalid trace format: `%s`", format)
+}
+
+// writeText writes a solver trace in a human-readable form
+func (st *SolverTrace) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Type Trace: %s (file: %s)\n", st.Context, st.FilePath)
+	fmt.Fprintln(w, strings.Repeat("=", 40))
+
+	fmt.Fprintln(w, "Type Variables:")
+	for _, tv := range st.Variables {
+		fmt.Fprintf(w, "  t%d at %s", tv.ID, tv.Position)
+
+		if tv.DefaultType != "" {
+			fmt.Fprintf(w, ", default: %s", tv.DefaultType)
+		}
+
+		if tv.EvalType != "" {
+			fmt.Fprintf(w, ", evaluated: %s", tv.EvalType)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "Constraints:")
+	for i, cons := range st.Constraints {
+		fmt.Fprintf(w, "  #%d %s %s %s (%s) at %s\n", i, cons.Lhs, kindOperator(cons.Kind), cons.Rhs, cons.Kind, cons.Position)
+	}
+
+	fmt.Fprintln(w, "Unification:")
+	for _, step := range st.Steps {
+		prefix := "  "
+		if step.Depth == 0 {
+			prefix += fmt.Sprintf("#%d ", step.Constraint)
+		} else {
+			prefix += strings.Repeat("  ", step.Depth+1)
+		}
+
+		fmt.Fprintf(w, "%s%s %s %s => %s\n", prefix, step.Lhs, kindOperator(step.Kind), step.Rhs, step.Result)
+	}
+
+	fmt.Fprintln(w, "Substitutions:")
+	for _, sub := range st.Substitutions {
+		provenance := make([]string, len(sub.Provenance))
+		for i, step := range sub.Provenance {
+			provenance[i] = fmt.Sprintf("%s at %s", step.Type, step.Position)
+		}
+
+		fmt.Fprintf(w, "  t%d := %s (%s) from %s\n", sub.TypeVarID, sub.Type, sub.Kind, strings.Join(provenance, ", then "))
+	}
+
+	if st.Succeeded {
+		fmt.Fprint(w, "Result: solved\n\n")
+	} else {
+		fmt.Fprint(w, "Result: failed\n\n")
+	}
+}
+
+// kindOperator gets the operator used to display a constraint kind by name
+func kindOperator(kindName string) string {
+	for kind, name := range constraintKindNames {
+		if name == kindName {
+			return constraintKindOperators[kind]
+		}
+	}
+
+	return "?"
+}
@@ -0,0 +1,97 @@
+package typing
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"whirlwind/logging"
+)
+
+// traceTestSolve solves a small type context with tracing enabled: `t0` is
+// inferred as `i32` and then coerced to `i32`
+func traceTestSolve(t *testing.T) *SolverTrace {
+	t.Helper()
+
+	s := newLoggedSolver(t, "let x = 1\n")
+	trace := NewSolverTrace("body of `f`", "file.wrl")
+	s.Trace = trace
+
+	tv := s.NewTypeVar(nil, &logging.TextPosition{StartLn: 1, StartCol: 4, EndLn: 1, EndCol: 5}, nil, nil, -1)
+	s.AddConstraint(tv, i32Type, TCEquality, &logging.TextPosition{StartLn: 1, StartCol: 8, EndLn: 1, EndCol: 9})
+	s.AddConstraint(i32Type, tv, TCLeftCoerce, nil)
+
+	if ok, output := solveLogged(t, s); !ok {
+		t.Fatalf("solving failed:\n%s", output)
+	}
+
+	return trace
+}
+
+func TestWriteSolverTracesText(t *testing.T) {
+	buff := &bytes.Buffer{}
+	if err := WriteSolverTraces(buff, []*SolverTrace{traceTestSolve(t)}, "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"Type Trace: body of `f` (file: file.wrl)",
+		strings.Repeat("=", 40),
+		"Type Variables:",
+		"  t0 at 1:5, evaluated: i32",
+		"Constraints:",
+		"  #0 t0 == i32 (equality) at 1:9",
+		"  #1 i32 <- t0 (left-coerce) at ?",
+		"Unification:",
+		"  #0 t0 == i32 => equal",
+		"  #1 i32 <- t0 => equal",
+		"      i32 == i32 => equal",
+		"Substitutions:",
+		"  t0 := i32 (equality) from i32 at 1:9",
+		"Result: solved",
+		"",
+		"",
+	}, "\n")
+
+	if got := buff.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteSolverTracesJSON(t *testing.T) {
+	buff := &bytes.Buffer{}
+	if err := WriteSolverTraces(buff, []*SolverTrace{traceTestSolve(t)}, "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var traces []*SolverTrace
+	if err := json.Unmarshal(buff.Bytes(), &traces); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want 1", len(traces))
+	}
+
+	trace := traces[0]
+	if !trace.Succeeded || trace.Context != "body of `f`" || len(trace.Variables) != 1 || len(trace.Constraints) != 2 {
+		t.Errorf("got trace %+v", trace)
+	}
+
+	if len(trace.Substitutions) != 1 || trace.Substitutions[0].Type != "i32" || trace.Substitutions[0].Provenance[0].Position.StartCol != 9 {
+		t.Errorf("got substitutions %+v", trace.Substitutions)
+	}
+
+	// no traces are still written as a list
+	buff.Reset()
+	if err := WriteSolverTraces(buff, nil, "json"); err != nil {
+		t.Fatal(err)
+	} else if got := strings.TrimSpace(buff.String()); got != "[]" {
+		t.Errorf("got %s for no traces, want []", got)
+	}
+
+	if err := WriteSolverTraces(buff, nil, "yaml"); err == nil {
+		t.Error("expected an error for an invalid format")
+	}
+}
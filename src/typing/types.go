@@ -35,13 +35,17 @@ type DataType interface {
 	equals(dt DataType) bool
 
 	// copyTemplate is used to create a generic instances by duplicate a type
-	// template so as to perserve the temporary values of various wildcard types
-	// in the new instance without requiring that the original template retain
-	// those values.  This is NOT a true deep copy -- several types that can not
-	// contain WildcardTypes are not copied
-	copyTemplate() DataType
+	// template.  The wildcard types in the template are replaced by those they
+	// are mapped to in the given wildcard map so that the template itself is
+	// never changed.  This is NOT a true deep copy -- several types that can
+	// not contain WildcardTypes are not copied
+	copyTemplate(wcm wildcardMap) DataType
 }
 
+// wildcardMap maps the wildcard types of a template to the wildcard types that
+// replace them in a copy of the template (see `copyTemplate`)
+type wildcardMap map[*WildcardType]*WildcardType
+
 // ContainsType checks if a slice of data types contains a type equivalent to
 // the given data type (via. the equals method)
 func ContainsType(dt DataType, slice []DataType) bool {
@@ -55,11 +59,11 @@ func ContainsType(dt DataType, slice []DataType) bool {
 }
 
 // copyTemplateSlice applies copyTemplate to a slice of data types
-func copyTemplateSlice(dtSlice []DataType) []DataType {
+func copyTemplateSlice(dtSlice []DataType, wcm wildcardMap) []DataType {
 	newList := make([]DataType, len(dtSlice))
 
 	for i, item := range dtSlice {
-		newList[i] = item.copyTemplate()
+		newList[i] = item.copyTemplate(wcm)
 	}
 
 	return newList
@@ -154,7 +158,7 @@ func (pt *PrimitiveType) equals(other DataType) bool {
 }
 
 // Primitives can't store WildcardTypes so no copy is necessary
-func (pt *PrimitiveType) copyTemplate() DataType {
+func (pt *PrimitiveType) copyTemplate(wcm wildcardMap) DataType {
 	return pt
 }
 
@@ -199,8 +203,8 @@ func (tt TupleType) equals(other DataType) bool {
 	return false
 }
 
-func (tt TupleType) copyTemplate() DataType {
-	return TupleType(copyTemplateSlice(tt))
+func (tt TupleType) copyTemplate(wcm wildcardMap) DataType {
+	return TupleType(copyTemplateSlice(tt, wcm))
 }
 
 // -----------------------------------------------------------------------------
@@ -223,9 +227,9 @@ func (vt *VectorType) equals(other DataType) bool {
 	return false
 }
 
-func (vt *VectorType) copyTemplate() DataType {
+func (vt *VectorType) copyTemplate(wcm wildcardMap) DataType {
 	return &VectorType{
-		ElemType: vt.ElemType.copyTemplate(),
+		ElemType: vt.ElemType.copyTemplate(wcm),
 		Size:     vt.Size,
 	}
 }
@@ -259,11 +263,11 @@ func (rt *RefType) equals(other DataType) bool {
 	return false
 }
 
-func (rt *RefType) copyTemplate() DataType {
+func (rt *RefType) copyTemplate(wcm wildcardMap) DataType {
 	// one of those situations where spread initialization would be nice...
 	return &RefType{
 		Constant: rt.Constant,
-		ElemType: rt.ElemType.copyTemplate(),
+		ElemType: rt.ElemType.copyTemplate(wcm),
 	}
 }
 
@@ -363,12 +367,12 @@ func (ft *FuncType) equals(other DataType) bool {
 	return false
 }
 
-func (ft *FuncType) copyTemplate() DataType {
+func (ft *FuncType) copyTemplate(wcm wildcardMap) DataType {
 	newArgs := make([]*FuncArg, len(ft.Args))
 
 	for i, arg := range ft.Args {
 		newArgs[i] = &FuncArg{
-			Val:        arg.Val.copyTemplate(),
+			Val:        arg.Val.copyTemplate(wcm),
 			Name:       arg.Name,
 			Indefinite: arg.Indefinite,
 			Optional:   arg.Optional,
@@ -377,7 +381,7 @@ func (ft *FuncType) copyTemplate() DataType {
 
 	return &FuncType{
 		Args:       newArgs,
-		ReturnType: ft.ReturnType.copyTemplate(),
+		ReturnType: ft.ReturnType.copyTemplate(wcm),
 		Async:      ft.Async,
 		Boxable:    ft.Boxable,
 		Boxed:      ft.Boxed,
@@ -399,6 +403,10 @@ type StructType struct {
 	Fields       map[string]*TypedValue
 	Packed       bool
 	Inherit      *StructType
+
+	// FieldOrder stores the names of the fields in the order they were
+	// declared (which is the order they are laid out in memory)
+	FieldOrder []string
 }
 
 func (st *StructType) Repr() string {
@@ -413,16 +421,16 @@ func (st *StructType) equals(other DataType) bool {
 	return false
 }
 
-func (st *StructType) copyTemplate() DataType {
+func (st *StructType) copyTemplate(wcm wildcardMap) DataType {
 	newFields := make(map[string]*TypedValue)
 
 	for name, field := range st.Fields {
-		newFields[name] = field.copyTemplate()
+		newFields[name] = field.copyTemplate(wcm)
 	}
 
 	var newInherit *StructType
 	if st.Inherit != nil {
-		newInherit = st.Inherit.copyTemplate().(*StructType)
+		newInherit = st.Inherit.copyTemplate(wcm).(*StructType)
 	}
 
 	return &StructType{
@@ -431,6 +439,7 @@ func (st *StructType) copyTemplate() DataType {
 		Fields:       newFields,
 		Packed:       st.Packed,
 		Inherit:      newInherit,
+		FieldOrder:   st.FieldOrder,
 	}
 }
 
@@ -444,9 +453,9 @@ func (tv *TypedValue) Equals(otv *TypedValue) bool {
 	return Equals(tv.Type, otv.Type) && tv.Constant == otv.Constant && tv.Volatile == otv.Volatile
 }
 
-func (tv *TypedValue) copyTemplate() *TypedValue {
+func (tv *TypedValue) copyTemplate(wcm wildcardMap) *TypedValue {
 	return &TypedValue{
-		Type:     tv.Type.copyTemplate(),
+		Type:     tv.Type.copyTemplate(wcm),
 		Constant: tv.Constant,
 		Volatile: tv.Volatile,
 	}
@@ -462,6 +471,10 @@ type InterfType struct {
 	Name         string
 	SrcPackageID uint
 
+	// BoundType is the type that a type interface is bound to.  It is `nil`
+	// for conceptual interfaces.
+	BoundType DataType
+
 	// Implements stores only the various interfaces that this InterfType
 	// implements explicitly (to prevent virtual methods from appearing on
 	// interfaces that don't actually fully implement an interface)
@@ -485,6 +498,9 @@ type InterfMethod struct {
 	// Specializations stores any of the specializations of this method.  Note
 	// that this slice is NOT mirrored on the associated definition node
 	Specializations []*GenericSpecialization
+
+	// DocComment is the doc comment of the method's definition (if any)
+	DocComment string
 }
 
 const (
@@ -497,6 +513,11 @@ const (
 
 func (it *InterfType) Repr() string {
 	if it.Name == "" {
+		// type interfaces are represented by the types they are bound to
+		if it.BoundType != nil {
+			return it.BoundType.Repr()
+		}
+
 		return "<type-interf>"
 	}
 
@@ -511,28 +532,35 @@ func (it *InterfType) equals(other DataType) bool {
 	return false
 }
 
-func (it *InterfType) copyTemplate() DataType {
+func (it *InterfType) copyTemplate(wcm wildcardMap) DataType {
 	newMethods := make(map[string]*InterfMethod, len(it.Methods))
 
 	for name, method := range it.Methods {
 		newMethods[name] = &InterfMethod{
-			Signature: method.Signature.copyTemplate(),
-			Kind:      method.Kind,
+			Signature:  method.Signature.copyTemplate(wcm),
+			Kind:       method.Kind,
+			DocComment: method.DocComment,
 		}
 	}
 
 	// really wishing for generics rn...
 	newImplements := make([]*InterfType, len(it.Implements))
 	for i, implement := range it.Implements {
-		newImplements[i] = implement.copyTemplate().(*InterfType)
+		newImplements[i] = implement.copyTemplate(wcm).(*InterfType)
+	}
+
+	var newBoundType DataType
+	if it.BoundType != nil {
+		newBoundType = it.BoundType.copyTemplate(wcm)
 	}
 
 	return &InterfType{
 		Name:         it.Name,
 		SrcPackageID: it.SrcPackageID,
+		BoundType:    newBoundType,
 		Methods:      newMethods,
 		Implements:   newImplements,
-		Instances:    copyTemplateSlice(it.Instances),
+		Instances:    copyTemplateSlice(it.Instances, wcm),
 	}
 }
 
@@ -561,7 +589,7 @@ func (at *AlgebraicType) equals(other DataType) bool {
 	return false
 }
 
-func (at *AlgebraicType) copyTemplate() DataType {
+func (at *AlgebraicType) copyTemplate(wcm wildcardMap) DataType {
 	newAt := &AlgebraicType{
 		Name:         at.Name,
 		SrcPackageID: at.SrcPackageID,
@@ -572,7 +600,7 @@ func (at *AlgebraicType) copyTemplate() DataType {
 	for i, vari := range at.Variants {
 		newAt.Variants[i] = &AlgebraicVariant{
 			Name:   vari.Name,
-			Values: copyTemplateSlice(vari.Values),
+			Values: copyTemplateSlice(vari.Values, wcm),
 			Parent: newAt,
 		}
 	}
@@ -644,7 +672,7 @@ func (av *AlgebraicVariant) equals(other DataType) bool {
 // AlgebraicVariants should NEVER be directly in templates (because they need
 // to maintain a consistent parent and should never appear in a generic
 // template)
-func (av *AlgebraicVariant) copyTemplate() DataType {
+func (av *AlgebraicVariant) copyTemplate(wcm wildcardMap) DataType {
 	logging.LogFatal("`copyTemplate` called on `AlgebraicVariant`")
 	return nil
 }
@@ -679,10 +707,10 @@ func (at *AliasType) equals(other DataType) bool {
 	return false
 }
 
-func (at *AliasType) copyTemplate() DataType {
+func (at *AliasType) copyTemplate(wcm wildcardMap) DataType {
 	return &AliasType{
 		Name:         at.Name,
 		SrcPackageID: at.SrcPackageID,
-		TrueType:     at.TrueType.copyTemplate(),
+		TrueType:     at.TrueType.copyTemplate(wcm),
 	}
 }
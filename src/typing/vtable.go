@@ -0,0 +1,147 @@
+package typing
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Interface Dispatch
+// ------------------
+// Methods called on interface values are dispatched through the method table
+// (vtable) of the type of the value stored in the interface.  Every conceptual
+// interface has a fixed layout of method slots and the vtable of each type
+// that implements the interface stores the implementation of each method in
+// the corresponding slot:
+//
+// - The slots of an interface are its methods sorted by name so that every
+//   package that uses the interface agrees on its layout.  Generic methods
+//   have no slots since they can only be called once their type parameters are
+//   known (so they are always dispatched statically).
+// - A slot is filled by the method of the same name and signature bound to the
+//   type (either implementing an abstract method, overriding a virtual method
+//   or simply matching it).  A virtual method that the type's binding inherited
+//   by deriving an interface is resolved through the interfaces the binding
+//   `Implements` to the interface that defines its body.
+// - If the type does not bind the method at all, the slot is filled by the
+//   virtual method of the interface itself.
+
+// MethodSlot is a slot in the method table of an interface
+type MethodSlot struct {
+	Name      string
+	Index     int
+	Signature DataType
+}
+
+// VTablePlan describes the method table of a type for an interface that it
+// implements: which method fills each method slot of the interface.
+type VTablePlan struct {
+	Type    DataType
+	Interf  *InterfType
+	Entries []*VTableEntry
+}
+
+// VTableEntry is the method that fills a single slot of a method table
+type VTableEntry struct {
+	Slot *MethodSlot
+
+	// Source is the interface that defines the body of the method: either a
+	// type interface bound to the type or a conceptual interface that defines
+	// a virtual method
+	Source *InterfType
+
+	// Kind is the kind of the method in its source interface (eg. `MKVirtual`)
+	Kind int
+
+	// Overrides is the conceptual interface that defines the virtual method
+	// overridden by this method.  It is `nil` if the method does not override
+	// a virtual method.
+	Overrides *InterfType
+}
+
+// InterfSlots computes the method slots of a conceptual interface
+func InterfSlots(it *InterfType) []*MethodSlot {
+	names := make([]string, 0, len(it.Methods))
+	for name, method := range it.Methods {
+		if _, ok := method.Signature.(*GenericType); !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	slots := make([]*MethodSlot, len(names))
+	for i, name := range names {
+		slots[i] = &MethodSlot{Name: name, Index: i, Signature: it.Methods[name].Signature}
+	}
+
+	return slots
+}
+
+// PlanVTable builds the method table plan of a type for a conceptual interface.
+// It returns an error if the type does not implement the interface or if one
+// of the slots of the interface cannot be filled.  `InnerType` should be called
+// on `dt` before this function is invoked
+func (s *Solver) PlanVTable(dt DataType, it *InterfType) (*VTablePlan, error) {
+	if !s.ImplementsInterf(dt, it) {
+		return nil, fmt.Errorf("type `%s` does not implement interface `%s`", s.PackagePaths.ReprType(dt), s.PackagePaths.ReprType(it))
+	}
+
+	s.lockSharedState()
+	defer s.unlockSharedState()
+
+	bindings := append(s.GetBindings(s.LocalBindings, dt), s.GetBindings(s.GlobalBindings, dt)...)
+
+	plan := &VTablePlan{Type: dt, Interf: it}
+	for _, slot := range InterfSlots(it) {
+		entry := &VTableEntry{Slot: slot}
+
+		for _, binding := range bindings {
+			if containsMethod(binding, slot.Name, it.Methods[slot.Name]) {
+				entry.Source = binding
+				entry.Kind = binding.Methods[slot.Name].Kind
+				break
+			}
+		}
+
+		switch entry.Kind {
+		case MKVirtual:
+			// the binding inherited the method by deriving an interface
+			if entry.Source != nil {
+				entry.Source = virtualSource(entry.Source, slot.Name)
+			}
+		case MKOverride:
+			entry.Overrides = virtualSource(entry.Source, slot.Name)
+		}
+
+		if entry.Source == nil {
+			if it.Methods[slot.Name].Kind != MKVirtual {
+				reprs := s.PackagePaths.ReprTypes(dt, it)
+				return nil, fmt.Errorf("type `%s` has no implementation of method `%s` of interface `%s`", reprs[0], slot.Name, reprs[1])
+			}
+
+			entry.Source = it
+			entry.Kind = MKVirtual
+		}
+
+		plan.Entries = append(plan.Entries, entry)
+	}
+
+	return plan, nil
+}
+
+// virtualSource finds the conceptual interface that defines the body of a
+// virtual method inherited by an interface through the interfaces it
+// implements.  It returns `nil` if no such interface exists.
+func virtualSource(it *InterfType, methodName string) *InterfType {
+	for _, implement := range it.Implements {
+		if method, ok := implement.Methods[methodName]; ok && method.Kind == MKVirtual {
+			if source := virtualSource(implement, methodName); source != nil {
+				return source
+			}
+
+			return implement
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package typing
+
+import "testing"
+
+func TestInterfSlots(t *testing.T) {
+	sig := &FuncType{ReturnType: boolType}
+	it := &InterfType{Name: "Shape", Methods: map[string]*InterfMethod{
+		"size": {Signature: sig, Kind: MKVirtual},
+		"area": {Signature: sig, Kind: MKAbstract},
+		"name": {Signature: sig, Kind: MKVirtual},
+		// generic methods are always dispatched statically
+		"map": {Signature: &GenericType{Template: sig}, Kind: MKAbstract},
+	}}
+
+	slots := InterfSlots(it)
+
+	want := []string{"area", "name", "size"}
+	if len(slots) != len(want) {
+		t.Fatalf("got %d slots, want %d", len(slots), len(want))
+	}
+
+	for i, name := range want {
+		if slots[i].Name != name || slots[i].Index != i {
+			t.Errorf("got slot `%s` at %d, want `%s` at %d", slots[i].Name, slots[i].Index, name, i)
+		}
+	}
+}
+
+func TestVirtualSource(t *testing.T) {
+	sig := &FuncType{ReturnType: boolType}
+
+	// `Shape` defines `name` and `Named` inherits it from `Shape`
+	shape := &InterfType{Name: "Shape", Methods: map[string]*InterfMethod{
+		"name": {Signature: sig, Kind: MKVirtual},
+	}}
+	named := &InterfType{Name: "Named", Methods: map[string]*InterfMethod{
+		"name": shape.Methods["name"],
+	}, Implements: []*InterfType{shape}}
+	binding := &InterfType{Methods: map[string]*InterfMethod{
+		"name": shape.Methods["name"],
+		"area": {Signature: sig, Kind: MKImplement},
+	}, Implements: []*InterfType{named}}
+
+	if source := virtualSource(binding, "name"); source != shape {
+		t.Errorf("got the source `%s`, want `Shape`", ReprType(source))
+	}
+
+	if source := virtualSource(binding, "area"); source != nil {
+		t.Errorf("got the source `%s` of a method that isn't virtual", ReprType(source))
+	}
+}
@@ -118,11 +118,31 @@ func (w *Walker) getFieldOrMethod(dt typing.DataType, fieldName string, opPos, n
 		}
 	}
 
-	// if we reach here, then no match was found
+	// if we reach here, then no match was found so we suggest the closest field
+	// or method name (if there is one)
+	var candidates []string
+	switch v := dt.(type) {
+	case *typing.StructType:
+		for name := range v.Fields {
+			candidates = append(candidates, name)
+		}
+	case *typing.InterfType:
+		for name := range v.Methods {
+			candidates = append(candidates, name)
+		}
+	}
+
+	for _, binding := range w.getBindings(dt) {
+		for name := range binding.Methods {
+			candidates = append(candidates, name)
+		}
+	}
+
 	w.logError(
-		fmt.Sprintf("Type `%s` has no bound method `%s`", dt.Repr(), fieldName),
+		fmt.Sprintf("Type `%s` has no bound method `%s`", w.solver.PackagePaths.ReprType(dt), fieldName),
 		logging.LMKProp,
 		namePos,
+		logging.WithSuggestion(fieldName, candidates),
 	)
 
 	return nil, false
@@ -143,7 +163,7 @@ func (w *Walker) walkFuncCall(root common.HIRExpr, rootInnerType typing.DataType
 		} else {
 			// ah yes, the classic `fallthrough` not allowed in type switches...
 			w.logError(
-				fmt.Sprintf("Unable to call non-function of type `%s`", rootInnerType.Repr()),
+				fmt.Sprintf("Unable to call non-function of type `%s`", w.solver.PackagePaths.ReprType(rootInnerType)),
 				logging.LMKTyping,
 				branch.Position(),
 			)
@@ -152,7 +172,7 @@ func (w *Walker) walkFuncCall(root common.HIRExpr, rootInnerType typing.DataType
 		}
 	default:
 		w.logError(
-			fmt.Sprintf("Unable to call non-function of type `%s`", rootInnerType.Repr()),
+			fmt.Sprintf("Unable to call non-function of type `%s`", w.solver.PackagePaths.ReprType(rootInnerType)),
 			logging.LMKTyping,
 			branch.Position(),
 		)
@@ -241,7 +261,7 @@ func (w *Walker) walkFuncCallArgs(fntype *typing.FuncType, branch *syntax.ASTBra
 					}
 
 					if argexpr, ok := w.walkExpr(arg.BranchAt(0)); ok {
-						w.solver.AddConstraint(farg.Val.Type, argexpr.Type(), typing.TCLeftCoerce, arg.Position())
+						w.solver.AddConstraintWithSides(farg.Val.Type, argexpr.Type(), typing.TCLeftCoerce, arg.Position(), branch.Position(), arg.Position())
 
 						if farg.Indefinite {
 							indefArgs = append(indefArgs, argexpr)
@@ -283,7 +303,7 @@ func (w *Walker) walkFuncCallArgs(fntype *typing.FuncType, branch *syntax.ASTBra
 								}
 
 								if argexpr, ok := w.walkExpr(arg.BranchAt(2)); ok {
-									w.solver.AddConstraint(farg.Val.Type, argexpr.Type(), typing.TCLeftCoerce, arg.Position())
+									w.solver.AddConstraintWithSides(farg.Val.Type, argexpr.Type(), typing.TCLeftCoerce, arg.Position(), branch.Position(), arg.Position())
 
 									argNodes[farg.Name] = argexpr
 									argDts[farg.Name] = argexpr.Type()
@@ -428,8 +448,13 @@ func (w *Walker) walkAtom(branch *syntax.ASTBranch) (common.HIRExpr, bool) {
 			if unsigned && long {
 				return newLiteral(atomCore, typing.PrimKindIntegral, typing.PrimIntU64), true
 			} else if unsigned {
+				uintType, ok := w.getLiteralDefault(w.uintType, "uint", atomCore.Position())
+				if !ok {
+					return nil, false
+				}
+
 				return w.newConstrainedLiteral(atomCore,
-					w.uintType, // default value
+					uintType, // default value
 					primitiveTypeTable[syntax.U8],
 					primitiveTypeTable[syntax.U16],
 					primitiveTypeTable[syntax.U32],
@@ -443,10 +468,25 @@ func (w *Walker) walkAtom(branch *syntax.ASTBranch) (common.HIRExpr, bool) {
 				), true
 			} else {
 				// Integer literals can be either floats or ints depending on usage
-				return w.newConstrainedLiteral(atomCore, w.intType, w.getCoreType("Numeric")), true
+				intType, ok := w.getLiteralDefault(w.intType, "int", atomCore.Position())
+				if !ok {
+					return nil, false
+				}
+
+				numericType, ok := w.getCoreType("Numeric", atomCore.Position())
+				if !ok {
+					return nil, false
+				}
+
+				return w.newConstrainedLiteral(atomCore, intType, numericType), true
 			}
 		case syntax.FLOATLIT:
-			return w.newConstrainedLiteral(atomCore, primitiveTypeTable[syntax.F32], w.getCoreType("Floating")), true
+			floatingType, ok := w.getCoreType("Floating", atomCore.Position())
+			if !ok {
+				return nil, false
+			}
+
+			return w.newConstrainedLiteral(atomCore, primitiveTypeTable[syntax.F32], floatingType), true
 		case syntax.NULL:
 			ut := w.solver.NewTypeVar(nil, atomCore.Position(), func() { w.logUndeterminedNull(atomCore.Position()) }, nil, -1)
 
@@ -2,6 +2,7 @@ package validate
 
 import (
 	"fmt"
+	"sort"
 
 	"whirlwind/common"
 	"whirlwind/logging"
@@ -207,10 +208,11 @@ func (w *Walker) walkTypeDef(dast *syntax.ASTBranch) (*common.HIRTypeDef, bool)
 		DefKind:    common.DefKindTypeDef,
 		DeclStatus: w.declStatus,
 		Constant:   true,
+		DocComment: dast.DocComment,
 	}
 
-	if !w.define(symbol) {
-		w.logRepeatDef(name, namePosition)
+	if !w.define(symbol, namePosition) {
+		w.logRepeatDef(name, namePosition, w.previousDefinition(name)...)
 		return nil, false
 	}
 
@@ -218,6 +220,7 @@ func (w *Walker) walkTypeDef(dast *syntax.ASTBranch) (*common.HIRTypeDef, bool)
 		Name:       name,
 		Type:       dt,
 		FieldInits: fieldInits,
+		DocComment: dast.DocComment,
 	}
 
 	symbol.DefNode = tdef
@@ -240,7 +243,7 @@ func (w *Walker) walkTypeDef(dast *syntax.ASTBranch) (*common.HIRTypeDef, bool)
 					DefNode:    tdef,
 				}
 
-				if !w.define(symbol) {
+				if !w.define(symbol, namePosition) {
 					w.logError(
 						fmt.Sprintf("Algebraic type `%s` must be marked `closed` as its variant `%s` shares a name with an already-defined symbol", name, vari.Name),
 						logging.LMKName,
@@ -326,9 +329,10 @@ func (w *Walker) walkStructSuffix(suffix *syntax.ASTBranch, name string, fieldIn
 				if fnames, tv, init, ok := w.walkTypeValues(branch, "fields"); ok {
 					// multiple fields can share the same type value and
 					// initializer (for efficiency)
-					for fname := range fnames {
+					for _, fname := range namesInOrder(fnames) {
 						// duplicates names already checked in `w.walkTypeValues`
 						structType.Fields[fname] = tv
+						structType.FieldOrder = append(structType.FieldOrder, fname)
 
 						if init != nil {
 							fieldInits[fname] = init
@@ -354,7 +358,7 @@ func (w *Walker) walkStructSuffix(suffix *syntax.ASTBranch, name string, fieldIn
 					} else {
 						// structs can only inherit from other structs
 						w.logError(
-							fmt.Sprintf("Struct `%s` must inherit from another struct not `%s`", name, dt.Repr()),
+							fmt.Sprintf("Struct `%s` must inherit from another struct not `%s`", name, w.solver.PackagePaths.ReprType(dt)),
 							logging.LMKDef,
 							branch.Position(),
 						)
@@ -370,6 +374,22 @@ func (w *Walker) walkStructSuffix(suffix *syntax.ASTBranch, name string, fieldIn
 	return structType, true
 }
 
+// namesInOrder sorts a map of names and positions (eg. from `walkIdList`) into
+// the order the names appear in the source text
+func namesInOrder(names map[string]*logging.TextPosition) []string {
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+
+	sort.Slice(sortedNames, func(i, j int) bool {
+		a, b := names[sortedNames[i]], names[sortedNames[j]]
+		return a.StartLn < b.StartLn || a.StartLn == b.StartLn && a.StartCol < b.StartCol
+	})
+
+	return sortedNames
+}
+
 // walkTypeValues walks any node that is of the form of a type value (ie.
 // `identifier_list` followed by `type_ext`) and generates a single common type
 // value and a map of names and positions from it.  It also handles `vol` and
@@ -420,6 +440,7 @@ func (w *Walker) walkFuncDef(branch *syntax.ASTBranch, isMethod bool) (*common.H
 	funcType := &typing.FuncType{Boxable: !w.hasFlag("intrinsic")}
 	var initializers map[string]common.HIRNode
 	var body common.HIRNode
+	var rtPos *logging.TextPosition
 
 	for _, item := range branch.Content {
 		switch v := item.(type) {
@@ -434,6 +455,10 @@ func (w *Walker) walkFuncDef(branch *syntax.ASTBranch, isMethod bool) (*common.H
 					funcType.Args = args
 					initializers = adata
 					funcType.ReturnType = rtType
+
+					if v.Len() == 2 {
+						rtPos = v.BranchAt(1).Position()
+					}
 				} else {
 					return nil, false
 				}
@@ -482,10 +507,11 @@ func (w *Walker) walkFuncDef(branch *syntax.ASTBranch, isMethod bool) (*common.H
 		DeclStatus: w.declStatus,
 		DefKind:    common.DefKindFuncDef,
 		Constant:   true,
+		DocComment: branch.DocComment,
 	}
 
-	if !isMethod && !w.define(sym) {
-		w.logRepeatDef(name, namePosition)
+	if !isMethod && !w.define(sym, namePosition) {
+		w.logRepeatDef(name, namePosition, w.previousDefinition(name)...)
 		return nil, false
 	}
 
@@ -495,6 +521,9 @@ func (w *Walker) walkFuncDef(branch *syntax.ASTBranch, isMethod bool) (*common.H
 		Annotations:  w.annotations,
 		Initializers: initializers,
 		Body:         body,
+		DocComment:   branch.DocComment,
+
+		ReturnTypePosition: rtPos,
 	}
 
 	sym.DefNode = fdef
@@ -648,10 +677,11 @@ func (w *Walker) walkInterfDef(branch *syntax.ASTBranch) (*common.HIRInterfDef,
 		DefKind:    common.DefKindTypeDef,
 		DeclStatus: w.declStatus,
 		Constant:   true,
+		DocComment: branch.DocComment,
 	}
 
-	if !w.define(sym) {
-		w.logRepeatDef(sym.Name, branch.Content[1].Position())
+	if !w.define(sym, branch.Content[1].Position()) {
+		w.logRepeatDef(sym.Name, branch.Content[1].Position(), w.previousDefinition(sym.Name)...)
 		return nil, false
 	}
 
@@ -661,9 +691,10 @@ func (w *Walker) walkInterfDef(branch *syntax.ASTBranch) (*common.HIRInterfDef,
 	w.interfGenericCtx = nil
 
 	return &common.HIRInterfDef{
-		Name:    it.Name,
-		Type:    it,
-		Methods: methodNodes,
+		Name:       it.Name,
+		Type:       it,
+		Methods:    methodNodes,
+		DocComment: branch.DocComment,
 	}, true
 }
 
@@ -677,7 +708,10 @@ func (w *Walker) walkInterfBind(branch *syntax.ASTBranch) (common.HIRNode, bool)
 	var bindDt typing.DataType
 	var methodNodes []common.HIRNode
 
-	implInterfs := make(map[*typing.InterfType]*logging.TextPosition)
+	// the interfaces the binding derives are stored in the order they are
+	// listed so that they are derived in that order
+	var implInterfs []*typing.InterfType
+	var implPositions []*logging.TextPosition
 
 	var bindTypePos *logging.TextPosition
 	for _, item := range branch.Content {
@@ -707,7 +741,7 @@ func (w *Walker) walkInterfBind(branch *syntax.ASTBranch) (common.HIRNode, bool)
 						// erroring first)
 						case *typing.InterfType, *typing.RefType:
 							w.logError(
-								fmt.Sprintf("Cannot bind interface onto type `%s`", dt.Repr()),
+								fmt.Sprintf("Cannot bind interface onto type `%s`", w.solver.PackagePaths.ReprType(dt)),
 								logging.LMKInterf,
 								itembranch.Position(),
 							)
@@ -721,10 +755,11 @@ func (w *Walker) walkInterfBind(branch *syntax.ASTBranch) (common.HIRNode, bool)
 						// here
 						bindDt = typing.InnerType(dt)
 					} else if implIt, ok := typing.InnerType(dt).(*typing.InterfType); ok {
-						implInterfs[implIt] = itembranch.Position()
+						implInterfs = append(implInterfs, implIt)
+						implPositions = append(implPositions, itembranch.Position())
 					} else {
 						w.logError(
-							fmt.Sprintf("Binding may only derive interfaces not `%s`", dt.Repr()),
+							fmt.Sprintf("Binding may only derive interfaces not `%s`", w.solver.PackagePaths.ReprType(dt)),
 							logging.LMKInterf,
 							itembranch.Position(),
 						)
@@ -744,6 +779,8 @@ func (w *Walker) walkInterfBind(branch *syntax.ASTBranch) (common.HIRNode, bool)
 		}
 	}
 
+	it.BoundType = bindDt
+
 	// typeInterf is the final data type created (once generics are applied)
 	var typeInterf typing.DataType
 
@@ -799,14 +836,15 @@ func (w *Walker) walkInterfBind(branch *syntax.ASTBranch) (common.HIRNode, bool)
 	w.interfGenericCtx = nil
 
 	// check and apply any of our explicit implementations
-	for implInterf, pos := range implInterfs {
+	for i, implInterf := range implInterfs {
 		if w.solver.ImplementsInterf(bindDt, implInterf) {
 			w.solver.Derive(it, implInterf)
 		} else {
+			reprs := w.solver.PackagePaths.ReprTypes(bindDt, implInterf)
 			w.logError(
-				fmt.Sprintf("Type interface for `%s` does not fully implement interface `%s`", bindDt.Repr(), implInterf.Repr()),
+				fmt.Sprintf("Type interface for `%s` does not fully implement interface `%s`", reprs[0], reprs[1]),
 				logging.LMKInterf,
-				pos,
+				implPositions[i],
 			)
 		}
 	}
@@ -821,7 +859,7 @@ func (w *Walker) checkBinding(binding *typing.Binding, bindTypePos *logging.Text
 		w.logError(
 			fmt.Sprintf("Multiple implementations given for method `%s` bound to `%s`",
 				mname,
-				binding.MatchType.Repr(),
+				w.solver.PackagePaths.ReprType(binding.MatchType),
 			),
 			logging.LMKImport,
 			bindTypePos,
@@ -876,6 +914,7 @@ func (w *Walker) walkInterfBody(body *syntax.ASTBranch, it *typing.InterfType, c
 			var node common.HIRNode
 			var dt typing.DataType
 			var methodKind int
+			var docComment string
 
 			methodBranch := interfMember.BranchAt(0)
 			switch methodBranch.Name {
@@ -895,6 +934,7 @@ func (w *Walker) walkInterfBody(body *syntax.ASTBranch, it *typing.InterfType, c
 					name = fnnode.Name
 					dt = fnnode.Type
 					node = fnnode
+					docComment = fnnode.DocComment
 
 					// the name of a function is always the second node
 					namePosition = methodBranch.Content[1].Position()
@@ -949,8 +989,9 @@ func (w *Walker) walkInterfBody(body *syntax.ASTBranch, it *typing.InterfType, c
 			}
 
 			it.Methods[name] = &typing.InterfMethod{
-				Signature: dt,
-				Kind:      methodKind,
+				Signature:  dt,
+				Kind:       methodKind,
+				DocComment: docComment,
 			}
 
 			methodNodes = append(methodNodes, node)
@@ -1405,7 +1446,7 @@ func (w *Walker) walkOperatorDef(branch *syntax.ASTBranch) (common.HIRNode, bool
 func (w *Walker) defineOperator(opkind int, sig typing.DataType, opValue string, argPos *logging.TextPosition) bool {
 	if sig, isConflict := w.SrcPackage.CheckOperatorConflicts(w.SrcFile, opkind, sig); isConflict {
 		w.logError(
-			fmt.Sprintf("Operator definition for `%s` conflicts with preexisting definition with signature `%s`", opValue, sig.Repr()),
+			fmt.Sprintf("Operator definition for `%s` conflicts with preexisting definition with signature `%s`", opValue, w.solver.PackagePaths.ReprType(sig)),
 			logging.LMKDef,
 			argPos,
 		)
@@ -1447,8 +1488,8 @@ func (w *Walker) walkConsDef(branch *syntax.ASTBranch) (*common.HIRConsDef, bool
 			Constant:   true,
 		}
 
-		if !w.define(sym) {
-			w.logRepeatDef(sym.Name, nameLeaf.Position())
+		if !w.define(sym, nameLeaf.Position()) {
+			w.logRepeatDef(sym.Name, nameLeaf.Position(), w.previousDefinition(sym.Name)...)
 			return nil, false
 		}
 
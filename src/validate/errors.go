@@ -7,9 +7,35 @@ import (
 	"whirlwind/typing"
 )
 
-// LogUndefined logs an undefined error for the given symbol.
-func (w *Walker) LogUndefined(name string, pos *logging.TextPosition) {
-	logging.LogCompileError(w.Context, fmt.Sprintf("Symbol `%s` undefined", name), logging.LMKName, pos)
+// LogUndefined logs an undefined error for the given symbol.  It suggests the
+// visible name closest to the undefined name if there is one.  Any additional
+// names that should be considered (eg. definitions that haven't been resolved
+// yet) can also be passed in.
+func (w *Walker) LogUndefined(name string, pos *logging.TextPosition, extraCandidates ...string) {
+	logging.LogCompileError(
+		w.Context,
+		fmt.Sprintf("Symbol `%s` undefined", name),
+		logging.LMKName,
+		pos,
+		logging.WithSuggestion(name, append(w.visibleNames(), extraCandidates...)),
+	)
+}
+
+// logMissingLiteralType logs an error indicating that a type required by a
+// literal is not defined.  These types are normally provided by the prelude.
+func (w *Walker) logMissingLiteralType(name string, pos *logging.TextPosition) {
+	annotations := []logging.Annotation{logging.WithNote(fmt.Sprintf("`%s` is normally provided by the prelude", name))}
+	if _, ok := w.SrcFile.MetadataTags["no_prelude"]; ok {
+		annotations = append(annotations, logging.WithHelp("this file disables the prelude with `!! no_prelude`"))
+	}
+
+	logging.LogCompileError(
+		w.Context,
+		fmt.Sprintf("Literal requires the type `%s` which is not defined", name),
+		logging.LMKTyping,
+		pos,
+		annotations...,
+	)
 }
 
 // LogNotVisibleInPackage logs an import error in which is a symbol is not able
@@ -23,16 +49,47 @@ func (w *Walker) LogNotVisibleInPackage(symname, pkgname string, pos *logging.Te
 	)
 }
 
-// logRepeatDef logs an error indicate that a symbol has already been defined
-func (w *Walker) logRepeatDef(name string, pos *logging.TextPosition) {
+// logRepeatDef logs an error indicate that a symbol has already been defined.
+// The annotations should point to the previous definition if it is known.
+func (w *Walker) logRepeatDef(name string, pos *logging.TextPosition, annotations ...logging.Annotation) {
 	logging.LogCompileError(
 		w.Context,
 		fmt.Sprintf("Symbol `%s` already defined", name),
 		logging.LMKName,
 		pos,
+		append([]logging.Annotation{logging.WithLabel("redefined here")}, annotations...)...,
 	)
 }
 
+// previousDefinition creates the annotations pointing to whatever a global
+// symbol name is already used for: a global definition, an import or a package
+func (w *Walker) previousDefinition(name string) []logging.Annotation {
+	if sym, ok := w.SrcPackage.GlobalTable[name]; ok && sym.DefPosition != nil {
+		return []logging.Annotation{
+			logging.WithSpanIn(sym.DefFilePath, sym.DefPosition, fmt.Sprintf("`%s` first defined here", name)),
+		}
+	}
+
+	if wsi, ok := w.SrcFile.LocalTable[name]; ok {
+		if wsi.Position != nil {
+			return []logging.Annotation{
+				logging.WithSpan(wsi.Position, fmt.Sprintf("`%s` imported here", name)),
+			}
+		}
+
+		return []logging.Annotation{logging.WithNote(fmt.Sprintf("`%s` is imported by a namespace import", name))}
+	}
+
+	if _, ok := w.SrcFile.VisiblePackages[name]; ok {
+		return []logging.Annotation{
+			logging.WithNote(fmt.Sprintf("a package named `%s` is visible in this file", name)),
+			logging.WithHelp("rename the definition or import the package under a different name"),
+		}
+	}
+
+	return nil
+}
+
 // logInvalidIntrinsic marks that the given named type cannot be intrinsic.
 // Sets `fatalDefError`.
 func (w *Walker) logInvalidIntrinsic(name, kind string, pos *logging.TextPosition) {
@@ -43,12 +100,18 @@ func (w *Walker) logInvalidIntrinsic(name, kind string, pos *logging.TextPositio
 	)
 }
 
-// logCoercionError logs an error coercing from one type to another
-func (w *Walker) logCoercionError(src, dest typing.DataType, pos *logging.TextPosition) {
+// logCoercionError logs an error coercing from one type to another.  `destPos`
+// is the position of whatever required the destination type (eg. a return type
+// label) and can be `nil` if there is no such position.
+func (w *Walker) logCoercionError(src, dest typing.DataType, pos, destPos *logging.TextPosition) {
+	reprs := w.solver.PackagePaths.ReprTypes(src, dest)
+
 	w.logError(
-		fmt.Sprintf("Unable to coerce from `%s` to `%s`", src.Repr(), dest.Repr()),
+		fmt.Sprintf("Unable to coerce from `%s` to `%s`", reprs[0], reprs[1]),
 		logging.LMKTyping,
 		pos,
+		logging.WithLabel(fmt.Sprintf("this has type `%s`", reprs[0])),
+		logging.WithSpan(destPos, fmt.Sprintf("expected `%s` because of this", reprs[1])),
 	)
 }
 
@@ -56,7 +119,7 @@ func (w *Walker) logCoercionError(src, dest typing.DataType, pos *logging.TextPo
 // not be determined by the solver
 func (w *Walker) logUnsolvableGenericTypeParam(gt *typing.GenericType, name string, pos *logging.TextPosition) {
 	w.logError(
-		fmt.Sprintf("Unable to infer type for generic parameter `%s` of `%s`", name, gt.Repr()),
+		fmt.Sprintf("Unable to infer type for generic parameter `%s` of `%s`", name, w.solver.PackagePaths.ReprType(gt)),
 		logging.LMKTyping,
 		pos,
 	)
@@ -72,11 +135,12 @@ func (w *Walker) logUndeterminedNull(pos *logging.TextPosition) {
 }
 
 // logError logs an error of any kind within the walker's file
-func (w *Walker) logError(message string, kind int, pos *logging.TextPosition) {
+func (w *Walker) logError(message string, kind int, pos *logging.TextPosition, annotations ...logging.Annotation) {
 	logging.LogCompileError(
 		w.Context,
 		message,
 		kind,
 		pos,
+		annotations...,
 	)
 }
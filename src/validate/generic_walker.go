@@ -76,29 +76,13 @@ func (w *Walker) createGenericInstance(generic typing.DataType, genericPos *logg
 			return nil, ok
 		}
 	case *typing.OpaqueGenericType:
-		if v.EvalType == nil {
-			ogi := &typing.OpaqueGenericInstanceType{
-				OpaqueGeneric:    v,
-				TypeParams:       params,
-				TypeParamsBranch: paramsBranch,
-			}
-
-			v.Instances = append(v.Instances, ogi)
-
-			return ogi, true
-		}
-
-		if gi, ok := w.solver.CreateGenericInstance(v.EvalType, params, paramsBranch); ok {
-			return gi, ok
-		} else {
-			return nil, ok
-		}
+		return w.solver.CreateOpaqueGenericInstance(v, params, paramsBranch)
 		// TODO: Wildcard generics
 	}
 
 	// not a generic type -- error
 	w.logError(
-		fmt.Sprintf("Unable to pass type parameters to non-generic type `%s`", generic.Repr()),
+		fmt.Sprintf("Unable to pass type parameters to non-generic type `%s`", w.solver.PackagePaths.ReprType(generic)),
 		logging.LMKTyping,
 		genericPos,
 	)
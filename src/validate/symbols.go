@@ -1,7 +1,10 @@
 package validate
 
 import (
+	"strings"
+
 	"whirlwind/common"
+	"whirlwind/logging"
 	"whirlwind/typing"
 )
 
@@ -16,6 +19,15 @@ func (w *Walker) globalLookup(name string) (*common.Symbol, bool) {
 	}
 
 	if wsi, ok := w.SrcFile.LocalTable[name]; ok {
+		// imports that are only used by indeterminate definitions (eg. in
+		// function signatures) are never looked up by the resolver so we
+		// update their shared symbol reference here
+		if wsi.SymbolRef.Name == "" {
+			if isym, ok := wsi.SrcPackage.ImportFromNamespace(name); ok {
+				*wsi.SymbolRef = *isym
+			}
+		}
+
 		// all unresolved imports should be pruned by this point
 		return wsi.SymbolRef, true
 	}
@@ -52,8 +64,9 @@ func (w *Walker) localLookup(name string) (*common.Symbol, bool) {
 }
 
 // define defines a new symbol in the global namespace of a package (returns false
-// if the symbol if already defined).  It does not log an error.
-func (w *Walker) define(sym *common.Symbol) bool {
+// if the symbol if already defined).  It does not log an error.  The position is
+// the position of the name of the symbol's definition.
+func (w *Walker) define(sym *common.Symbol, pos *logging.TextPosition) bool {
 	if _, ok := w.SrcPackage.GlobalTable[sym.Name]; ok {
 		return false
 	}
@@ -70,10 +83,56 @@ func (w *Walker) define(sym *common.Symbol) bool {
 	}
 
 	// if it is not already defined, stick it in the global table
+	sym.DefPosition = pos
+	sym.DefFilePath = w.Context.FilePath
 	w.SrcPackage.GlobalTable[sym.Name] = sym
 	return true
 }
 
+// visibleNames returns all of the names that are currently visible: those in
+// the local scopes (including function arguments), the generic contexts, the
+// global table, the local table and the visible packages
+func (w *Walker) visibleNames() []string {
+	var names []string
+
+	for _, scope := range w.scopeStack {
+		for name := range scope.Symbols {
+			names = append(names, name)
+		}
+
+		if scope.FuncCtx != nil {
+			for _, arg := range scope.FuncCtx.Args {
+				// unnamed arguments are named by their position (eg. `$0`)
+				if !strings.HasPrefix(arg.Name, "$") {
+					names = append(names, arg.Name)
+				}
+			}
+		}
+	}
+
+	for _, wc := range w.genericCtx {
+		names = append(names, wc.Name)
+	}
+
+	for _, wc := range w.interfGenericCtx {
+		names = append(names, wc.Name)
+	}
+
+	for name := range w.SrcPackage.GlobalTable {
+		names = append(names, name)
+	}
+
+	for name := range w.SrcFile.LocalTable {
+		names = append(names, name)
+	}
+
+	for name := range w.SrcFile.VisiblePackages {
+		names = append(names, name)
+	}
+
+	return names
+}
+
 // implicitImport attempts to perform an implicit import of a symbol from a visible package
 func (w *Walker) implicitImport(ipkg *common.WhirlPackage, name string) (*common.Symbol, bool) {
 	// avoid repeated imports if possible
@@ -92,7 +151,25 @@ func (w *Walker) implicitImport(ipkg *common.WhirlPackage, name string) (*common
 	return nsym, ok
 }
 
-// getCoreType loads a core/prelude-defined type from the local table
-func (w *Walker) getCoreType(name string) typing.DataType {
-	return w.SrcFile.LocalTable[name].SymbolRef.Type
+// getCoreType loads a core/prelude-defined type from the local table.  If the
+// type isn't imported (eg. because the prelude is disabled), an error is
+// logged for the literal at the given position that requires it.
+func (w *Walker) getCoreType(name string, pos *logging.TextPosition) (typing.DataType, bool) {
+	if wsi, ok := w.SrcFile.LocalTable[name]; ok {
+		return wsi.SymbolRef.Type, true
+	}
+
+	w.logMissingLiteralType(name, pos)
+	return nil, false
+}
+
+// getLiteralDefault checks that the default type of a literal (`int` or
+// `uint`) is defined.  If it isn't, an error is logged for the literal.
+func (w *Walker) getLiteralDefault(dt typing.DataType, name string, pos *logging.TextPosition) (typing.DataType, bool) {
+	if dt == nil {
+		w.logMissingLiteralType(name, pos)
+		return nil, false
+	}
+
+	return dt, true
 }
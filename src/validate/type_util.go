@@ -5,6 +5,24 @@ import (
 	"whirlwind/typing"
 )
 
+// solveTypeContext runs the solver on the type context built while walking an
+// expression.  If the expression is not valid, the context is simply
+// discarded.  It returns a flag indicating whether solving succeeded.
+func (w *Walker) solveTypeContext(valid bool) bool {
+	if !valid {
+		w.solver.Reset()
+		return false
+	}
+
+	if w.traceLabel != "" {
+		trace := typing.NewSolverTrace(w.traceLabel, w.Context.FilePath)
+		w.solver.Trace = trace
+		w.traces = append(w.traces, trace)
+	}
+
+	return w.solver.Solve()
+}
+
 // coerceTo performs a coercion check from the type of HIRExpr to the given type
 func (w *Walker) coerceTo(expr common.HIRExpr, dest typing.DataType) bool {
 	return w.solver.CoerceTo(expr.Type(), dest)
@@ -79,7 +79,7 @@ func (w *Walker) walkTypeLabel(label *syntax.ASTBranch) (typing.DataType, bool)
 		// return false.
 		if requiresRef {
 			w.logError(
-				fmt.Sprintf("The type `%s` can only be stored by reference here", dt.Repr()),
+				fmt.Sprintf("The type `%s` can only be stored by reference here", w.solver.PackagePaths.ReprType(dt)),
 				logging.LMKTyping,
 				label.Position(),
 			)
@@ -105,7 +105,7 @@ func (w *Walker) walkGenericTypeConstraint(param *syntax.ASTBranch) ([]typing.Da
 			// required as so we can simply return false.
 			if requiresRef {
 				w.logError(
-					fmt.Sprintf("The type `%s` can only be stored by reference here", dt.Repr()),
+					fmt.Sprintf("The type `%s` can only be stored by reference here", w.solver.PackagePaths.ReprType(dt)),
 					logging.LMKTyping,
 					param.Content[i].Position(),
 				)
@@ -1,7 +1,10 @@
 package validate
 
 import (
+	"fmt"
+
 	"whirlwind/common"
+	"whirlwind/logging"
 	"whirlwind/syntax"
 	"whirlwind/typing"
 )
@@ -13,16 +16,32 @@ import (
 // Walker which does the actual checking.  It works to coordinate the walker
 type PredicateValidator struct {
 	walkers []*Walker
+
+	// traceFuncName is the name of the function whose type contexts should be
+	// traced.  It is empty if no function should be traced.
+	traceFuncName string
+}
+
+func NewPredicateValidator(walkers []*Walker) *PredicateValidator {
+	return &PredicateValidator{walkers: walkers}
 }
 
-func NewPredicateValidator(walkers map[*common.WhirlFile]*Walker) *PredicateValidator {
-	pv := &PredicateValidator{}
+// TraceTypes indicates to the validator that the solving of the type contexts
+// of the function with the given name (its body and initializers) should be
+// traced.  The traces can be retrieved once validation is complete.
+func (pv *PredicateValidator) TraceTypes(funcName string) {
+	pv.traceFuncName = funcName
+}
 
-	for _, w := range walkers {
-		pv.walkers = append(pv.walkers, w)
+// Traces returns all of the solver traces recorded during validation: they are
+// ordered by file and then by the order they were recorded in
+func (pv *PredicateValidator) Traces() []*typing.SolverTrace {
+	var traces []*typing.SolverTrace
+	for _, w := range pv.walkers {
+		traces = append(traces, w.traces...)
 	}
 
-	return pv
+	return traces
 }
 
 // Validate runs the predicate validation algorithm on the given package. All
@@ -54,22 +73,25 @@ func (pv *PredicateValidator) validateNode(w *Walker, node common.HIRNode) {
 	case *common.HIRFuncDef:
 		// make sure the function body is not empty before walking it
 		if v.Body != nil {
-			if body, ok := w.walkFuncBody(v.Body.(*common.HIRIncomplete), v.Type); ok {
+			pv.traceContext(w, v.Name, fmt.Sprintf("body of `%s`", v.Name))
+			if body, ok := w.walkFuncBody(v.Body.(*common.HIRIncomplete), v.Type, v.ReturnTypePosition); ok {
 				v.Body = body
 			}
 		}
 
-		// handle argument initializers
-		for name, init := range v.Initializers {
-			for _, arg := range v.Type.Args {
-				if arg.Name == name {
-					if expr, ok := w.walkInitializer(init.(*common.HIRIncomplete), arg.Val.Type); ok {
-						v.Initializers[name] = expr
-					}
+		// handle argument initializers (in the order of the arguments so that
+		// they are always validated in the same order)
+		for _, arg := range v.Type.Args {
+			if init, ok := v.Initializers[arg.Name]; ok {
+				pv.traceContext(w, v.Name, fmt.Sprintf("initializer of `%s` in `%s`", arg.Name, v.Name))
+				if expr, ok := w.walkInitializer(init.(*common.HIRIncomplete), arg.Val.Type); ok {
+					v.Initializers[arg.Name] = expr
 				}
 			}
 		}
 
+		w.traceLabel = ""
+
 	case *common.HIROperDef:
 		// walk func body and initializers
 	case *common.HIRInterfDef:
@@ -83,10 +105,22 @@ func (pv *PredicateValidator) validateNode(w *Walker, node common.HIRNode) {
 	}
 }
 
+// traceContext sets the trace label of the walker for the next type context
+// it walks: the context is only traced if it belongs to the function being
+// traced (see `TraceTypes`)
+func (pv *PredicateValidator) traceContext(w *Walker, funcName, label string) {
+	if pv.traceFuncName != "" && funcName == pv.traceFuncName {
+		w.traceLabel = label
+	} else {
+		w.traceLabel = ""
+	}
+}
+
 // walkFuncBody walks a branch (wrapped in a HIRIncomplete) that was stored as a
 // function body.  It also accepts the data type (signature) of the function
-// whose body is walks -- this is used as the function context
-func (w *Walker) walkFuncBody(inc *common.HIRIncomplete, fn *typing.FuncType) (common.HIRNode, bool) {
+// whose body is walks -- this is used as the function context -- and the
+// position of its return type label (if it has one) for error reporting
+func (w *Walker) walkFuncBody(inc *common.HIRIncomplete, fn *typing.FuncType, rtPos *logging.TextPosition) (common.HIRNode, bool) {
 	// create our contextual function scope
 	w.pushFuncScope(fn)
 
@@ -95,11 +129,11 @@ func (w *Walker) walkFuncBody(inc *common.HIRIncomplete, fn *typing.FuncType) (c
 
 	branch := (*syntax.ASTBranch)(inc)
 	if branch.Name == "expr" {
-		if expr, ok := w.walkExpr(branch); ok {
+		if expr, ok := w.walkExpr(branch); w.solveTypeContext(ok) {
 			if w.coerceTo(expr, fn.ReturnType) {
 				return expr.(common.HIRNode), true
 			} else {
-				w.logCoercionError(expr.Type(), fn.ReturnType, branch.Position())
+				w.logCoercionError(expr.Type(), fn.ReturnType, branch.Position(), rtPos)
 			}
 		}
 	} else {
@@ -112,11 +146,11 @@ func (w *Walker) walkFuncBody(inc *common.HIRIncomplete, fn *typing.FuncType) (c
 // walkInitializer is used to walk an initializer branch (wrapped in a
 // HIRIncomplete) and check it against the expected type it was given.
 func (w *Walker) walkInitializer(inc *common.HIRIncomplete, expected typing.DataType) (common.HIRNode, bool) {
-	if expr, ok := w.walkExpr((*syntax.ASTBranch)(inc)); ok {
+	if expr, ok := w.walkExpr((*syntax.ASTBranch)(inc)); w.solveTypeContext(ok) {
 		if w.coerceTo(expr, expected) {
 			return expr.(common.HIRNode), true
 		} else {
-			w.logCoercionError(expr.Type(), expected, (*syntax.ASTBranch)(inc).Position())
+			w.logCoercionError(expr.Type(), expected, (*syntax.ASTBranch)(inc).Position(), nil)
 		}
 	}
 
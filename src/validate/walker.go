@@ -81,10 +81,20 @@ type Walker struct {
 	// uintType stores a reference to the "base" unsigned integral type (`uint`)
 	// for the given application (varys based on architecture)
 	uintType typing.DataType
+
+	// traceLabel is the label of the type context currently being walked if
+	// the solving of that context should be traced (see `typing.SolverTrace`).
+	// It is empty if no tracing should occur.
+	traceLabel string
+
+	// traces stores all of the solver traces recorded by the walker in the
+	// order they were recorded
+	traces []*typing.SolverTrace
 }
 
-// NewWalker creates a new walker for the given package and file
-func NewWalker(pkg *common.WhirlPackage, file *common.WhirlFile, fpath string, ost common.OpaqueSymbolTable) *Walker {
+// NewWalker creates a new walker for the given package and file.  `pp` is used
+// to qualify types in the walker's diagnostics.
+func NewWalker(pkg *common.WhirlPackage, file *common.WhirlFile, fpath string, ost common.OpaqueSymbolTable, pp typing.PackagePaths) *Walker {
 	// initialize the files local binding registry (may decide to remove this as
 	// a file field if it is not helpful/necessary and instead embed as a walker
 	// field)
@@ -103,7 +113,7 @@ func NewWalker(pkg *common.WhirlPackage, file *common.WhirlFile, fpath string, o
 		SrcFile:                 file,
 		Context:                 lctx,
 		declStatus:              common.DSInternal,
-		solver:                  typing.NewSolver(lctx, file.LocalBindings, pkg.GlobalBindings),
+		solver:                  typing.NewSolver(lctx, file.LocalBindings, pkg.GlobalBindings, pp),
 		resolving:               true, // start in resolution by default
 		sharedOpaqueSymbolTable: ost,
 	}
@@ -114,18 +124,16 @@ func (w *Walker) ResolutionDone() {
 	w.resolving = false
 	w.sharedOpaqueSymbolTable = nil
 
-	// only attempt to load `int` and `uint` if resolution suceeded
-	if logging.ShouldProceed() {
+	// only attempt to load `int` and `uint` if resolution suceeded.  They may
+	// not be defined (eg. if the prelude is disabled) in which case an error
+	// is logged by any literal that needs them.
+	if w.Context.ShouldProceed() {
 		if intTypeSym, ok := w.globalLookup("int"); ok {
 			w.intType = intTypeSym.Type
-		} else {
-			logging.LogFatal("Missing definition for `int`")
 		}
 
 		if uintTypeSym, ok := w.globalLookup("uint"); ok {
 			w.uintType = uintTypeSym.Type
-		} else {
-			logging.LogFatal("Missing definition for `uint`")
 		}
 	}
 }